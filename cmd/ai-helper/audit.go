@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amaslovskyi/ai-helper/pkg/audit"
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
+	"github.com/amaslovskyi/ai-helper/pkg/llm"
+	"github.com/amaslovskyi/ai-helper/pkg/security"
+	"github.com/amaslovskyi/ai-helper/pkg/session"
+	"github.com/amaslovskyi/ai-helper/pkg/ui"
+)
+
+// logAudit appends a record; failures are reported but never fatal
+func logAudit(auditLog *audit.Log, rec audit.Record) {
+	if err := auditLog.Append(rec); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Failed to write audit log: %v", err))
+	}
+}
+
+// recordSuggestion logs a suggestion that was shown and remembers it so
+// the shell integration can report when it is executed
+func recordSuggestion(auditLog *audit.Log, rec audit.Record, resp *llm.Response, sessions *session.Store) {
+	if rec.Suggestion == "" {
+		rec.Suggestion = resp.Suggestion
+	}
+	if rec.Model == "" {
+		rec.Model = string(resp.Model)
+	}
+	logAudit(auditLog, rec)
+
+	if resp.Suggestion != "" {
+		_ = sessions.RecordSuggestion(rec.Session, resp.Suggestion)
+	}
+}
+
+// whoami names the user acknowledging a risk
+func whoami() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// allowSuggestion adds an acknowledged suggestion to the project's
+// allowlist: the nearest .ai-helper-policy.yaml, or a new one at the
+// repository root (or the current directory outside a repository)
+func allowSuggestion(suggestion string, result *security.ScanResult, ack *audit.Acknowledgement) {
+	if security.ContainsSecret(suggestion) {
+		ui.PrintWarning("Not added to the allowlist: the suggestion contains a secret")
+		return
+	}
+
+	cwd, _ := os.Getwd()
+	file := envctx.FindUp(cwd, security.PolicyFileName)
+	if file == "" {
+		root := envctx.FindRepoRoot(cwd)
+		if root == "" {
+			root = cwd
+		}
+		file = filepath.Join(root, security.PolicyFileName)
+	}
+
+	// One entry per command with findings, since entries only cover the
+	// command they name
+	var allows []*security.Allow
+	byCommand := map[string]*security.Allow{}
+	seen := map[string]bool{}
+	for _, finding := range result.Findings {
+		command := security.FindingText(suggestion, finding)
+		allow, ok := byCommand[command]
+		if !ok {
+			allow = &security.Allow{
+				Command:        command,
+				Reason:         "acknowledged at the prompt",
+				AcknowledgedBy: ack.By,
+				AcknowledgedAt: ack.At,
+			}
+			byCommand[command] = allow
+			allows = append(allows, allow)
+		}
+		if !seen[command+"\x00"+finding.Rule.ID] {
+			seen[command+"\x00"+finding.Rule.ID] = true
+			allow.Rules = append(allow.Rules, finding.Rule.ID)
+		}
+	}
+
+	for _, allow := range allows {
+		if err := security.AddAllow(file, allow); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Failed to update allowlist: %v", err))
+			return
+		}
+	}
+	ui.PrintInfo(fmt.Sprintf("Added to the allowlist in %s", file))
+}
+
+// handleAudit dispatches audit subcommands
+func handleAudit(auditLog *audit.Log, sessions *session.Store, sessionID string) {
+	if len(os.Args) < 3 {
+		ui.PrintError("Usage: ai-helper audit verify | exec <command> <exit_code> [started]")
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "verify":
+		handleAuditVerify(auditLog)
+	case "exec":
+		handleAuditExec(auditLog, sessions, sessionID)
+	default:
+		ui.PrintError(fmt.Sprintf("Unknown audit command: %s", os.Args[2]))
+		os.Exit(1)
+	}
+}
+
+// handleAuditVerify checks the hash chain of the audit log
+func handleAuditVerify(auditLog *audit.Log) {
+	count, head, err := auditLog.Verify()
+	if err != nil {
+		var verr *audit.VerifyError
+		if errors.As(err, &verr) {
+			ui.PrintError(fmt.Sprintf("Audit log has been tampered with: %s:%v", auditLog.Location(), err))
+			ui.PrintInfo(fmt.Sprintf("The first %d record(s) are intact", count))
+		} else {
+			ui.PrintError(fmt.Sprintf("Failed to verify audit log: %v", err))
+		}
+		os.Exit(1)
+	}
+
+	if count == 0 {
+		ui.PrintInfo(fmt.Sprintf("Audit log is empty (%s)", auditLog.Location()))
+		return
+	}
+	ui.PrintSuccess(fmt.Sprintf("Audit log intact: %d record(s) in %s", count, auditLog.Location()))
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Head:"), head)
+	fmt.Println(ui.Colorize(ui.Dim, "  Keep the head hash elsewhere to detect truncation later"))
+}
+
+// handleAuditExec is called by the shell integration after a command
+// runs: if it is the session's last suggestion, the execution is logged
+func handleAuditExec(auditLog *audit.Log, sessions *session.Store, sessionID string) {
+	if len(os.Args) < 5 {
+		ui.PrintError("Usage: ai-helper audit exec <command> <exit_code> [started]")
+		os.Exit(1)
+	}
+
+	command := os.Args[3]
+	exitCode, err := strconv.Atoi(os.Args[4])
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Invalid exit code %q", os.Args[4]))
+		os.Exit(1)
+	}
+
+	suggestion, recorded, err := sessions.Suggestion(sessionID)
+	if err != nil || suggestion == "" {
+		return
+	}
+
+	// A suggestion made while the command ran (ask, ai) is not its origin
+	if len(os.Args) > 5 {
+		if started, err := strconv.ParseFloat(os.Args[5], 64); err == nil {
+			if recorded.After(time.Unix(0, int64(started*float64(time.Second)))) {
+				return
+			}
+		}
+	}
+
+	_ = sessions.ForgetSuggestion(sessionID)
+	if strings.TrimSpace(command) != strings.TrimSpace(suggestion) {
+		return
+	}
+
+	logAudit(auditLog, audit.Record{
+		Event:      audit.EventExec,
+		Session:    sessionID,
+		Command:    command,
+		ExitCode:   &exitCode,
+		Suggestion: suggestion,
+		Choice:     "executed",
+	})
+}
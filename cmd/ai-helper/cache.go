@@ -0,0 +1,440 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amaslovskyi/ai-helper/pkg/cache"
+	"github.com/amaslovskyi/ai-helper/pkg/config"
+	"github.com/amaslovskyi/ai-helper/pkg/ui"
+)
+
+// handleCacheExport writes a redacted, shareable pack of personal cache entries
+func handleCacheExport(cacheStore *cache.Cache) {
+	flags, positional := parseFlags(os.Args[2:], "--author")
+	author := flags["--author"]
+
+	if len(positional) != 1 {
+		ui.PrintError("Usage: ai-helper cache-export <file|-> [--author <name>]")
+		os.Exit(1)
+	}
+
+	if author == "" {
+		author = defaultAuthor()
+	}
+
+	file := positional[0]
+	pack, err := cacheStore.Export(author, version)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to export cache: %v", err))
+		os.Exit(1)
+	}
+	if err := cache.WritePack(pack, file); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to export cache: %v", err))
+		os.Exit(1)
+	}
+
+	if file != "-" {
+		ui.PrintSuccess(fmt.Sprintf("Exported %d patterns to %s", len(pack.Entries), file))
+	}
+}
+
+// handleCacheMigrate copies personal entries to another storage backend
+// and switches the configuration over to it
+func handleCacheMigrate(cacheStore *cache.Cache, configFile, aiDir string) {
+	if len(os.Args) != 3 || !config.ValidateCacheBackend(os.Args[2]) {
+		ui.PrintError("Usage: ai-helper cache-migrate <json|bolt>")
+		os.Exit(1)
+	}
+
+	to := os.Args[2]
+	from := cacheStore.Backend()
+	if from == to {
+		ui.PrintInfo(fmt.Sprintf("Cache already uses the %s backend", to))
+		return
+	}
+
+	// Release the current store so the migration can open it
+	if err := cacheStore.Close(); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to close cache: %v", err))
+		os.Exit(1)
+	}
+
+	count, err := cache.Migrate(aiDir, from, to)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to migrate cache: %v", err))
+		os.Exit(1)
+	}
+
+	// Only the user file is updated; project files and env are left alone
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+	if err := cfg.Set("cache_backend", to); err != nil {
+		ui.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if err := cfg.Save(configFile); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to save config: %v", err))
+		os.Exit(1)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Migrated %d entries from %s to %s", count, from, to))
+	ui.PrintInfo(fmt.Sprintf("The old store is kept at %s", cache.StoreFile(aiDir, from)))
+}
+
+// handleCacheImport merges a team pack, lists imports, or rolls one back
+func handleCacheImport(cacheStore *cache.Cache) {
+	if len(os.Args) < 3 {
+		fmt.Println(ui.Colorize(ui.Red, "Usage: ai-helper cache-import <file>"))
+		fmt.Println("       ai-helper cache-import --list")
+		fmt.Println("       ai-helper cache-import --rollback <import-id>")
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "--list":
+		imports := cacheStore.Imports()
+		if len(imports) == 0 {
+			ui.PrintInfo("No team packs imported")
+			return
+		}
+		fmt.Println(ui.Colorize(ui.CyanBold, "📦 Team Packs:"))
+		for _, pack := range imports {
+			fmt.Printf("  %s %d patterns by %s (exported %s, tool v%s, imported %s)\n",
+				ui.Colorize(ui.Yellow, pack.ID),
+				len(pack.Entries),
+				pack.Provenance.Author,
+				pack.Provenance.Date,
+				pack.Provenance.ToolVersion,
+				pack.ImportedAt)
+		}
+
+	case "--rollback":
+		if len(os.Args) < 4 {
+			ui.PrintError("Usage: ai-helper cache-import --rollback <import-id>")
+			os.Exit(1)
+		}
+		pack, err := cacheStore.RemoveImport(os.Args[3])
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to roll back import: %v", err))
+			os.Exit(1)
+		}
+		ui.PrintSuccess(fmt.Sprintf("Rolled back import %s (%d patterns)", pack.ID, len(pack.Entries)))
+
+	default:
+		result, err := cacheStore.Import(os.Args[2])
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to import pack: %v", err))
+			os.Exit(1)
+		}
+		ui.PrintSuccess(fmt.Sprintf("Imported pack %s: %d added, %d replaced, %d kept existing",
+			result.ID, result.Added, result.Replaced, result.Skipped))
+		if result.Secrets > 0 {
+			ui.PrintWarning(fmt.Sprintf("Left out %d patterns whose command contains a secret", result.Secrets))
+		}
+		ui.PrintInfo("Roll back with: ai-helper cache-import --rollback " + result.ID)
+	}
+}
+
+// defaultAuthor returns the git user name, falling back to the OS user
+func defaultAuthor() string {
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+// handleCacheList lists cache entries with optional tool filter and sort order
+func handleCacheList(cacheStore *cache.Cache) {
+	flags, positional := parseFlags(os.Args[2:], "--tool", "--sort", "--limit")
+	if len(positional) > 0 {
+		ui.PrintError("Usage: ai-helper cache-list [--tool <tool>] [--sort hits|recent] [--limit <n>] [--json]")
+		os.Exit(1)
+	}
+
+	sortBy := flags["--sort"]
+	if sortBy != "" && sortBy != cache.SortByHits && sortBy != cache.SortByRecent {
+		ui.PrintError("Invalid sort order. Use: hits or recent")
+		os.Exit(1)
+	}
+
+	records := cacheStore.Records(cache.ListOptions{
+		Tool:   flags["--tool"],
+		SortBy: sortBy,
+	})
+	printRecords(records, flags)
+}
+
+// handleCacheSearch lists cache entries whose command, error or fix contains text
+func handleCacheSearch(cacheStore *cache.Cache) {
+	flags, positional := parseFlags(os.Args[2:], "--tool", "--sort", "--limit")
+	if len(positional) == 0 {
+		ui.PrintError("Usage: ai-helper cache-search <text> [--tool <tool>] [--sort hits|recent] [--json]")
+		os.Exit(1)
+	}
+
+	records := cacheStore.Records(cache.ListOptions{
+		Tool:   flags["--tool"],
+		Query:  strings.Join(positional, " "),
+		SortBy: flags["--sort"],
+	})
+	printRecords(records, flags)
+}
+
+// handleCacheShow prints a single cache entry
+func handleCacheShow(cacheStore *cache.Cache) {
+	flags, positional := parseFlags(os.Args[2:])
+	if len(positional) != 1 {
+		ui.PrintError("Usage: ai-helper cache-show <key> [--json]")
+		os.Exit(1)
+	}
+
+	record := findRecord(cacheStore, positional[0])
+	if _, ok := flags["--json"]; ok {
+		printJSON(record)
+		return
+	}
+
+	fmt.Println(ui.Colorize(ui.CyanBold, "💾 Cache Entry "+record.Key))
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Tool:"), record.Tool)
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Source:"), record.Source)
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Command:"), record.Command)
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Error:"), record.Error)
+	if record.Scope != nil {
+		if record.Scope.Repo != "" {
+			fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Scope repo:"), record.Scope.Repo)
+		}
+		if record.Scope.KubeContext != "" {
+			fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Scope kube context:"), record.Scope.KubeContext)
+		}
+		if record.Scope.Workspace != "" {
+			fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Scope tf workspace:"), record.Scope.Workspace)
+		}
+	}
+	fmt.Printf("  %s %d\n", ui.Colorize(ui.Yellow, "Hits:"), record.Hits)
+	fmt.Printf("  %s %d\n", ui.Colorize(ui.Yellow, "Rating:"), record.Rating)
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Created:"), formatUnix(record.Timestamp))
+	fmt.Printf("  %s %s\n", ui.Colorize(ui.Yellow, "Last used:"), formatUnix(record.LastUsed))
+	fmt.Println(ui.Colorize(ui.Yellow, "  Fix:"))
+	fmt.Println(ui.FormatAIResponse(record.Fix))
+}
+
+// handleCacheDelete removes a single cache entry
+func handleCacheDelete(cacheStore *cache.Cache) {
+	if len(os.Args) != 3 {
+		ui.PrintError("Usage: ai-helper cache-delete <key>")
+		os.Exit(1)
+	}
+
+	record := findRecord(cacheStore, os.Args[2])
+	if err := cacheStore.Delete(record.Key); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to delete entry: %v", err))
+		os.Exit(1)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Deleted cache entry %s (%s)", record.Key, record.Command))
+}
+
+// handleCacheEdit opens a single cache entry in $EDITOR and saves the result
+func handleCacheEdit(cacheStore *cache.Cache) {
+	if len(os.Args) != 3 {
+		ui.PrintError("Usage: ai-helper cache-edit <key>")
+		os.Exit(1)
+	}
+
+	record := findRecord(cacheStore, os.Args[2])
+
+	data, err := json.MarshalIndent(record.Entry, "", "  ")
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to encode entry: %v", err))
+		os.Exit(1)
+	}
+
+	tmp, err := os.CreateTemp("", "ai-helper-cache-*.json")
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to create temp file: %v", err))
+		os.Exit(1)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to write temp file: %v", err))
+		os.Exit(1)
+	}
+	tmp.Close()
+
+	if err := runEditor(tmp.Name()); err != nil {
+		ui.PrintError(fmt.Sprintf("Editor failed: %v", err))
+		os.Exit(1)
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to read edited entry: %v", err))
+		os.Exit(1)
+	}
+
+	var updated cache.Entry
+	if err := json.Unmarshal(edited, &updated); err != nil {
+		ui.PrintError(fmt.Sprintf("Edited entry is not valid JSON, nothing saved: %v", err))
+		os.Exit(1)
+	}
+	if strings.TrimSpace(updated.Command) == "" || strings.TrimSpace(updated.Fix) == "" {
+		ui.PrintError("Edited entry must keep a non-empty cmd and fix, nothing saved")
+		os.Exit(1)
+	}
+
+	newKey, err := cacheStore.Update(record.Key, &updated)
+	if errors.Is(err, cache.ErrSecret) {
+		ui.PrintError("Edited command contains a secret, nothing saved")
+		os.Exit(1)
+	}
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to save entry: %v", err))
+		os.Exit(1)
+	}
+
+	if newKey != record.Key {
+		ui.PrintSuccess(fmt.Sprintf("Cache entry saved under new key %s", newKey))
+		return
+	}
+	ui.PrintSuccess(fmt.Sprintf("Cache entry %s saved", newKey))
+}
+
+// findRecord resolves a key prefix or exits with an error
+func findRecord(cacheStore *cache.Cache, key string) *cache.Record {
+	record, err := cacheStore.Find(key)
+	if err != nil {
+		ui.PrintError(err.Error())
+		os.Exit(1)
+	}
+	return record
+}
+
+// printRecords prints records as a table, or as JSON with --json
+func printRecords(records []cache.Record, flags map[string]string) {
+	if limit, ok := flags["--limit"]; ok {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			ui.PrintError("Invalid --limit value")
+			os.Exit(1)
+		}
+		if n < len(records) {
+			records = records[:n]
+		}
+	}
+
+	if _, ok := flags["--json"]; ok {
+		printJSON(records)
+		return
+	}
+
+	if len(records) == 0 {
+		ui.PrintInfo("No matching cache entries")
+		return
+	}
+
+	for _, r := range records {
+		source := r.Source
+		if source != cache.SourcePersonal {
+			source = ui.Colorize(ui.Magenta, source)
+		}
+		fmt.Printf("%s %-10s %4d hits  %s  %s  %s\n",
+			ui.Colorize(ui.Yellow, shortKey(r.Key)),
+			r.Tool,
+			r.Hits,
+			formatUnix(r.LastUsed),
+			source,
+			r.Command)
+	}
+}
+
+// shortKey abbreviates a cache key for table output
+func shortKey(key string) string {
+	if len(key) > 12 {
+		return key[:12]
+	}
+	return key
+}
+
+// printJSON prints v as indented JSON to stdout
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to encode JSON: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// formatUnix formats a unix timestamp for display
+func formatUnix(ts int64) string {
+	if ts == 0 {
+		return "never"
+	}
+	return time.Unix(ts, 0).Format("2006-01-02 15:04")
+}
+
+// runEditor opens file in $VISUAL or $EDITOR (default vi)
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Editors are often configured with arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], file)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// parseFlags splits args into flags and positional arguments.
+// Flags listed in valueFlags take a value ("--flag value" or "--flag=value");
+// any other argument starting with "--" is a boolean flag.
+func parseFlags(args []string, valueFlags ...string) (map[string]string, []string) {
+	takesValue := make(map[string]bool, len(valueFlags))
+	for _, f := range valueFlags {
+		takesValue[f] = true
+	}
+
+	flags := make(map[string]string)
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case !strings.HasPrefix(arg, "--"):
+			positional = append(positional, arg)
+		case strings.Contains(arg, "="):
+			name, value, _ := strings.Cut(arg, "=")
+			flags[name] = value
+		case takesValue[arg] && i+1 < len(args):
+			flags[arg] = args[i+1]
+			i++
+		default:
+			flags[arg] = ""
+		}
+	}
+
+	return flags, positional
+}
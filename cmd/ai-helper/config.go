@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/config"
+	"github.com/amaslovskyi/ai-helper/pkg/ui"
+)
+
+// legacyConfigKeys maps the original config-set key names to config keys
+var legacyConfigKeys = map[string]string{
+	"mode":          "activation_mode",
+	"confidence":    "show_confidence",
+	"model":         "preferred_model",
+	"cache-scope":   "cache_scopes",
+	"danger-policy": "danger_policy",
+}
+
+// configKey resolves legacy names and dashes to a dotted config key.
+// Only the top-level part is rewritten; tool names keep their dashes.
+func configKey(name string) string {
+	if key, ok := legacyConfigKeys[name]; ok {
+		return key
+	}
+	top, sub, nested := strings.Cut(name, ".")
+	top = strings.ReplaceAll(top, "-", "_")
+	if nested {
+		return top + "." + sub
+	}
+	return top
+}
+
+// configEntry is one value in config-get --json output
+type configEntry struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// handleConfigGet prints one effective value, or all of them
+func handleConfigGet(cfg *config.Config) {
+	flags, positional := parseFlags(os.Args[2:])
+	_, asJSON := flags["--json"]
+
+	if len(positional) > 1 {
+		ui.PrintError("Usage: ai-helper config-get [key] [--json]")
+		os.Exit(1)
+	}
+
+	if len(positional) == 1 {
+		key := configKey(positional[0])
+		value, err := cfg.Get(key)
+		if err != nil {
+			ui.PrintError(err.Error())
+			os.Exit(1)
+		}
+		if asJSON {
+			printJSON(configEntry{Key: key, Value: value, Source: cfg.Source(key)})
+			return
+		}
+		fmt.Println(formatConfigValue(value))
+		return
+	}
+
+	entries := make([]configEntry, 0, len(config.Keys()))
+	for _, key := range config.Keys() {
+		value, err := cfg.Get(key)
+		if err != nil {
+			ui.PrintError(err.Error())
+			os.Exit(1)
+		}
+		entries = append(entries, configEntry{Key: key, Value: value, Source: cfg.Source(key)})
+
+		// Expand maps so each entry shows its own source
+		if m, ok := value.(map[string]interface{}); ok {
+			subs := make([]string, 0, len(m))
+			for sub := range m {
+				subs = append(subs, sub)
+			}
+			sort.Strings(subs)
+			for _, sub := range subs {
+				entries = append(entries, configEntry{Key: key + "." + sub, Value: m[sub], Source: cfg.Source(key + "." + sub)})
+			}
+		}
+	}
+
+	if asJSON {
+		printJSON(entries)
+		return
+	}
+	for _, entry := range entries {
+		if _, isMap := entry.Value.(map[string]interface{}); isMap {
+			continue
+		}
+		fmt.Printf("%s = %s %s\n", entry.Key, formatConfigValue(entry.Value), ui.Colorize(ui.Dim, "("+entry.Source+")"))
+	}
+}
+
+// handleConfigSet updates the user configuration file.
+// Project files and environment variables are never written.
+func handleConfigSet(configFile string) {
+	args := os.Args[2:]
+
+	// Legacy form: config-set tool-mode <tool> <mode>
+	if len(args) == 3 && args[0] == "tool-mode" {
+		args = []string{"tool_specific_modes." + args[1], args[2]}
+	}
+
+	if len(args) != 2 {
+		fmt.Println(ui.Colorize(ui.Red, "Usage: ai-helper config-set <key> <value>"))
+		fmt.Println()
+		fmt.Println("Available keys:")
+		for _, key := range config.Keys() {
+			if key != "version" {
+				fmt.Printf("  %s\n", key)
+			}
+		}
+		fmt.Println()
+		fmt.Println("Lists are comma-separated, maps take key=value pairs or a dotted key.")
+		fmt.Println("Short names mode, confidence, model, cache-scope and danger-policy still work.")
+		fmt.Println()
+		fmt.Println("Examples:")
+		fmt.Println("  ai-helper config-set mode interactive")
+		fmt.Println("  ai-helper config-set tool_specific_modes.kubectl interactive")
+		fmt.Println("  ai-helper config-set auto_execute_safe true")
+		fmt.Println("  ai-helper config-set provider opencode")
+		fmt.Println("  ai-helper config-set model anthropic/claude-sonnet-4-20250514")
+		fmt.Println("  ai-helper config-set cache-scope kube_context,repo")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+
+	key := configKey(args[0])
+	if err := cfg.Set(key, args[1]); err != nil {
+		ui.PrintError(err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfg.Save(configFile); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to save config: %v", err))
+		os.Exit(1)
+	}
+
+	value, _ := cfg.Get(key)
+	ui.PrintSuccess(fmt.Sprintf("%s set to: %s", key, formatConfigValue(value)))
+}
+
+// handleConfigUnset restores a key in the user configuration file to its
+// default, or removes a single map entry such as a tool mode
+func handleConfigUnset(configFile string) {
+	if len(os.Args) != 3 {
+		ui.PrintError("Usage: ai-helper config-unset <key>")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		os.Exit(1)
+	}
+
+	key := configKey(os.Args[2])
+	if err := cfg.Unset(key); err != nil {
+		ui.PrintError(err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfg.Save(configFile); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to save config: %v", err))
+		os.Exit(1)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("%s unset", key))
+}
+
+// formatConfigValue renders a raw config value for display
+func formatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		if len(items) == 0 {
+			return "[]"
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, v[k]))
+		}
+		return strings.Join(pairs, ",")
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// handleProfile lists, selects and edits named configuration profiles
+func handleProfile(cfg *config.Config, configFile string) {
+	usage := func() {
+		fmt.Println(ui.Colorize(ui.Red, "Usage: ai-helper profile list"))
+		fmt.Println("       ai-helper profile use <name>")
+		fmt.Println("       ai-helper profile off")
+		fmt.Println("       ai-helper profile show <name>")
+		fmt.Println("       ai-helper profile set <name> <key> <value>")
+		fmt.Println("       ai-helper profile delete <name>")
+		os.Exit(1)
+	}
+	if len(os.Args) < 3 {
+		usage()
+	}
+
+	switch sub, args := os.Args[2], os.Args[3:]; sub {
+	case "list":
+		names := cfg.ProfileNames()
+		if len(names) == 0 {
+			ui.PrintInfo("No profiles defined. Create one with: ai-helper profile set <name> <key> <value>")
+			return
+		}
+		fmt.Println(ui.Colorize(ui.CyanBold, "👤 Profiles:"))
+		for _, name := range names {
+			if name == cfg.ActiveProfile {
+				fmt.Printf("  %s %s\n", ui.Colorize(ui.GreenBold, "* "+name), sourceNote(cfg, "active_profile"))
+			} else {
+				fmt.Printf("    %s\n", name)
+			}
+		}
+
+	case "show":
+		if len(args) != 1 {
+			usage()
+		}
+		values, ok := cfg.Profiles[args[0]]
+		if !ok {
+			ui.PrintError(fmt.Sprintf("Profile %q is not defined", args[0]))
+			os.Exit(1)
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s = %s\n", key, formatConfigValue(values[key]))
+		}
+
+	case "use", "off", "set", "delete":
+		user, err := config.Load(configFile)
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+			os.Exit(1)
+		}
+
+		switch sub {
+		case "use":
+			if len(args) != 1 {
+				usage()
+			}
+			// The selection is saved to the user file, so it must name a
+			// profile defined there; a project file's profiles are not
+			// defined in other directories
+			if _, ok := user.Profiles[args[0]]; !ok {
+				if _, ok := cfg.Profiles[args[0]]; ok {
+					ui.PrintError(fmt.Sprintf("Profile %q is defined by the project; select it with active_profile in %s", args[0], config.ProjectFileName))
+				} else {
+					ui.PrintError(fmt.Sprintf("Profile %q is not defined", args[0]))
+				}
+				os.Exit(1)
+			}
+			user.ActiveProfile = args[0]
+		case "off":
+			user.ActiveProfile = ""
+		case "set":
+			if len(args) != 3 {
+				usage()
+			}
+			if err := user.SetProfileValue(args[0], configKey(args[1]), args[2]); err != nil {
+				ui.PrintError(err.Error())
+				os.Exit(1)
+			}
+		case "delete":
+			if len(args) != 1 {
+				usage()
+			}
+			if err := user.DeleteProfile(args[0]); err != nil {
+				ui.PrintError(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if err := user.Save(configFile); err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to save config: %v", err))
+			os.Exit(1)
+		}
+
+		switch sub {
+		case "use":
+			ui.PrintSuccess(fmt.Sprintf("Active profile: %s", args[0]))
+		case "off":
+			ui.PrintSuccess("No profile active")
+		case "set":
+			ui.PrintSuccess(fmt.Sprintf("Profile %s: %s set to %s", args[0], configKey(args[1]), args[2]))
+		case "delete":
+			ui.PrintSuccess(fmt.Sprintf("Profile %s deleted", args[0]))
+		}
+
+	default:
+		usage()
+	}
+}
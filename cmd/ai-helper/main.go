@@ -2,62 +2,108 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/amaslovskyi/ai-helper/pkg/audit"
 	"github.com/amaslovskyi/ai-helper/pkg/cache"
 	"github.com/amaslovskyi/ai-helper/pkg/config"
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
 	"github.com/amaslovskyi/ai-helper/pkg/interactive"
 	"github.com/amaslovskyi/ai-helper/pkg/llm"
 	"github.com/amaslovskyi/ai-helper/pkg/security"
+	"github.com/amaslovskyi/ai-helper/pkg/session"
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
 	"github.com/amaslovskyi/ai-helper/pkg/ui"
 	"github.com/amaslovskyi/ai-helper/pkg/validators"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/ansible"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/argocd"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/docker"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/git"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/helm"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/kubectl"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/terraform"
-	"github.com/amaslovskyi/ai-helper/pkg/validators/terragrunt"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
+
+	// Built-in validators register themselves
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/ansible"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/argocd"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/docker"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/git"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/helm"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/kubectl"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/terraform"
+	_ "github.com/amaslovskyi/ai-helper/pkg/validators/terragrunt"
 )
 
 // version is set at build time via ldflags, defaults to dev if not set
 var version = "dev"
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
+	// Initialize components
+	aiDir, err := resolveAIDir()
+	if err != nil {
+		ui.PrintError(err.Error())
 		os.Exit(1)
 	}
 
-	// Initialize components
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		ui.PrintError(fmt.Sprintf("Failed to get home directory: %v", err))
+	if len(os.Args) < 2 {
+		printUsage()
 		os.Exit(1)
 	}
 
-	aiDir := filepath.Join(homeDir, ".ai")
-	cacheFile := filepath.Join(aiDir, "cache.json")
 	configFile := filepath.Join(aiDir, "config.json")
 
-	// Load configuration
-	cfg, err := config.Load(configFile)
+	// Load configuration (defaults, user file, project file, environment)
+	cwd, _ := os.Getwd()
+	cfg, err := config.Resolve(configFile, cwd)
 	if err != nil {
-		ui.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+		// Commands that repair the config must work while it is broken
+		switch os.Args[1] {
+		case "config-validate":
+			handleConfigValidate(configFile, cwd)
+			return
+		case "config-reset":
+			handleConfigReset(configFile)
+			return
+		case "profile":
+			// Without the project file, so a broken profile can be turned off
+			if user, err := config.Load(configFile); err == nil {
+				handleProfile(user, configFile)
+				return
+			}
+		}
+		ui.PrintError(fmt.Sprintf("Failed to load config:\n%v", err))
+		ui.PrintInfo("Run 'ai-helper config-validate' for details or 'ai-helper config-reset' to start over")
 		os.Exit(1)
 	}
 
-	// Create cache
-	cacheStore, err := cache.NewCache(cacheFile)
-	if err != nil {
-		ui.PrintError(fmt.Sprintf("Failed to initialize cache: %v", err))
-		os.Exit(1)
+	// Honor a pause of this terminal session (ai-helper pause / menu option)
+	sessions := session.NewStore(filepath.Join(aiDir, "sessions"))
+	sessionID := session.CurrentID()
+	if state, err := sessions.Load(sessionID); err == nil {
+		cfg.SessionDisabled = state.Active(time.Now())
+	}
+
+	// Suggestions, verdicts and choices are recorded for compliance
+	auditLog := audit.New(filepath.Join(aiDir, "audit.log"))
+
+	// The cache is opened only by the commands that use it
+	var cacheStore *cache.Cache
+	openCache := func() *cache.Cache {
+		if cacheStore == nil {
+			store, err := cache.NewCache(aiDir, cfg.CacheBackend)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to initialize cache: %v", err))
+				os.Exit(1)
+			}
+			cacheStore = store
+		}
+		return cacheStore
 	}
+	defer func() {
+		if cacheStore != nil {
+			cacheStore.Close()
+		}
+	}()
 
 	// Create LLM client based on provider configuration
 	var client llm.Client
@@ -65,22 +111,49 @@ func main() {
 	case config.ProviderOpenCode:
 		client = llm.NewOpenCodeClient(cfg.PreferredModel)
 	default:
-		client = llm.NewOllamaClient("")
+		client = llm.NewOllamaClient(cfg.OllamaURL)
+	}
+
+	// Create security scanner from the built-in policy plus user and project
+	// overrides (a project may only tighten the rules)
+	policy, err := security.LoadPolicy(
+		filepath.Join(aiDir, "policy.yaml"),
+		envctx.FindUp(cwd, security.PolicyFileName),
+	)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to load security policy: %v", err))
+		os.Exit(1)
 	}
+	scanner := security.NewScanner(policy)
 
-	// Create security scanner
-	scanner := security.NewScanner()
+	// Flag schemas: embedded defaults plus those imported into ~/.ai/schemas
+	schemaDir := filepath.Join(aiDir, "schemas")
+	if err := schema.Load(schemaDir); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Skipped flag schemas: %v", err))
+	}
+
+	// Installed tool versions select the validator rules that apply; they
+	// are cached until the binary changes
+	toolversion.SetCacheFile(filepath.Join(aiDir, "tool-versions.json"))
 
-	// Create validators (order matters: more specific first)
-	validatorsList := []validators.Validator{
-		kubectl.NewValidator(),    // k, kubectl
-		terraform.NewValidator(),  // tf, terraform
-		terragrunt.NewValidator(), // tg, terragrunt
-		helm.NewValidator(),       // h, helm
-		git.NewValidator(),        // git, gco, gcb, gp, etc.
-		docker.NewValidator(),     // docker, d, dc
-		ansible.NewValidator(),    // ansible, ansible-playbook
-		argocd.NewValidator(),     // argocd
+	// Escalate findings for commands aimed at production clusters, workspaces and accounts
+	environment := envctx.Detect(cwd)
+	scanner.SetEnvironment(environment, cfg.ProductionPatterns)
+
+	// Create the registered validators plus the plugins in ~/.ai/validators,
+	// highest priority first. Plugins are run to describe themselves, so
+	// only the commands that validate load them.
+	loadValidators := func() []validators.Validator {
+		if err := validators.LoadPlugins(filepath.Join(aiDir, "validators")); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Skipped validator plugins: %v", err))
+		}
+		list := validators.New()
+		for _, v := range list {
+			if aware, ok := v.(validators.EnvironmentAware); ok {
+				aware.SetEnvironment(environment, cfg.ProductionPatterns)
+			}
+		}
+		return list
 	}
 
 	// Parse command
@@ -88,22 +161,57 @@ func main() {
 
 	switch cmd {
 	case "analyze":
-		handleAnalyze(client, cacheStore, scanner, validatorsList, cfg)
+		handleAnalyze(client, openCache(), scanner, loadValidators(), cfg, sessions, sessionID, auditLog)
 	case "proactive", "ask":
-		handleProactive(client, scanner, validatorsList, cfg)
+		handleProactive(client, scanner, loadValidators(), cfg, sessions, sessionID, auditLog)
 	case "version", "-v", "--version", "-V":
 		// Support common version flag conventions
 		fmt.Printf("AI Terminal Helper v%s (Go)\n", version)
 	case "cache-stats":
-		handleCacheStats(cacheStore)
+		handleCacheStats(openCache())
 	case "cache-clear":
-		handleCacheClear(cacheStore)
+		handleCacheClear(openCache())
+	case "cache-list":
+		handleCacheList(openCache())
+	case "cache-search":
+		handleCacheSearch(openCache())
+	case "cache-show":
+		handleCacheShow(openCache())
+	case "cache-delete":
+		handleCacheDelete(openCache())
+	case "cache-edit":
+		handleCacheEdit(openCache())
+	case "cache-export":
+		handleCacheExport(openCache())
+	case "cache-import":
+		handleCacheImport(openCache())
+	case "cache-migrate":
+		handleCacheMigrate(openCache(), configFile, aiDir)
 	case "config-show":
 		handleConfigShow(cfg)
+	case "config-get":
+		handleConfigGet(cfg)
 	case "config-set":
-		handleConfigSet(cfg, configFile)
+		handleConfigSet(configFile)
+	case "config-unset":
+		handleConfigUnset(configFile)
 	case "config-reset":
 		handleConfigReset(configFile)
+	case "config-validate":
+		handleConfigValidate(configFile, cwd)
+	case "profile":
+		handleProfile(cfg, configFile)
+	case "pause":
+		handlePause(sessions, sessionID)
+	case "audit":
+		handleAudit(auditLog, sessions, sessionID)
+	case "resume":
+		handleResume(sessions, sessionID)
+	case "validators":
+		loadValidators() // Registers the plugins so they are listed
+		handleValidators()
+	case "schema":
+		handleSchema(schemaDir)
 	case "-h", "--help", "help":
 		// Support common help flag conventions
 		printUsage()
@@ -115,7 +223,35 @@ func main() {
 	}
 }
 
-func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security.Scanner, validators []validators.Validator, cfg *config.Config) {
+// resolveAIDir returns the directory holding config, cache and team packs:
+// the --config <dir> flag (removed from os.Args), then $AI_HELPER_HOME,
+// then ~/.ai
+func resolveAIDir() (string, error) {
+	if len(os.Args) > 1 && (os.Args[1] == "--config" || strings.HasPrefix(os.Args[1], "--config=")) {
+		dir := strings.TrimPrefix(os.Args[1], "--config=")
+		rest := os.Args[2:]
+		if os.Args[1] == "--config" {
+			if len(os.Args) < 3 {
+				return "", fmt.Errorf("--config requires a directory")
+			}
+			dir, rest = os.Args[2], os.Args[3:]
+		}
+		os.Args = append([]string{os.Args[0]}, rest...)
+		return filepath.Abs(dir)
+	}
+
+	if dir := os.Getenv("AI_HELPER_HOME"); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".ai"), nil
+}
+
+func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security.Scanner, validators []validators.Validator, cfg *config.Config, sessions *session.Store, sessionID string, auditLog *audit.Log) {
 	if len(os.Args) < 4 {
 		ui.PrintError("Usage: ai-helper analyze <command> <exit_code> [error_output]")
 		os.Exit(1)
@@ -143,10 +279,48 @@ func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security
 		os.Exit(exitCode) // Just exit with the original error code
 	}
 
+	rec := audit.Record{
+		Event:    audit.EventAnalyze,
+		Session:  sessionID,
+		Command:  command,
+		ExitCode: &exitCode,
+		Error:    errorOutput,
+	}
+
+	// Detect repository / kube context / terraform workspace for scoped caching
+	cwd, _ := os.Getwd()
+	scope := cache.ScopeFromEnvironment(envctx.Detect(cwd))
+
+	// Cached and seed answers may come from imported team packs or hand
+	// edits, so they are validated and scanned like the AI's; one the
+	// validators reject is passed over
+	useStored := func(label, source string, resp *llm.Response) bool {
+		validation := validateCommand(resp.Suggestion, validators)
+		if validation.Blocked() {
+			return false
+		}
+		fmt.Println(ui.Colorize(ui.MagentaBold, label))
+		rec.Source, rec.Validation = source, validationSummary(validation)
+		if rec.Validation != "ok" {
+			ui.PrintWarning(rec.Validation)
+		}
+		if !checkSuggestion(scanner, resp.Suggestion, cfg, &rec, func() {
+			printResponse(resp)
+		}) {
+			logAudit(auditLog, rec)
+			os.Exit(1)
+		}
+		recordSuggestion(auditLog, rec, resp, sessions)
+		return true
+	}
+
 	// Try cache first
-	if cachedResp, ok := cacheStore.Get(command, errorOutput); ok {
-		fmt.Println(ui.Colorize(ui.MagentaBold, "💾 [Cached]"))
-		printResponse(cachedResp)
+	if cachedResp, ok := cacheStore.Get(command, errorOutput, scope); ok && useStored("💾 [Cached]", "cache", cachedResp) {
+		return
+	}
+
+	// Then the curated seed packs shipped with the binary
+	if seedResp, _, ok := cacheStore.MatchSeed(command, errorOutput); ok && useStored("📦 [Seed]", "seed", seedResp) {
 		return
 	}
 
@@ -154,18 +328,21 @@ func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security
 	if cfg.ShouldShowMenu(toolName) {
 		// Show interactive menu
 		result := interactive.ShowErrorMenu(command, errorOutput)
+		rec.Choice = result.Action
 
 		switch result.Action {
 		case "ai":
 			// Continue to AI suggestion
 		case "manual":
 			fmt.Println(ui.Colorize(ui.Cyan, "📖 Tip: Use 'man "+toolName+"' for documentation"))
+			logAudit(auditLog, rec)
 			return
 		case "skip":
+			logAudit(auditLog, rec)
 			return
 		case "disable":
-			cfg.SessionDisabled = true
-			ui.PrintInfo("AI disabled for this session. Restart terminal to re-enable.")
+			logAudit(auditLog, rec)
+			pauseSession(sessions, sessionID, askPauseDuration())
 			return
 		default:
 			return
@@ -175,11 +352,25 @@ func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security
 		return
 	}
 
+	// An offline fix when the validators know what was mistyped, once the
+	// activation mode allows a suggestion
+	if fixResp, ok := offlineFix(command, validators); ok {
+		fmt.Println(ui.Colorize(ui.MagentaBold, "⚡ [Offline fix]"))
+		rec.Source, rec.Validation = "validator", "ok"
+		if !checkSuggestion(scanner, fixResp.Suggestion, cfg, &rec, func() {
+			printResponse(fixResp)
+		}) {
+			logAudit(auditLog, rec)
+			os.Exit(1)
+		}
+		recordSuggestion(auditLog, rec, fixResp, sessions)
+		return
+	}
+
 	// Query AI
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	cwd, _ := os.Getwd()
 	req := llm.Request{
 		Command:   command,
 		Error:     errorOutput,
@@ -194,14 +385,16 @@ func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security
 		os.Exit(1)
 	}
 
-	// Validate the suggested command
-	validationErr := validateCommand(resp.Suggestion, validators)
-	if validationErr != nil {
-		ui.PrintWarning(fmt.Sprintf("AI suggestion validation failed: %v", validationErr))
+	// Validate the suggested command; a blocked suggestion is corrected
+	// offline when the validators know the fix
+	validation := autoCorrect(resp, validateCommand(resp.Suggestion, validators), validators)
+	if validation.Blocked() {
+		summary := validationSummary(validation)
+		ui.PrintWarning(fmt.Sprintf("AI suggestion validation failed:\n%s", summary))
 		ui.PrintInfo("Querying AI again with validation context...")
 
 		// Query again with validation error context
-		req.Context = fmt.Sprintf("Previous suggestion '%s' was invalid: %v", resp.Suggestion, validationErr)
+		req.Context = fmt.Sprintf("Previous suggestion '%s' was invalid:\n%s", resp.Suggestion, summary)
 		resp, err = client.Query(ctx, req)
 		if err != nil {
 			ui.PrintError(fmt.Sprintf("AI re-query failed: %v", err))
@@ -209,41 +402,48 @@ func handleAnalyze(client llm.Client, cacheStore *cache.Cache, scanner *security
 		}
 
 		// Validate again
-		validationErr = validateCommand(resp.Suggestion, validators)
-		if validationErr != nil {
-			ui.PrintError(fmt.Sprintf("AI still suggesting invalid command: %v", validationErr))
+		validation = autoCorrect(resp, validateCommand(resp.Suggestion, validators), validators)
+		if validation.Blocked() {
+			summary = validationSummary(validation)
+			ui.PrintError(fmt.Sprintf("AI still suggesting invalid command:\n%s", summary))
 			ui.PrintInfo("Suggestion: " + resp.Suggestion)
+			rec.Source, rec.Model, rec.Suggestion = "llm", string(resp.Model), resp.Suggestion
+			rec.Validation = summary
+			logAudit(auditLog, rec)
 			os.Exit(1)
 		}
 	}
+	if summary := validationSummary(validation); summary != "ok" {
+		ui.PrintWarning(summary)
+	}
 
 	// Calculate confidence
 	complexity := llm.CalculateCommandComplexity(command)
-	confLevel, confScore := llm.CalculateConfidence(resp, validationErr, complexity)
+	confLevel, confScore := llm.CalculateConfidence(resp, validation.Findings, complexity)
 
-	// Security scan
-	dangerResult, err := scanner.Scan(resp.Suggestion)
-	if err != nil {
-		ui.PrintError(fmt.Sprintf("Security scan failed: %v", err))
-		os.Exit(1)
-	}
+	rec.Source, rec.Model, rec.Suggestion = "llm", string(resp.Model), resp.Suggestion
+	rec.Validation = validationSummary(validation)
 
-	if dangerResult.IsDangerous {
-		ui.PrintDanger(dangerResult.Warning())
+	// Security scan, then print the response with confidence
+	if !checkSuggestion(scanner, resp.Suggestion, cfg, &rec, func() {
+		printResponseWithConfidence(resp, confLevel, confScore)
+	}) {
+		logAudit(auditLog, rec)
 		os.Exit(1)
 	}
 
 	// Cache the response
-	if err := cacheStore.Set(command, errorOutput, resp); err != nil {
+	if err := cacheStore.Set(command, errorOutput, scope.Only(cfg.CacheScopes), resp); errors.Is(err, cache.ErrSecret) {
+		ui.PrintInfo("Not cached: the suggestion contains a secret")
+	} else if err != nil {
 		// Non-fatal, just log
 		ui.PrintWarning(fmt.Sprintf("Failed to cache response: %v", err))
 	}
 
-	// Print response with confidence
-	printResponseWithConfidence(resp, confLevel, confScore)
+	recordSuggestion(auditLog, rec, resp, sessions)
 }
 
-func handleProactive(client llm.Client, scanner *security.Scanner, validators []validators.Validator, cfg *config.Config) {
+func handleProactive(client llm.Client, scanner *security.Scanner, validators []validators.Validator, cfg *config.Config, sessions *session.Store, sessionID string, auditLog *audit.Log) {
 	if len(os.Args) < 3 {
 		ui.PrintError("Usage: ai-helper proactive <query>")
 		os.Exit(1)
@@ -274,30 +474,90 @@ func handleProactive(client llm.Client, scanner *security.Scanner, validators []
 		os.Exit(1)
 	}
 
-	// Validate the suggested command
-	validationErr := validateCommand(resp.Suggestion, validators)
-	if validationErr != nil {
-		ui.PrintWarning(fmt.Sprintf("Validation failed: %v", validationErr))
+	// Validate the suggested command, correcting it offline if it is
+	// blocked and the validators know the fix
+	validation := autoCorrect(resp, validateCommand(resp.Suggestion, validators), validators)
+	if summary := validationSummary(validation); summary != "ok" {
+		ui.PrintWarning(fmt.Sprintf("Validation failed:\n%s", summary))
 		// In proactive mode, still show the suggestion but with warning
 	}
 
 	// Calculate confidence
 	complexity := llm.CalculateCommandComplexity(query)
-	confLevel, confScore := llm.CalculateConfidence(resp, validationErr, complexity)
+	confLevel, confScore := llm.CalculateConfidence(resp, validation.Findings, complexity)
+
+	rec := audit.Record{
+		Event:      audit.EventProactive,
+		Session:    sessionID,
+		Command:    query,
+		Source:     "llm",
+		Model:      string(resp.Model),
+		Suggestion: resp.Suggestion,
+		Validation: validationSummary(validation),
+	}
+
+	// Security scan, then print the response with confidence
+	if !checkSuggestion(scanner, resp.Suggestion, cfg, &rec, func() {
+		printResponseWithConfidence(resp, confLevel, confScore)
+	}) {
+		logAudit(auditLog, rec)
+		os.Exit(1)
+	}
+
+	recordSuggestion(auditLog, rec, resp, sessions)
+}
 
-	// Security scan
-	dangerResult, err := scanner.Scan(resp.Suggestion)
+// checkSuggestion applies the security policy to a suggestion and prints
+// it with show unless it is blocked. A suggestion that needs confirmation
+// is printed with the findings and kept only if the user acknowledges the
+// risk. The verdict, choice and acknowledgement go into the audit record;
+// the result reports whether the suggestion was kept.
+func checkSuggestion(scanner *security.Scanner, suggestion string, cfg *config.Config, rec *audit.Record, show func()) bool {
+	result, err := scanner.Scan(suggestion)
 	if err != nil {
 		ui.PrintError(fmt.Sprintf("Security scan failed: %v", err))
-		os.Exit(1)
-	}
+		rec.Security = &audit.Verdict{Action: "error"}
+		return false
+	}
+
+	policy := string(cfg.DangerPolicy)
+	action := result.Action(policy)
+	rec.Security = &audit.Verdict{Action: string(action), Allowed: result.Allowed()}
+	if action == "" {
+		rec.Security.Action = "none"
+	}
+	for _, finding := range result.Findings {
+		rec.Security.Rules = append(rec.Security.Rules, finding.Rule.ID)
+	}
+
+	switch action {
+	case security.ActionBlock:
+		ui.PrintDanger(result.Warning())
+		return false
+	case security.ActionConfirm:
+		show()
+		answer := interactive.AcknowledgeRisk(security.Summary(result.Visible(policy)))
+		if answer == interactive.AckDeclined {
+			rec.Choice = "declined"
+			ui.PrintInfo("Not acknowledged: don't run this suggestion")
+			return false
+		}
 
-	if dangerResult.IsDangerous {
-		ui.PrintDanger(dangerResult.Warning())
-		os.Exit(1)
+		rec.Choice = "acknowledged"
+		rec.Acknowledged = &audit.Acknowledgement{
+			By:     whoami(),
+			At:     time.Now().UTC().Format(time.RFC3339),
+			Always: answer == interactive.AckAlways,
+		}
+		if rec.Acknowledged.Always {
+			allowSuggestion(suggestion, result, rec.Acknowledged)
+		}
+		return true
+	case security.ActionWarn:
+		ui.PrintWarning(security.Summary(result.Visible(policy)))
 	}
-
-	printResponseWithConfidence(resp, confLevel, confScore)
+	show()
+	return true
 }
 
 func handleCacheStats(cacheStore *cache.Cache) {
@@ -313,6 +573,27 @@ func handleCacheStats(cacheStore *cache.Cache) {
 		ui.Colorize(ui.Green, ""),
 		stats["total_hits"],
 		ui.Colorize(ui.Reset, ""))
+	fmt.Printf("  %s %s%d%s\n",
+		ui.Colorize(ui.Yellow, "Team patterns:"),
+		ui.Colorize(ui.Green, ""),
+		stats["team_entries"],
+		ui.Colorize(ui.Reset, ""))
+	fmt.Printf("  %s %s%d%s\n",
+		ui.Colorize(ui.Yellow, "Team imports:"),
+		ui.Colorize(ui.Green, ""),
+		stats["team_imports"],
+		ui.Colorize(ui.Reset, ""))
+	fmt.Printf("  %s %s%d%s %s\n",
+		ui.Colorize(ui.Yellow, "Seed patterns:"),
+		ui.Colorize(ui.Green, ""),
+		stats["seed_entries"],
+		ui.Colorize(ui.Reset, ""),
+		ui.Colorize(ui.Dim, "("+stats["seed_versions"].(string)+")"))
+	fmt.Printf("  %s %s%s%s\n",
+		ui.Colorize(ui.Yellow, "Backend:"),
+		ui.Colorize(ui.Blue, ""),
+		stats["backend"],
+		ui.Colorize(ui.Reset, ""))
 	fmt.Printf("  %s %s%s%s\n",
 		ui.Colorize(ui.Yellow, "Cache file:"),
 		ui.Colorize(ui.Blue, ""),
@@ -328,13 +609,63 @@ func handleCacheClear(cacheStore *cache.Cache) {
 	ui.PrintSuccess("Cache cleared")
 }
 
-func validateCommand(command string, validators []validators.Validator) error {
-	for _, v := range validators {
-		if v.CanValidate(command) {
-			return v.Validate(command)
-		}
+// validateCommand validates every simple command of a suggestion with its
+// validator
+func validateCommand(command string, list []validators.Validator) *validators.Result {
+	return validators.ValidateLine(command, list)
+}
+
+// autoCorrect applies the validators' corrections to a blocked suggestion
+// and returns the validation of the suggestion as it stands. The
+// suggestion is left alone unless the corrected command passes.
+func autoCorrect(resp *llm.Response, result *validators.Result, list []validators.Validator) *validators.Result {
+	if !result.Blocked() {
+		return result
+	}
+	corrected, ok := result.Corrected()
+	if !ok {
+		return result
+	}
+	fixed := validateCommand(corrected, list)
+	if fixed.Blocked() {
+		return result
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Corrected the suggestion: %s → %s", resp.Suggestion, corrected))
+	resp.Suggestion = corrected
+	return fixed
+}
+
+// offlineFix corrects a failed command without the AI when every blocking
+// finding the validators report has a correction, e.g. a mistyped
+// subcommand ("kubectl gett") or a known wrong flag
+func offlineFix(command string, list []validators.Validator) (*llm.Response, bool) {
+	result := validateCommand(command, list)
+	if !result.Blocked() {
+		return nil, false
+	}
+	corrected, ok := result.Corrected()
+	if !ok || validateCommand(corrected, list).Blocked() {
+		return nil, false
+	}
+
+	var causes []string
+	for _, finding := range result.Select(validators.SeverityBlock) {
+		causes = append(causes, finding.String())
 	}
-	return nil
+	return &llm.Response{
+		Suggestion: corrected,
+		RootCause:  strings.Join(causes, "; "),
+	}, true
+}
+
+// validationSummary describes the warnings and blocks of a validation for
+// the user and the audit log ("ok" if there are none)
+func validationSummary(result *validators.Result) string {
+	if summary := result.Summary(validators.SeverityWarn); summary != "" {
+		return summary
+	}
+	return "ok"
 }
 
 func printResponse(resp *llm.Response) {
@@ -375,113 +706,133 @@ func extractToolName(command string) string {
 	return parts[0]
 }
 
-// handleConfigShow displays current configuration
+// handleConfigShow displays the effective configuration and where each value came from
 func handleConfigShow(cfg *config.Config) {
 	fmt.Println(ui.Colorize(ui.CyanBold, "⚙️  Configuration:"))
-	fmt.Printf("  %s %s\n",
+	if cfg.SessionDisabled {
+		fmt.Printf("  %s %s\n",
+			ui.Colorize(ui.Yellow, "Session:"),
+			ui.Colorize(ui.Red, "paused (run 'ai-helper resume')"))
+	}
+	if cfg.ActiveProfile != "" {
+		fmt.Printf("  %s %s %s\n",
+			ui.Colorize(ui.Yellow, "Profile:"),
+			ui.Colorize(ui.GreenBold, cfg.ActiveProfile+" (active)"),
+			sourceNote(cfg, "active_profile"))
+	}
+	fmt.Printf("  %s %s %s\n",
 		ui.Colorize(ui.Yellow, "Activation Mode:"),
-		ui.Colorize(ui.Green, string(cfg.ActivationMode)))
-	fmt.Printf("  %s %v\n",
+		ui.Colorize(ui.Green, string(cfg.ActivationMode)),
+		sourceNote(cfg, "activation_mode"))
+	fmt.Printf("  %s %v %s\n",
 		ui.Colorize(ui.Yellow, "Auto Execute Safe:"),
-		cfg.AutoExecuteSafe)
-	fmt.Printf("  %s %v\n",
+		cfg.AutoExecuteSafe,
+		sourceNote(cfg, "auto_execute_safe"))
+	fmt.Printf("  %s %v %s\n",
 		ui.Colorize(ui.Yellow, "Show Confidence:"),
-		cfg.ShowConfidence)
-	fmt.Printf("  %s %s\n",
+		cfg.ShowConfidence,
+		sourceNote(cfg, "show_confidence"))
+	fmt.Printf("  %s %s %s\n",
 		ui.Colorize(ui.Yellow, "Provider:"),
-		ui.Colorize(ui.Green, string(cfg.Provider)))
+		ui.Colorize(ui.Green, string(cfg.Provider)),
+		sourceNote(cfg, "provider"))
 	if cfg.PreferredModel != "" {
-		fmt.Printf("  %s %s\n",
+		fmt.Printf("  %s %s %s\n",
 			ui.Colorize(ui.Yellow, "Preferred Model:"),
-			cfg.PreferredModel)
+			cfg.PreferredModel,
+			sourceNote(cfg, "preferred_model"))
+	}
+	if cfg.OllamaURL != "" {
+		fmt.Printf("  %s %s %s\n",
+			ui.Colorize(ui.Yellow, "Ollama URL:"),
+			cfg.OllamaURL,
+			sourceNote(cfg, "ollama_url"))
 	}
 	if len(cfg.ToolSpecificModes) > 0 {
 		fmt.Println(ui.Colorize(ui.Yellow, "  Tool-Specific Modes:"))
-		for tool, mode := range cfg.ToolSpecificModes {
-			fmt.Printf("    %s: %s\n", tool, mode)
+		tools := make([]string, 0, len(cfg.ToolSpecificModes))
+		for tool := range cfg.ToolSpecificModes {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			fmt.Printf("    %s: %s %s\n", tool, cfg.ToolSpecificModes[tool],
+				sourceNote(cfg, "tool_specific_modes."+tool))
 		}
 	}
+	fmt.Printf("  %s %s %s\n",
+		ui.Colorize(ui.Yellow, "Danger Policy:"),
+		ui.Colorize(ui.Green, string(cfg.DangerPolicy)),
+		sourceNote(cfg, "danger_policy"))
+	fmt.Printf("  %s %s %s\n",
+		ui.Colorize(ui.Yellow, "Production:"),
+		strings.Join(cfg.ProductionPatterns, ", "),
+		sourceNote(cfg, "production_patterns"))
+	cacheScopes := "global"
+	if len(cfg.CacheScopes) > 0 {
+		cacheScopes = strings.Join(cfg.CacheScopes, ", ")
+	}
+	fmt.Printf("  %s %s %s\n",
+		ui.Colorize(ui.Yellow, "Cache Scope:"),
+		cacheScopes,
+		sourceNote(cfg, "cache_scopes"))
+	fmt.Printf("  %s %s %s\n",
+		ui.Colorize(ui.Yellow, "Cache Backend:"),
+		cfg.CacheBackend,
+		sourceNote(cfg, "cache_backend"))
 }
 
-// handleConfigSet updates configuration
-func handleConfigSet(cfg *config.Config, configFile string) {
-	if len(os.Args) < 4 {
-		fmt.Println(ui.Colorize(ui.Red, "Usage: ai-helper config-set <key> <value>"))
-		fmt.Println()
-		fmt.Println("Available keys:")
-		fmt.Println("  mode <auto|interactive|manual|disabled> - Set activation mode")
-		fmt.Println("  tool-mode <tool> <mode> - Set tool-specific mode")
-		fmt.Println("  confidence <true|false> - Show/hide confidence scores")
-		fmt.Println("  provider <ollama|opencode> - Set LLM provider")
-		fmt.Println("  model <model-name> - Set preferred model")
-		fmt.Println()
-		fmt.Println("Examples:")
-		fmt.Println("  ai-helper config-set mode interactive")
-		fmt.Println("  ai-helper config-set tool-mode kubectl interactive")
-		fmt.Println("  ai-helper config-set confidence false")
-		fmt.Println("  ai-helper config-set provider opencode")
-		fmt.Println("  ai-helper config-set model anthropic/claude-sonnet-4-20250514")
-		os.Exit(1)
-	}
-
-	key := os.Args[2]
-	value := os.Args[3]
+// sourceNote formats the layer a config value came from
+func sourceNote(cfg *config.Config, key string) string {
+	return ui.Colorize(ui.Dim, "("+cfg.Source(key)+")")
+}
 
-	switch key {
-	case "mode":
-		if !config.ValidateMode(value) {
-			ui.PrintError("Invalid mode. Use: auto, interactive, manual, or disabled")
-			os.Exit(1)
+// handleConfigValidate lints a config file, or the user file, project file
+// and AI_HELPER_* environment when no file is given
+func handleConfigValidate(configFile, cwd string) {
+	var files []string
+	if len(os.Args) > 2 {
+		files = os.Args[2:]
+	} else {
+		if _, err := os.Stat(configFile); err == nil {
+			files = append(files, configFile)
 		}
-		cfg.ActivationMode = config.ActivationMode(value)
-		ui.PrintSuccess(fmt.Sprintf("Activation mode set to: %s", value))
-
-	case "tool-mode":
-		if len(os.Args) < 5 {
-			ui.PrintError("Usage: ai-helper config-set tool-mode <tool> <mode>")
-			os.Exit(1)
+		if projectFile := config.FindProjectFile(cwd); projectFile != "" {
+			files = append(files, projectFile)
 		}
-		tool := os.Args[3]
-		mode := os.Args[4]
-		if !config.ValidateMode(mode) {
-			ui.PrintError("Invalid mode. Use: auto, interactive, manual, or disabled")
-			os.Exit(1)
+	}
+
+	failed := false
+	for _, file := range files {
+		report, err := config.ValidateFile(file)
+		if err != nil {
+			failed = true
+			ui.PrintError(fmt.Sprintf("%s is invalid:", file))
+			fmt.Println(err)
+			continue
 		}
-		cfg.ToolSpecificModes[tool] = config.ActivationMode(mode)
-		ui.PrintSuccess(fmt.Sprintf("Mode for %s set to: %s", tool, mode))
-
-	case "confidence":
-		if value == "true" {
-			cfg.ShowConfidence = true
-		} else if value == "false" {
-			cfg.ShowConfidence = false
-		} else {
-			ui.PrintError("Invalid value. Use: true or false")
-			os.Exit(1)
+		ui.PrintSuccess(fmt.Sprintf("%s is valid", file))
+		if report.Migrated {
+			ui.PrintInfo(fmt.Sprintf("  Written in version %d; it will be upgraded to version %d when loaded", report.Version, config.CurrentVersion))
 		}
-		ui.PrintSuccess(fmt.Sprintf("Show confidence set to: %s", value))
+	}
 
-	case "provider":
-		provider := config.LLMProvider(value)
-		if provider != config.ProviderOllama && provider != config.ProviderOpenCode {
-			ui.PrintError("Invalid provider. Use: ollama or opencode")
-			os.Exit(1)
+	// Environment overrides are only checked when linting the active config
+	if len(os.Args) <= 2 {
+		if _, err := config.Resolve(configFile, cwd); err != nil {
+			var verr *config.ValidationError
+			if errors.As(err, &verr) && strings.HasPrefix(verr.Source, config.EnvPrefix) {
+				failed = true
+				ui.PrintError("Environment override is invalid:")
+				fmt.Println(err)
+			}
+		}
+		if len(files) == 0 && !failed {
+			ui.PrintInfo("No config files found; using defaults")
 		}
-		cfg.Provider = provider
-		ui.PrintSuccess(fmt.Sprintf("Provider set to: %s", value))
-
-	case "model":
-		cfg.PreferredModel = value
-		ui.PrintSuccess(fmt.Sprintf("Preferred model set to: %s", value))
-
-	default:
-		ui.PrintError(fmt.Sprintf("Unknown key: %s", key))
-		os.Exit(1)
 	}
 
-	// Save configuration
-	if err := cfg.Save(configFile); err != nil {
-		ui.PrintError(fmt.Sprintf("Failed to save config: %v", err))
+	if failed {
 		os.Exit(1)
 	}
 }
@@ -505,13 +856,33 @@ func printUsage() {
 	fmt.Printf(`AI Terminal Helper v%s (Go)
 
 Usage:
+  ai-helper [--config <dir>] <command> ...
   ai-helper analyze <command> <exit_code> [error_output]
   ai-helper proactive <query>
   ai-helper cache-stats
   ai-helper cache-clear
+  ai-helper cache-list [--tool <tool>] [--sort hits|recent] [--limit <n>] [--json]
+  ai-helper cache-search <text> [--tool <tool>] [--json]
+  ai-helper cache-show <key> [--json]
+  ai-helper cache-delete <key>
+  ai-helper cache-edit <key>
+  ai-helper cache-export <file|-> [--author <name>]
+  ai-helper cache-import <file> | --list | --rollback <id>
+  ai-helper cache-migrate <json|bolt>
+  ai-helper pause [duration]
+  ai-helper resume
+  ai-helper audit verify
+  ai-helper validators
+  ai-helper schema import <tool> [subcommand ...] [--file <file>] [--tool-version <version>]
+  ai-helper schema show <tool> | list
   ai-helper config-show
+  ai-helper config-get [key] [--json]
   ai-helper config-set <key> <value>
+  ai-helper config-unset <key>
   ai-helper config-reset
+  ai-helper config-validate [file]
+  ai-helper profile list | use <name> | off | show <name>
+  ai-helper profile set <name> <key> <value> | delete <name>
   ai-helper version | -v | --version
   ai-helper help | -h | --help
 
@@ -519,6 +890,10 @@ Examples:
   ai-helper analyze "kubectl get pods" 127 "command not found"
   ai-helper proactive "how do I list all docker containers"
   ai-helper cache-stats
+  ai-helper cache-list --tool kubectl --sort hits
+  ai-helper cache-export team-patterns.json
   ai-helper config-set mode interactive
+  ai-helper config-set tool_specific_modes.kubectl interactive
+  docker run --help | ai-helper schema import docker run
 `, version)
 }
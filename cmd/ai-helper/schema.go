@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/ui"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
+)
+
+// handleSchema imports, shows and lists the flag schemas validators check
+// commands against
+func handleSchema(schemaDir string) {
+	if len(os.Args) < 3 {
+		ui.PrintError("Usage: ai-helper schema import <tool> [subcommand ...] [--file <file>] [--tool-version <version>] | show <tool> | list")
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "import":
+		handleSchemaImport(schemaDir)
+	case "show":
+		handleSchemaShow()
+	case "list":
+		handleSchemaList()
+	default:
+		ui.PrintError(fmt.Sprintf("Unknown schema command: %s", os.Args[2]))
+		os.Exit(1)
+	}
+}
+
+// handleSchemaImport reads --help text or cobra completion output (from
+// stdin or --file) and merges it into the user schema of a tool
+func handleSchemaImport(schemaDir string) {
+	var path []string
+	var file, toolVersion string
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--file", "--tool-version":
+			if i+1 >= len(args) {
+				ui.PrintError(fmt.Sprintf("%s requires a value", args[i]))
+				os.Exit(1)
+			}
+			if args[i] == "--file" {
+				file = args[i+1]
+			} else {
+				toolVersion = args[i+1]
+			}
+			i++
+		default:
+			path = append(path, args[i])
+		}
+	}
+	if len(path) == 0 {
+		ui.PrintError("Usage: ai-helper schema import <tool> [subcommand ...] [--file <file>] [--tool-version <version>]")
+		os.Exit(1)
+	}
+	tool := path[0]
+
+	var data []byte
+	var err error
+	if file == "" || file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to read help output: %v", err))
+		os.Exit(1)
+	}
+
+	cmd := schema.ParseOutput(string(data))
+	commands, flags := cmd.Count()
+	if commands == 0 && flags == 0 {
+		ui.PrintError("No subcommands or flags found in the input")
+		ui.PrintInfo(fmt.Sprintf("Pipe in '%s --help' or cobra completion ('%s __complete <subcommand> -')", tool, tool))
+		os.Exit(1)
+	}
+
+	target := schema.FileName(schemaDir, tool)
+	s, err := schema.Read(target, tool)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to read schema: %v", err))
+		os.Exit(1)
+	}
+	s.Import(path[1:], cmd)
+	if toolVersion != "" {
+		s.ToolVersion = toolVersion
+	}
+	if err := s.Write(target); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to write schema: %v", err))
+		os.Exit(1)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Imported %d subcommand(s) and %d flag(s) for '%s' into %s",
+		commands, flags, strings.Join(path, " "), target))
+}
+
+// handleSchemaShow prints the merged schema of a tool as JSON
+func handleSchemaShow() {
+	if len(os.Args) != 4 {
+		ui.PrintError("Usage: ai-helper schema show <tool>")
+		os.Exit(1)
+	}
+	s := schema.Lookup(os.Args[3])
+	if s == nil {
+		ui.PrintError(fmt.Sprintf("No schema for %s", os.Args[3]))
+		os.Exit(1)
+	}
+
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to encode schema: %v", err))
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+// handleSchemaList lists the tools that have a schema
+func handleSchemaList() {
+	fmt.Println(ui.Colorize(ui.CyanBold, "Flag schemas:"))
+	for _, tool := range schema.Tools() {
+		s := schema.Lookup(tool)
+		commands, flags := s.Count()
+		version := s.ToolVersion
+		if version == "" {
+			version = "unknown version"
+		}
+		fmt.Printf("  %-12s %-16s %d subcommand(s), %d flag(s)\n", tool, version, commands, flags)
+	}
+}
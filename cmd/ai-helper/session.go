@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/amaslovskyi/ai-helper/pkg/interactive"
+	"github.com/amaslovskyi/ai-helper/pkg/session"
+	"github.com/amaslovskyi/ai-helper/pkg/ui"
+)
+
+// handlePause disables AI for the current terminal, optionally for a duration
+func handlePause(sessions *session.Store, sessionID string) {
+	if len(os.Args) > 3 {
+		ui.PrintError("Usage: ai-helper pause [duration]  (e.g. 30m, 2h)")
+		os.Exit(1)
+	}
+
+	var duration time.Duration
+	if len(os.Args) == 3 {
+		d, err := session.ParseDuration(os.Args[2])
+		if err != nil || d == 0 {
+			ui.PrintError(fmt.Sprintf("Invalid duration %q (e.g. 30m, 2h)", os.Args[2]))
+			os.Exit(1)
+		}
+		duration = d
+	}
+
+	pauseSession(sessions, sessionID, duration)
+}
+
+// pauseSession pauses AI for the terminal, for d or until resumed when d
+// is 0, and reports until when
+func pauseSession(sessions *session.Store, sessionID string, d time.Duration) {
+	// Drop stale sessions while we are here
+	_ = sessions.Prune()
+
+	state, err := sessions.Pause(sessionID, d)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to pause session: %v", err))
+		os.Exit(1)
+	}
+
+	if state.Until > 0 {
+		ui.PrintSuccess(fmt.Sprintf("AI paused in this terminal until %s", time.Unix(state.Until, 0).Format("15:04")))
+	} else {
+		ui.PrintSuccess("AI paused in this terminal until 'ai-helper resume'")
+	}
+}
+
+// askPauseDuration asks how long the error menu's disable option pauses
+// for, taking the same durations as 'ai-helper pause'
+func askPauseDuration() time.Duration {
+	for {
+		input := interactive.Prompt("Pause for how long? (e.g. 30m, 2h; Enter = until 'ai-helper resume'):")
+		d, err := session.ParseDuration(input)
+		if err == nil {
+			return d
+		}
+		ui.PrintWarning(err.Error())
+	}
+}
+
+// handleResume re-enables AI for the current terminal
+func handleResume(sessions *session.Store, sessionID string) {
+	if err := sessions.Resume(sessionID); err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to resume session: %v", err))
+		os.Exit(1)
+	}
+	_ = sessions.Prune()
+	ui.PrintSuccess("AI resumed in this terminal")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
+	"github.com/amaslovskyi/ai-helper/pkg/ui"
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// handleValidators lists the registered validators in the order they are
+// tried, with the tools and aliases each one handles and the installed
+// versions their rules are checked against
+func handleValidators() {
+	fmt.Println(ui.Colorize(ui.CyanBold, "Validators (tried in this order):"))
+	for _, r := range validators.Registrations() {
+		names := strings.Join(r.Tools, ", ")
+		if aliases := r.Aliases; len(aliases) > 0 {
+			more := ""
+			if len(aliases) > 8 {
+				aliases, more = aliases[:8], fmt.Sprintf(" +%d more", len(aliases)-8)
+			}
+			names += fmt.Sprintf(" (aliases: %s%s)", strings.Join(aliases, ", "), more)
+		}
+		fmt.Printf("  %-12s priority %-3d %s\n", r.Name, r.Priority, names)
+		if r.Source != validators.SourceBuiltin {
+			fmt.Println(ui.Colorize(ui.Yellow, "               plugin: "+r.Source))
+			continue
+		}
+		var installed []string
+		for _, tool := range r.Tools {
+			if v := toolversion.Lookup(tool); !v.IsZero() {
+				installed = append(installed, tool+" "+v.String())
+			}
+		}
+		if len(installed) > 0 {
+			fmt.Println("               installed: " + strings.Join(installed, ", "))
+		}
+	}
+}
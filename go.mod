@@ -2,4 +2,10 @@ module github.com/amaslovskyi/ai-helper
 
 go 1.25.5
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require (
+	go.etcd.io/bbolt v1.4.3
+	gopkg.in/yaml.v3 v3.0.1
+	mvdan.cc/sh/v3 v3.12.0
+)
+
+require golang.org/x/sys v0.33.0 // indirect
@@ -0,0 +1,269 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/amaslovskyi/ai-helper/pkg/security"
+)
+
+// Events
+const (
+	EventAnalyze   = "analyze"   // A failed command was analyzed
+	EventProactive = "proactive" // A command was generated from a question
+	EventExec      = "exec"      // A suggested command was executed
+)
+
+// Record is one line of the audit log. Command, Error and Suggestion are
+// redacted before they are written.
+type Record struct {
+	Seq     int64  `json:"seq"`
+	Time    string `json:"time"` // RFC 3339, UTC
+	Event   string `json:"event"`
+	Session string `json:"session,omitempty"`
+
+	Command  string `json:"command,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	// Source is where the suggestion came from: llm, cache, seed or
+	// validator (an offline fix)
+	Source     string `json:"source,omitempty"`
+	Model      string `json:"model,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+
+	// Validation is "ok" or the validator's message
+	Validation string `json:"validation,omitempty"`
+
+	// Security is the scanner's action (block, confirm, warn, log, or
+	// "none") and the rules that matched
+	Security *Verdict `json:"security,omitempty"`
+
+	// Choice is what the user picked: a menu action, "acknowledged" or
+	// "declined" at a danger prompt, or "executed"
+	Choice string `json:"choice,omitempty"`
+
+	// Acknowledged records who accepted the security findings and when
+	Acknowledged *Acknowledgement `json:"acknowledged,omitempty"`
+
+	// Prev is the hash of the previous record; Hash covers this record
+	// (without Hash) and Prev, so editing, reordering or removing a line
+	// breaks the chain
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// Verdict is the security scanner's decision on a suggestion
+type Verdict struct {
+	Action string   `json:"action"`
+	Rules  []string `json:"rules,omitempty"`
+
+	// Allowed lists the rules a project allowlist downgraded
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// Acknowledgement is a user accepting a risky suggestion. Always is set
+// when it was also added to the project's allowlist.
+type Acknowledgement struct {
+	By     string `json:"by"`
+	At     string `json:"at"` // RFC 3339, UTC
+	Always bool   `json:"always,omitempty"`
+}
+
+// genesis is the Prev of the first record
+const genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Log is an append-only, hash-chained JSON lines file
+type Log struct {
+	file string
+}
+
+// New returns the log stored in file (usually ~/.ai/audit.log)
+func New(file string) *Log {
+	return &Log{file: file}
+}
+
+// Location returns the log file path
+func (l *Log) Location() string {
+	return l.file
+}
+
+// Append redacts, chains and writes a record. Appends from concurrent
+// terminals are serialized with a file lock.
+func (l *Log) Append(rec Record) error {
+	if err := os.MkdirAll(filepath.Dir(l.file), 0755); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	f, err := os.OpenFile(l.file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	last, err := lastRecord(f)
+	if err != nil {
+		return err
+	}
+
+	rec.Seq = 1
+	rec.Prev = genesis
+	if last != nil {
+		rec.Seq = last.Seq + 1
+		rec.Prev = last.Hash
+	}
+	if rec.Time == "" {
+		rec.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	rec.Command = security.Redact(rec.Command)
+	rec.Error = security.Redact(rec.Error)
+	rec.Suggestion = security.Redact(rec.Suggestion)
+
+	rec.Hash, err = hash(rec)
+	if err != nil {
+		return err
+	}
+
+	line, err := marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	return err
+}
+
+// marshal encodes a record as one JSON line, leaving <, > and & readable
+func marshal(rec Record) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(rec); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hash returns the hex SHA-256 of a record's JSON without its Hash field
+func hash(rec Record) (string, error) {
+	rec.Hash = ""
+	data, err := marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastRecord reads the final line of the log (nil for an empty log)
+func lastRecord(f *os.File) (*Record, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return nil, nil
+	}
+
+	// Records are small; read backwards in chunks until a full line is found
+	const chunk = 4096
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		n := int64(chunk)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 || offset == 0 {
+			var rec Record
+			if err := json.Unmarshal(trimmed[i+1:], &rec); err != nil {
+				return nil, fmt.Errorf("audit log %s has a corrupt last line; run 'ai-helper audit verify'", f.Name())
+			}
+			return &rec, nil
+		}
+	}
+	return nil, nil
+}
+
+// VerifyError reports where the chain is broken
+type VerifyError struct {
+	Line   int
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Verify checks every record's hash and link to its predecessor. It returns
+// the number of records and the hash of the last one, which can be kept
+// elsewhere to detect truncation later. A missing log verifies as empty.
+func (l *Log) Verify() (int, string, error) {
+	f, err := os.Open(l.file)
+	if os.IsNotExist(err) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	count := 0
+	prev := genesis
+	for scanner.Scan() {
+		line := count + 1
+
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return count, prev, &VerifyError{Line: line, Reason: "not a valid record"}
+		}
+		if rec.Seq != int64(line) {
+			return count, prev, &VerifyError{Line: line, Reason: fmt.Sprintf("sequence %d, expected %d (records removed or reordered)", rec.Seq, line)}
+		}
+		if rec.Prev != prev {
+			return count, prev, &VerifyError{Line: line, Reason: "does not chain to the previous record"}
+		}
+		sum, err := hash(rec)
+		if err != nil {
+			return count, prev, err
+		}
+		if sum != rec.Hash {
+			return count, prev, &VerifyError{Line: line, Reason: "contents do not match the record hash (edited)"}
+		}
+
+		prev = rec.Hash
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, prev, err
+	}
+
+	if count == 0 {
+		return 0, "", nil
+	}
+	return count, prev, nil
+}
@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLog appends n records to a new log and returns it
+func writeLog(t *testing.T, n int) *Log {
+	t.Helper()
+	log := New(filepath.Join(t.TempDir(), "audit.log"))
+	for i := 0; i < n; i++ {
+		if err := log.Append(Record{Event: EventAnalyze, Command: "kubectl get pods", Suggestion: "kubectl get pods -A"}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	return log
+}
+
+// lines returns the log's records as lines
+func lines(t *testing.T, log *Log) [][]byte {
+	t.Helper()
+	data, err := os.ReadFile(log.Location())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+}
+
+// rewrite replaces the log's contents with lines
+func rewrite(t *testing.T, log *Log, lines [][]byte) {
+	t.Helper()
+	data := append(bytes.Join(lines, []byte("\n")), '\n')
+	if err := os.WriteFile(log.Location(), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+		line   int    // Line of the expected VerifyError, 0 for a valid chain
+		reason string // Part of the expected reason
+	}{
+		{
+			name:   "intact",
+			tamper: func(lines [][]byte) [][]byte { return lines },
+		},
+		{
+			name: "edited",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte("get pods -A"), []byte("delete pods"), 1)
+				return lines
+			},
+			line:   2,
+			reason: "edited",
+		},
+		{
+			name: "removed",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1:1], lines[2:]...)
+			},
+			line:   2,
+			reason: "removed or reordered",
+		},
+		{
+			name: "reordered",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			line:   2,
+			reason: "removed or reordered",
+		},
+		{
+			name: "not json",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[2] = []byte("garbage")
+				return lines
+			},
+			line:   3,
+			reason: "not a valid record",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := writeLog(t, 3)
+			rewrite(t, log, tt.tamper(lines(t, log)))
+
+			count, last, err := log.Verify()
+			if tt.line == 0 {
+				if err != nil || count != 3 || last == "" {
+					t.Fatalf("Verify() = %d, %q, %v; want 3 records", count, last, err)
+				}
+				return
+			}
+
+			var verr *VerifyError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Verify() error = %v, want a *VerifyError", err)
+			}
+			if verr.Line != tt.line || !strings.Contains(verr.Reason, tt.reason) {
+				t.Errorf("Verify() = line %d %q, want line %d %q", verr.Line, verr.Reason, tt.line, tt.reason)
+			}
+			if count != tt.line-1 {
+				t.Errorf("Verify() counted %d good records, want %d", count, tt.line-1)
+			}
+		})
+	}
+}
+
+func TestVerifyMissingLog(t *testing.T) {
+	count, last, err := New(filepath.Join(t.TempDir(), "audit.log")).Verify()
+	if count != 0 || last != "" || err != nil {
+		t.Errorf("Verify() = %d, %q, %v; want an empty log", count, last, err)
+	}
+}
+
+func TestAppendChains(t *testing.T) {
+	log := writeLog(t, 2)
+	_, last, err := log.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := log.Append(Record{Event: EventExec, Command: "ls"}); err != nil {
+		t.Fatal(err)
+	}
+	recs := lines(t, log)
+	if len(recs) != 3 || !bytes.Contains(recs[2], []byte(`"prev":"`+last+`"`)) {
+		t.Errorf("third record does not chain to %s: %s", last, recs[len(recs)-1])
+	}
+	if count, _, err := log.Verify(); err != nil || count != 3 {
+		t.Errorf("Verify() = %d, %v; want 3 records", count, err)
+	}
+}
+
+func TestAppendRedacts(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "audit.log"))
+	if err := log.Append(Record{Event: EventAnalyze, Command: "mysql --password hunter22"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(log.Location()); bytes.Contains(data, []byte("hunter22")) {
+		t.Errorf("secret written to the audit log: %s", data)
+	}
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// Sort orders for Records
+const (
+	SortByHits   = "hits"
+	SortByRecent = "recent"
+)
+
+// SourcePersonal marks entries stored in the personal cache file
+const SourcePersonal = "personal"
+
+// Record is a resolved cache entry together with its key and origin
+type Record struct {
+	Key    string `json:"key"`
+	Tool   string `json:"tool"`
+	Source string `json:"source"` // "personal" or "team:<import-id>"
+	*Entry
+}
+
+// ListOptions filters and orders Records
+type ListOptions struct {
+	Tool   string // Only entries for this tool (aliases are resolved)
+	Query  string // Case-insensitive text that must appear in command, error or fix
+	SortBy string // SortByHits or SortByRecent (default)
+}
+
+// Records returns the effective cache entries, one per key, after resolving
+// conflicts between personal entries and team packs
+func (c *Cache) Records(opts ListOptions) []Record {
+	aliases := validators.NewAliasMapper()
+	tool := aliases.GetToolName(opts.Tool)
+	query := strings.ToLower(opts.Query)
+
+	// The store narrows personal entries by tool using its index
+	personal, _ := c.store.List(Query{Tool: tool})
+	keys := make([]string, 0, len(personal))
+	seen := make(map[string]bool, len(personal))
+	for _, stored := range personal {
+		keys = append(keys, stored.Key)
+		seen[stored.Key] = true
+	}
+	for _, pack := range c.imports {
+		for key := range pack.Entries {
+			if !seen[key] {
+				keys = append(keys, key)
+				seen[key] = true
+			}
+		}
+	}
+
+	entries := c.lookupAll(keys)
+	records := make([]Record, 0, len(entries))
+	for key, entry := range entries {
+		record := Record{
+			Key:    key,
+			Tool:   aliases.GetToolName(entry.Command),
+			Source: sourceOf(entry),
+			Entry:  entry,
+		}
+
+		if tool != "" && record.Tool != tool {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(entry.Command+"\n"+entry.Error+"\n"+entry.Fix), query) {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if opts.SortBy == SortByHits && a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		if a.LastUsed != b.LastUsed {
+			return a.LastUsed > b.LastUsed
+		}
+		return a.Key < b.Key
+	})
+
+	return records
+}
+
+// Find resolves a full key or unique key prefix to a record
+func (c *Cache) Find(keyPrefix string) (*Record, error) {
+	if keyPrefix == "" {
+		return nil, fmt.Errorf("empty cache key")
+	}
+
+	var match *Record
+	for _, record := range c.Records(ListOptions{}) {
+		if !strings.HasPrefix(record.Key, keyPrefix) {
+			continue
+		}
+		if record.Key == keyPrefix {
+			r := record
+			return &r, nil
+		}
+		if match != nil {
+			return nil, fmt.Errorf("cache key %q is ambiguous", keyPrefix)
+		}
+		r := record
+		match = &r
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("no cache entry with key %q", keyPrefix)
+	}
+	return match, nil
+}
+
+// Delete removes the effective entry for a key from whichever layer holds it
+func (c *Cache) Delete(key string) error {
+	entry := c.lookup(key)
+	if entry == nil {
+		return fmt.Errorf("no cache entry with key %q", key)
+	}
+
+	if entry.Import == "" {
+		return c.store.Delete(key)
+	}
+
+	pack := c.pack(entry.Import)
+	delete(pack.Entries, key)
+	return c.savePack(pack)
+}
+
+// Update replaces the entry stored under key. If the command, error or scope
+// changed, the entry is moved to its new key in the same layer, unless
+// another entry already has that key. An entry whose suggested command
+// contains a secret is not stored and ErrSecret is returned.
+func (c *Cache) Update(key string, updated *Entry) (string, error) {
+	current := c.lookup(key)
+	if current == nil {
+		return "", fmt.Errorf("no cache entry with key %q", key)
+	}
+	if containsSecret(updated) {
+		return "", ErrSecret
+	}
+
+	updated.Import = current.Import
+	newKey := c.entryKey(updated)
+	if newKey != key && c.lookup(newKey) != nil {
+		return "", fmt.Errorf("another cache entry already has key %s", newKey)
+	}
+
+	if current.Import == "" {
+		if newKey != key {
+			if err := c.store.Delete(key); err != nil {
+				return "", err
+			}
+		}
+		return newKey, c.store.Put(newKey, updated)
+	}
+
+	pack := c.pack(current.Import)
+	delete(pack.Entries, key)
+	pack.Entries[newKey] = updated
+	return newKey, c.savePack(pack)
+}
+
+// persist writes the layer holding the entry stored under key back to disk
+func (c *Cache) persist(key string, entry *Entry) error {
+	if entry.Import == "" {
+		return c.store.Put(key, entry)
+	}
+	return c.savePack(c.pack(entry.Import))
+}
+
+// pack returns the imported pack with the given ID
+func (c *Cache) pack(id string) *Pack {
+	for _, pack := range c.imports {
+		if pack.ID == id {
+			return pack
+		}
+	}
+	return nil
+}
+
+// savePack writes an imported pack back to the team directory
+func (c *Cache) savePack(pack *Pack) error {
+	if pack == nil {
+		return fmt.Errorf("unknown team pack")
+	}
+
+	data, err := marshalPack(pack)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(c.teamDir, pack.ID+".json"), data, 0644)
+}
+
+// sourceOf describes which layer an entry lives in
+func sourceOf(entry *Entry) string {
+	if entry.Import == "" {
+		return SourcePersonal
+	}
+	return "team:" + entry.Import
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/llm"
+)
+
+// newTestCache opens a JSON cache in a temporary directory
+func newTestCache(t *testing.T, dir string) *Cache {
+	t.Helper()
+	c, err := NewCache(dir, BackendJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// sharedPrefix returns two keys of c's records that start with the same
+// character and that character
+func sharedPrefix(t *testing.T, c *Cache) (string, string, string) {
+	t.Helper()
+	first := make(map[byte]string)
+	for _, record := range c.Records(ListOptions{}) {
+		if other, ok := first[record.Key[0]]; ok {
+			return other, record.Key, record.Key[:1]
+		}
+		first[record.Key[0]] = record.Key
+	}
+	t.Fatal("no two keys share a first character")
+	return "", "", ""
+}
+
+func TestFind(t *testing.T) {
+	c := newTestCache(t, t.TempDir())
+	// More entries than hex digits, so two keys share a first character
+	for i := 0; i < 17; i++ {
+		command := "kubectl get pods -n ns" + string(rune('a'+i))
+		if err := c.Set(command, "not found", Scope{}, &llm.Response{Suggestion: command + " -A"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b, ambiguous := sharedPrefix(t, c)
+	common := 0
+	for common < len(a) && a[common] == b[common] {
+		common++
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   string // Key of the found record
+		err    string // Part of the expected error
+	}{
+		{name: "full key", prefix: a, want: a},
+		{name: "unique prefix", prefix: a[:common+1], want: a},
+		{name: "other unique prefix", prefix: b[:common+1], want: b},
+		{name: "ambiguous prefix", prefix: ambiguous, err: "is ambiguous"},
+		{name: "missing key", prefix: "zz", err: "no cache entry"},
+		{name: "empty key", prefix: "", err: "empty cache key"},
+	}
+
+	for _, tt := range tests {
+		record, err := c.Find(tt.prefix)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: Find(%q) error = %v, want %q", tt.name, tt.prefix, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Find(%q): %v", tt.name, tt.prefix, err)
+			continue
+		}
+		if record.Key != tt.want || record.Source != SourcePersonal {
+			t.Errorf("%s: Find(%q) = %s from %s, want %s", tt.name, tt.prefix, record.Key, record.Source, tt.want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	const command, failure = "kubectl get pods", "connection refused"
+
+	tests := []struct {
+		name   string
+		change func(e *Entry)
+		moves  bool // Whether the key changes
+		err    error
+	}{
+		{name: "fix only", change: func(e *Entry) { e.Fix = "✓ kubectl get pods -A\nRoot: wrong namespace" }},
+		{name: "rating", change: func(e *Entry) { e.Rating = 5 }},
+		{name: "error", change: func(e *Entry) { e.Error = "forbidden" }, moves: true},
+		{name: "scope", change: func(e *Entry) { e.Scope = &Scope{KubeContext: "prod"} }, moves: true},
+		{name: "secret", change: func(e *Entry) { e.Fix = "✓ kubectl get pods --token=abcdef0123456789abcdef\nRoot: r" }, err: ErrSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := newTestCache(t, dir)
+			if err := c.Set(command, failure, Scope{}, &llm.Response{Suggestion: "kubectl get pods", RootCause: "r"}); err != nil {
+				t.Fatal(err)
+			}
+			key := c.makeKey(command, failure, Scope{})
+
+			updated := *c.lookup(key)
+			tt.change(&updated)
+			newKey, err := c.Update(key, &updated)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Update error = %v, want %v", err, tt.err)
+				}
+				if entry := c.lookup(key); entry == nil || entry.Fix == updated.Fix {
+					t.Errorf("rejected update changed the entry: %+v", entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+
+			if (newKey != key) != tt.moves {
+				t.Errorf("Update moved %s to %s, want moved %v", key, newKey, tt.moves)
+			}
+			if tt.moves && c.lookup(key) != nil {
+				t.Errorf("entry still stored under the old key %s", key)
+			}
+
+			// The change is on disk under the new key
+			reopened := newTestCache(t, dir)
+			if entry := reopened.lookup(newKey); entry == nil || entry.Fix != updated.Fix || entry.Rating != updated.Rating {
+				t.Errorf("stored entry = %+v, want %+v", entry, updated)
+			}
+		})
+	}
+
+	if _, err := newTestCache(t, t.TempDir()).Update("missing", &Entry{}); err == nil {
+		t.Error("Update of a missing key should fail")
+	}
+}
+
+func TestUpdateKeyTaken(t *testing.T) {
+	c := newTestCache(t, t.TempDir())
+	for _, failure := range []string{"connection refused", "forbidden"} {
+		if err := c.Set("kubectl get pods", failure, Scope{}, &llm.Response{Suggestion: "kubectl get pods", RootCause: failure}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key := c.makeKey("kubectl get pods", "connection refused", Scope{})
+	taken := c.makeKey("kubectl get pods", "forbidden", Scope{})
+
+	updated := *c.lookup(key)
+	updated.Error = "forbidden"
+	if _, err := c.Update(key, &updated); err == nil || !strings.Contains(err.Error(), "already has key") {
+		t.Fatalf("Update error = %v, want the key to be taken", err)
+	}
+	if c.lookup(key) == nil || !strings.Contains(c.lookup(taken).Fix, "Root: forbidden") {
+		t.Error("a refused update changed the stored entries")
+	}
+}
+
+func TestUpdateTeamEntry(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestCache(t, dir)
+	key := c.makeKey("helm list", "context deadline exceeded", Scope{})
+
+	file := filepath.Join(t.TempDir(), "team.json")
+	pack := &Pack{Version: PackVersion, Entries: map[string]*Entry{
+		key: {Command: "helm list", Error: "context deadline exceeded", Fix: "✓ helm list -A\nRoot: r"},
+	}}
+	if err := WritePack(pack, file); err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.Import(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := *c.lookup(key)
+	updated.Error = "timed out"
+	newKey, err := c.Update(key, &updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry stays in the team pack and is not copied to the personal store
+	if personal, _ := c.store.Get(newKey); personal != nil {
+		t.Error("team entry was moved to the personal store")
+	}
+	reopened := newTestCache(t, dir)
+	if entry := reopened.lookup(newKey); entry == nil || entry.Import != result.ID {
+		t.Errorf("entry under %s = %+v, want one from pack %s", newKey, entry, result.ID)
+	}
+	if reopened.lookup(key) != nil {
+		t.Errorf("team entry still stored under the old key %s", key)
+	}
+}
@@ -3,7 +3,7 @@ package cache
 import (
 	"crypto/md5"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,65 +11,130 @@ import (
 	"time"
 
 	"github.com/amaslovskyi/ai-helper/pkg/llm"
+	"github.com/amaslovskyi/ai-helper/pkg/security"
 )
 
+// ErrSecret is returned by Set for a response that contains a secret;
+// such responses are never cached
+var ErrSecret = errors.New("response contains a secret")
+
 // Entry represents a cache entry
 type Entry struct {
-	Command   string    `json:"cmd"`
-	Error     string    `json:"error"`
-	Fix       string    `json:"fix"`
-	Timestamp int64     `json:"timestamp"`
-	Hits      int       `json:"hits"`
-	LastUsed  int64     `json:"last_used"`
+	Command   string `json:"cmd"`
+	Error     string `json:"error"`
+	Fix       string `json:"fix"`
+	Timestamp int64  `json:"timestamp"`
+	Hits      int    `json:"hits"`
+	LastUsed  int64  `json:"last_used"`
+
+	// Scope narrows the entry to a repository, kube context or terraform
+	// workspace. Nil means the entry applies everywhere.
+	Scope *Scope `json:"scope,omitempty"`
+
+	// Rating is an optional user-assigned quality score.
+	// Conflicts are resolved by rating first, then by hit count.
+	Rating int `json:"rating,omitempty"`
+
+	// Import is the ID of the team pack this entry came from (empty for personal entries)
+	Import string `json:"-"`
+}
+
+// outranks reports whether e should win a conflict against other
+func (e *Entry) outranks(other *Entry) bool {
+	if e.Rating != other.Rating {
+		return e.Rating > other.Rating
+	}
+	return e.Hits > other.Hits
 }
 
 // Cache manages the response cache
 type Cache struct {
-	file    string
-	entries map[string]*Entry
+	dir     string
+	backend string
+	store   Store // personal entries
+	teamDir string
+	imports []*Pack     // imported team packs, oldest first
+	seeds   []*SeedPack // embedded seed packs
 }
 
-// NewCache creates a new cache
-func NewCache(cacheFile string) (*Cache, error) {
+// NewCache opens the cache in dir using the given storage backend
+// (BackendJSON or BackendBolt; empty selects BackendJSON)
+func NewCache(dir, backend string) (*Cache, error) {
 	// Create directory if it doesn't exist
-	dir := filepath.Dir(cacheFile)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	c := &Cache{
-		file:    cacheFile,
-		entries: make(map[string]*Entry),
+	if backend == "" {
+		backend = BackendJSON
 	}
 
-	// Load existing cache
-	if err := c.load(); err != nil && !os.IsNotExist(err) {
+	// Open the personal entry store
+	store, err := OpenStore(dir, backend)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load cache: %w", err)
 	}
 
+	c := &Cache{
+		dir:     dir,
+		backend: backend,
+		store:   store,
+		teamDir: filepath.Join(dir, "team"),
+	}
+
+	// Load imported team packs
+	if err := c.loadImports(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to load team packs: %w", err)
+	}
+
+	// Load embedded seed packs
+	seeds, err := LoadSeedPacks()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to load seed packs: %w", err)
+	}
+	c.seeds = seeds
+
 	return c, nil
 }
 
-// Get retrieves a cached response
-func (c *Cache) Get(command, errorMsg string) (*llm.Response, bool) {
-	key := c.makeKey(command, errorMsg)
-	entry, ok := c.entries[key]
-	if !ok {
+// Get retrieves a cached response. Entries matching the most specific
+// narrowing of scope win; global entries are the final fallback.
+func (c *Cache) Get(command, errorMsg string, scope Scope) (*llm.Response, bool) {
+	candidates := scope.candidates()
+	keys := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		keys[i] = c.makeKey(command, errorMsg, candidate)
+	}
+
+	var entry *Entry
+	var key string
+	found := c.lookupAll(keys)
+	for _, key = range keys {
+		if entry = found[key]; entry != nil {
+			break
+		}
+	}
+	if entry == nil {
 		return nil, false
 	}
 
-	// Update hit counter and last used
+	// Update hit counter and last used (best effort, a failed write
+	// must not hide the cached answer)
 	entry.Hits++
 	entry.LastUsed = time.Now().Unix()
+	_ = c.persist(key, entry)
 
-	// Parse the fix into a response
-	response := &llm.Response{
-		Cached: true,
-	}
+	response := parseFix(entry.Fix)
+	response.Cached = true
+	return response, true
+}
 
-	// Parse the cached fix text
-	lines := strings.Split(entry.Fix, "\n")
-	for _, line := range lines {
+// parseFix parses the text of a cached fix into a response
+func parseFix(fix string) *llm.Response {
+	response := &llm.Response{}
+	for _, line := range strings.Split(fix, "\n") {
 		line = strings.TrimSpace(line)
 		switch {
 		case strings.HasPrefix(line, "✓"):
@@ -80,21 +145,32 @@ func (c *Cache) Get(command, errorMsg string) (*llm.Response, bool) {
 			response.Tip = strings.TrimSpace(strings.TrimPrefix(line, "Tip:"))
 		}
 	}
+	return response
+}
 
-	return response, true
+// containsSecret reports whether the command an entry suggests contains a
+// secret. Only the command is checked: the root cause and tip are prose,
+// where words like "token:" and "secret:" are normal.
+func containsSecret(entry *Entry) bool {
+	return security.ContainsSecret(parseFix(entry.Fix).Suggestion)
 }
 
-// Set stores a response in the cache
-func (c *Cache) Set(command, errorMsg string, response *llm.Response) error {
-	key := c.makeKey(command, errorMsg)
+// Set stores a response in the cache under the given scope. A response
+// whose suggested command contains a secret is not stored and ErrSecret is
+// returned.
+func (c *Cache) Set(command, errorMsg string, scope Scope, response *llm.Response) error {
+	key := c.makeKey(command, errorMsg, scope)
 
 	// Format the response as text
 	fix := fmt.Sprintf("✓ %s\nRoot: %s", response.Suggestion, response.RootCause)
 	if response.Tip != "" {
 		fix += fmt.Sprintf("\nTip: %s", response.Tip)
 	}
+	if security.ContainsSecret(response.Suggestion) {
+		return ErrSecret
+	}
 
-	c.entries[key] = &Entry{
+	entry := &Entry{
 		Command:   command,
 		Error:     errorMsg,
 		Fix:       fix,
@@ -102,64 +178,113 @@ func (c *Cache) Set(command, errorMsg string, response *llm.Response) error {
 		Hits:      0,
 		LastUsed:  time.Now().Unix(),
 	}
+	if !scope.IsGlobal() {
+		entry.Scope = &scope
+	}
 
-	return c.save()
+	return c.store.Put(key, entry)
 }
 
-// makeKey creates a cache key from command and error
-func (c *Cache) makeKey(command, errorMsg string) string {
-	// Use first line of error for key
-	errorFirstLine := strings.Split(errorMsg, "\n")[0]
-	data := command + "::" + errorFirstLine
-	hash := md5.Sum([]byte(data))
-	return hex.EncodeToString(hash[:])
+// lookup resolves a key across personal entries and team packs.
+// The highest-ranked entry wins; personal entries win ties.
+func (c *Cache) lookup(key string) *Entry {
+	return c.lookupAll([]string{key})[key]
 }
 
-// load loads the cache from disk
-func (c *Cache) load() error {
-	data, err := os.ReadFile(c.file)
-	if err != nil {
-		return err
+// lookupAll resolves several keys like lookup, reading the personal
+// entries from the store at once; keys without an entry are left out
+func (c *Cache) lookupAll(keys []string) map[string]*Entry {
+	// A store read error is treated as a miss so the LLM still answers
+	found, err := c.store.GetMany(keys)
+	if err != nil || found == nil {
+		found = make(map[string]*Entry, len(keys))
 	}
 
-	// Try to load with current format
-	if err := json.Unmarshal(data, &c.entries); err != nil {
-		// If it fails, might be old bash format or corrupted
-		// Start with empty cache instead of failing
-		c.entries = make(map[string]*Entry)
-		return nil
+	for _, key := range keys {
+		best := found[key]
+		for i := len(c.imports) - 1; i >= 0; i-- {
+			entry, ok := c.imports[i].Entries[key]
+			if !ok {
+				continue
+			}
+			if best == nil || entry.outranks(best) {
+				best = entry
+			}
+		}
+		if best != nil {
+			found[key] = best
+		}
 	}
-
-	return nil
+	return found
 }
 
-// save saves the cache to disk
-func (c *Cache) save() error {
-	data, err := json.MarshalIndent(c.entries, "", "  ")
-	if err != nil {
-		return err
+// makeKey creates a cache key from command, error and scope.
+// Global keys match the format used by the bash version.
+func (c *Cache) makeKey(command, errorMsg string, scope Scope) string {
+	// Use first line of error for key
+	errorFirstLine := strings.Split(errorMsg, "\n")[0]
+	data := command + "::" + errorFirstLine
+	if !scope.IsGlobal() {
+		data += "::repo=" + scope.Repo + ";kube=" + scope.KubeContext + ";ws=" + scope.Workspace
 	}
+	hash := md5.Sum([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
 
-	return os.WriteFile(c.file, data, 0644)
+// entryKey returns the key an entry is stored under
+func (c *Cache) entryKey(entry *Entry) string {
+	var scope Scope
+	if entry.Scope != nil {
+		scope = *entry.Scope
+	}
+	return c.makeKey(entry.Command, entry.Error, scope)
 }
 
 // Stats returns cache statistics
 func (c *Cache) Stats() map[string]interface{} {
+	entries, _ := c.store.List(Query{})
 	totalHits := 0
-	for _, entry := range c.entries {
-		totalHits += entry.Hits
+	for _, stored := range entries {
+		totalHits += stored.Entry.Hits
+	}
+
+	teamEntries := 0
+	for _, pack := range c.imports {
+		teamEntries += len(pack.Entries)
+	}
+
+	seedEntries := 0
+	seedVersions := make([]string, 0, len(c.seeds))
+	for _, pack := range c.seeds {
+		seedEntries += len(pack.Seeds)
+		seedVersions = append(seedVersions, fmt.Sprintf("%s v%d", pack.Tool, pack.Version))
 	}
 
 	return map[string]interface{}{
-		"total_entries": len(c.entries),
+		"total_entries": len(entries),
 		"total_hits":    totalHits,
-		"cache_file":    c.file,
+		"team_entries":  teamEntries,
+		"team_imports":  len(c.imports),
+		"seed_entries":  seedEntries,
+		"seed_versions": strings.Join(seedVersions, ", "),
+		"cache_file":    c.store.Location(),
+		"backend":       c.backend,
 	}
 }
 
-// Clear removes all entries from the cache
+// Clear removes all personal entries from the cache.
+// Imported team packs are kept; use RemoveImport to roll them back.
 func (c *Cache) Clear() error {
-	c.entries = make(map[string]*Entry)
-	return c.save()
+	return c.store.Clear()
+}
+
+// Backend returns the storage backend in use
+func (c *Cache) Backend() string {
+	return c.backend
+}
+
+// Close releases the personal entry store
+func (c *Cache) Close() error {
+	return c.store.Close()
 }
 
@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amaslovskyi/ai-helper/pkg/security"
+)
+
+// PackVersion is the pack format version written by Export
+const PackVersion = 1
+
+// Provenance records who produced a pack and with which tool version
+type Provenance struct {
+	Author      string `json:"author"`
+	Date        string `json:"date"`
+	ToolVersion string `json:"tool_version"`
+}
+
+// Pack is a portable, redacted bundle of cache entries that can be
+// shared with a team and imported into another cache
+type Pack struct {
+	Version    int               `json:"version"`
+	Provenance Provenance        `json:"provenance"`
+	Entries    map[string]*Entry `json:"entries"`
+
+	// Set when a pack is stored locally after import
+	ID         string `json:"id,omitempty"`
+	ImportedAt string `json:"imported_at,omitempty"`
+}
+
+// ImportResult summarizes how an imported pack was merged
+type ImportResult struct {
+	ID       string
+	Added    int // New patterns
+	Replaced int // Patterns where the imported entry outranked the existing one
+	Skipped  int // Patterns where the existing entry was kept
+	Secrets  int // Patterns left out because their command contains a secret
+}
+
+// Export builds a redacted pack of all personal entries
+func (c *Cache) Export(author, toolVersion string) (*Pack, error) {
+	entries, err := c.store.List(Query{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	pack := &Pack{
+		Version: PackVersion,
+		Provenance: Provenance{
+			Author:      author,
+			Date:        time.Now().UTC().Format(time.RFC3339),
+			ToolVersion: toolVersion,
+		},
+		Entries: make(map[string]*Entry, len(entries)),
+	}
+
+	for _, stored := range entries {
+		entry := stored.Entry
+		redacted := &Entry{
+			Command:   security.Redact(entry.Command),
+			Error:     security.Redact(entry.Error),
+			Fix:       security.Redact(entry.Fix),
+			Timestamp: entry.Timestamp,
+			Hits:      entry.Hits,
+			LastUsed:  entry.LastUsed,
+			Rating:    entry.Rating,
+		}
+		if entry.Scope != nil {
+			redacted.Scope = &Scope{
+				Repo:        security.Redact(entry.Scope.Repo),
+				KubeContext: entry.Scope.KubeContext,
+				Workspace:   entry.Scope.Workspace,
+			}
+		}
+		// Re-key so the key matches the redacted command, error and scope
+		pack.Entries[c.entryKey(redacted)] = redacted
+	}
+
+	return pack, nil
+}
+
+// marshalPack encodes a pack as indented JSON without HTML escaping,
+// so redaction placeholders like <redacted> stay readable
+func marshalPack(pack *Pack) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pack); err != nil {
+		return nil, fmt.Errorf("failed to marshal pack: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WritePack writes a pack to a file, or to stdout if file is "-"
+func WritePack(pack *Pack, file string) error {
+	data, err := marshalPack(pack)
+	if err != nil {
+		return err
+	}
+
+	if file == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(file, data, 0644)
+}
+
+// ReadPack reads and validates a pack file
+func ReadPack(file string) (*Pack, []byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read pack: %w", err)
+	}
+
+	var pack Pack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, nil, fmt.Errorf("invalid pack file: %w", err)
+	}
+
+	if pack.Version < 1 || pack.Version > PackVersion {
+		return nil, nil, fmt.Errorf("unsupported pack version %d (supported: 1-%d)", pack.Version, PackVersion)
+	}
+
+	if pack.Entries == nil {
+		pack.Entries = make(map[string]*Entry)
+	}
+
+	return &pack, data, nil
+}
+
+// Import merges a pack into the team layer of the cache.
+// Imported entries are stored apart from personal ones so the import can be
+// rolled back with RemoveImport. On conflict the higher-ranked entry is kept
+// and the winning entry inherits the larger hit count. Entries whose
+// command contains a secret are left out.
+func (c *Cache) Import(file string) (*ImportResult, error) {
+	pack, data, err := ReadPack(file)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])[:12]
+	for _, existing := range c.imports {
+		if existing.ID == id {
+			return nil, fmt.Errorf("pack already imported as %s", id)
+		}
+	}
+
+	result := &ImportResult{ID: id}
+	merged := make(map[string]*Entry, len(pack.Entries))
+
+	keys := make([]string, 0, len(pack.Entries))
+	for key := range pack.Entries {
+		keys = append(keys, key)
+	}
+	current := c.lookupAll(keys)
+
+	for key, incoming := range pack.Entries {
+		if incoming == nil {
+			continue
+		}
+		if containsSecret(incoming) {
+			result.Secrets++
+			continue
+		}
+
+		existing := current[key]
+		switch {
+		case existing == nil:
+			result.Added++
+		case incoming.outranks(existing):
+			if existing.Hits > incoming.Hits {
+				incoming.Hits = existing.Hits
+			}
+			result.Replaced++
+		default:
+			result.Skipped++
+			continue
+		}
+
+		incoming.Import = id
+		merged[key] = incoming
+	}
+
+	pack.ID = id
+	pack.ImportedAt = time.Now().UTC().Format(time.RFC3339)
+	pack.Entries = merged
+
+	if err := os.MkdirAll(c.teamDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create team directory: %w", err)
+	}
+
+	out, err := marshalPack(pack)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(c.teamDir, id+".json"), out, 0644); err != nil {
+		return nil, fmt.Errorf("failed to store pack: %w", err)
+	}
+
+	c.imports = append(c.imports, pack)
+	return result, nil
+}
+
+// Imports returns the imported team packs, oldest first
+func (c *Cache) Imports() []*Pack {
+	return c.imports
+}
+
+// RemoveImport rolls back a team pack import by ID (or unique ID prefix)
+func (c *Cache) RemoveImport(id string) (*Pack, error) {
+	var match *Pack
+	index := -1
+	for i, pack := range c.imports {
+		if strings.HasPrefix(pack.ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("import ID %q is ambiguous", id)
+			}
+			match = pack
+			index = i
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("no import with ID %q", id)
+	}
+
+	if err := os.Remove(filepath.Join(c.teamDir, match.ID+".json")); err != nil {
+		return nil, fmt.Errorf("failed to remove pack: %w", err)
+	}
+
+	c.imports = append(c.imports[:index], c.imports[index+1:]...)
+	return match, nil
+}
+
+// loadImports loads all team packs from the team directory
+func (c *Cache) loadImports() error {
+	files, err := filepath.Glob(filepath.Join(c.teamDir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		pack, _, err := ReadPack(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+
+		if pack.ID == "" {
+			pack.ID = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		for _, entry := range pack.Entries {
+			entry.Import = pack.ID
+		}
+
+		c.imports = append(c.imports, pack)
+	}
+
+	// Oldest first, so newer imports are consulted first on lookup
+	sort.SliceStable(c.imports, func(i, j int) bool {
+		return c.imports[i].ImportedAt < c.imports[j].ImportedAt
+	})
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"sort"
+
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
+)
+
+// Scope dimensions that entries can be narrowed to
+const (
+	ScopeRepo        = "repo"
+	ScopeKubeContext = "kube_context"
+	ScopeWorkspace   = "tf_workspace"
+)
+
+// ScopeDimensions lists the valid scope dimensions, in lookup priority order
+var ScopeDimensions = []string{ScopeKubeContext, ScopeWorkspace, ScopeRepo}
+
+// Scope narrows a cache entry to a git repository, kube context and/or
+// terraform workspace. The zero Scope is global.
+type Scope struct {
+	Repo        string `json:"repo,omitempty"`
+	KubeContext string `json:"kube_context,omitempty"`
+	Workspace   string `json:"tf_workspace,omitempty"`
+}
+
+// ScopeFromEnvironment builds the full scope of the given environment
+func ScopeFromEnvironment(env *envctx.Environment) Scope {
+	if env == nil {
+		return Scope{}
+	}
+	return Scope{
+		Repo:        env.RepoRoot,
+		KubeContext: env.KubeContext,
+		Workspace:   env.TerraformWorkspace,
+	}
+}
+
+// IsGlobal reports whether the scope has no dimensions set
+func (s Scope) IsGlobal() bool {
+	return s == Scope{}
+}
+
+// Only keeps the given dimensions and clears the rest
+func (s Scope) Only(dimensions []string) Scope {
+	var out Scope
+	for _, dim := range dimensions {
+		switch dim {
+		case ScopeRepo:
+			out.Repo = s.Repo
+		case ScopeKubeContext:
+			out.KubeContext = s.KubeContext
+		case ScopeWorkspace:
+			out.Workspace = s.Workspace
+		}
+	}
+	return out
+}
+
+// get returns the value of a dimension
+func (s Scope) get(dim string) string {
+	switch dim {
+	case ScopeRepo:
+		return s.Repo
+	case ScopeKubeContext:
+		return s.KubeContext
+	case ScopeWorkspace:
+		return s.Workspace
+	}
+	return ""
+}
+
+// candidates returns every narrowing of s, most specific first and
+// ending with the global scope
+func (s Scope) candidates() []Scope {
+	var dims []string
+	for _, dim := range ScopeDimensions {
+		if s.get(dim) != "" {
+			dims = append(dims, dim)
+		}
+	}
+
+	// Every subset of the set dimensions, as bitmasks over dims with the
+	// highest-priority dimension in the top bit, so ties in size are
+	// broken by dimension priority
+	masks := make([]int, 0, 1<<len(dims))
+	for mask := (1 << len(dims)) - 1; mask >= 0; mask-- {
+		masks = append(masks, mask)
+	}
+	sort.SliceStable(masks, func(i, j int) bool {
+		return bitCount(masks[i]) > bitCount(masks[j])
+	})
+
+	scopes := make([]Scope, 0, len(masks))
+	for _, mask := range masks {
+		var keep []string
+		for i, dim := range dims {
+			if mask&(1<<(len(dims)-1-i)) != 0 {
+				keep = append(keep, dim)
+			}
+		}
+		scopes = append(scopes, s.Only(keep))
+	}
+	return scopes
+}
+
+// bitCount returns the number of set bits in n
+func bitCount(n int) int {
+	count := 0
+	for ; n > 0; n &= n - 1 {
+		count++
+	}
+	return count
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
+	"github.com/amaslovskyi/ai-helper/pkg/llm"
+)
+
+func TestScopeCandidates(t *testing.T) {
+	repo, kube, ws := "/src/app", "prod", "default"
+
+	tests := []struct {
+		scope Scope
+		want  []Scope
+	}{
+		{Scope{}, []Scope{{}}},
+		{Scope{Repo: repo}, []Scope{{Repo: repo}, {}}},
+		{Scope{Repo: repo, Workspace: ws}, []Scope{
+			{Repo: repo, Workspace: ws},
+			{Workspace: ws},
+			{Repo: repo},
+			{},
+		}},
+		{Scope{Repo: repo, KubeContext: kube, Workspace: ws}, []Scope{
+			{Repo: repo, KubeContext: kube, Workspace: ws},
+			{KubeContext: kube, Workspace: ws},
+			{Repo: repo, KubeContext: kube},
+			{Repo: repo, Workspace: ws},
+			{KubeContext: kube},
+			{Workspace: ws},
+			{Repo: repo},
+			{},
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.scope.candidates(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.candidates() = %+v, want %+v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestScopeOnly(t *testing.T) {
+	full := ScopeFromEnvironment(&envctx.Environment{RepoRoot: "/src/app", KubeContext: "prod", TerraformWorkspace: "dev"})
+
+	tests := []struct {
+		dims []string
+		want Scope
+	}{
+		{nil, Scope{}},
+		{[]string{ScopeRepo}, Scope{Repo: "/src/app"}},
+		{[]string{ScopeKubeContext, ScopeWorkspace}, Scope{KubeContext: "prod", Workspace: "dev"}},
+		{ScopeDimensions, full},
+	}
+
+	for _, tt := range tests {
+		if got := full.Only(tt.dims); got != tt.want {
+			t.Errorf("Only(%q) = %+v, want %+v", tt.dims, got, tt.want)
+		}
+	}
+	if !ScopeFromEnvironment(nil).IsGlobal() {
+		t.Error("a nil environment should give the global scope")
+	}
+}
+
+func TestGetByScope(t *testing.T) {
+	const command, failure = "kubectl get pods", "connection refused"
+	stored := map[Scope]string{
+		{}:                                      "kubectl get pods --global",
+		{KubeContext: "prod"}:                   "kubectl get pods --prod",
+		{Repo: "/src/app"}:                      "kubectl get pods --app",
+		{Repo: "/src/app", KubeContext: "prod"}: "kubectl get pods --app-prod",
+		{Workspace: "staging"}:                  "kubectl get pods --staging",
+	}
+
+	c, err := NewCache(t.TempDir(), BackendJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for scope, suggestion := range stored {
+		if err := c.Set(command, failure, scope, &llm.Response{Suggestion: suggestion, RootCause: "r"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		scope Scope
+		want  string
+	}{
+		{"global", Scope{}, "kubectl get pods --global"},
+		{"exact repo and context", Scope{Repo: "/src/app", KubeContext: "prod"}, "kubectl get pods --app-prod"},
+		{"context wins over repo", Scope{Repo: "/src/other", KubeContext: "prod"}, "kubectl get pods --prod"},
+		{"more dimensions win", Scope{Repo: "/src/app", KubeContext: "prod", Workspace: "staging"}, "kubectl get pods --app-prod"},
+		{"workspace", Scope{Repo: "/src/other", Workspace: "staging"}, "kubectl get pods --staging"},
+		{"repo only", Scope{Repo: "/src/app", KubeContext: "dev"}, "kubectl get pods --app"},
+		{"falls back to global", Scope{Repo: "/src/other", KubeContext: "dev", Workspace: "default"}, "kubectl get pods --global"},
+	}
+
+	for _, tt := range tests {
+		response, ok := c.Get(command, failure, tt.scope)
+		if !ok || response.Suggestion != tt.want {
+			t.Errorf("%s: Get = %+v, %v; want %q", tt.name, response, ok, tt.want)
+		}
+	}
+
+	// Scoped entries do not leak into other scopes without a global entry
+	if err := c.Set("helm list", failure, Scope{KubeContext: "prod"}, &llm.Response{Suggestion: "helm list -A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("helm list", failure, Scope{KubeContext: "dev"}); ok {
+		t.Error("an entry scoped to prod matched in dev")
+	}
+}
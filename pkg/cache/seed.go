@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/llm"
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+//go:embed seeds/*.json
+var seedFiles embed.FS
+
+// Seed is a curated, pattern-based answer shipped with the binary.
+// Unlike learned entries, seeds match by regular expression so one seed
+// covers every variant of a common error.
+type Seed struct {
+	ID         string   `json:"id"`
+	Command    string   `json:"command,omitempty"` // Optional regex the failed command must match
+	OS         []string `json:"os,omitempty"`      // Operating systems (GOOS) the seed applies to, all if empty
+	Error      string   `json:"error"`             // Regex the error output must match
+	Suggestion string   `json:"suggestion"`        // May contain {{command}}, or {{subcommand}} and {{args}} (see Response)
+	RootCause  string   `json:"root_cause"`
+	Tip        string   `json:"tip,omitempty"`
+
+	commandRe *regexp.Regexp
+	errorRe   *regexp.Regexp
+}
+
+// SeedPack is a versioned set of seeds for one tool. Seed packs are
+// embedded in the binary and never written to the cache file, so
+// upgrading ai-helper refreshes them.
+type SeedPack struct {
+	Tool    string  `json:"tool"`
+	Version int     `json:"version"`
+	Seeds   []*Seed `json:"seeds"`
+}
+
+// LoadSeedPacks parses and compiles the embedded seed packs
+func LoadSeedPacks() ([]*SeedPack, error) {
+	files, err := seedFiles.ReadDir("seeds")
+	if err != nil {
+		return nil, err
+	}
+
+	packs := make([]*SeedPack, 0, len(files))
+	for _, file := range files {
+		data, err := seedFiles.ReadFile(path.Join("seeds", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var pack SeedPack
+		if err := json.Unmarshal(data, &pack); err != nil {
+			return nil, fmt.Errorf("seed pack %s: %w", file.Name(), err)
+		}
+
+		for _, seed := range pack.Seeds {
+			if seed.commandRe, err = compileSeedPattern(seed.Command); err != nil {
+				return nil, fmt.Errorf("seed %s: invalid command pattern: %w", seed.ID, err)
+			}
+			if seed.errorRe, err = compileSeedPattern(seed.Error); err != nil {
+				return nil, fmt.Errorf("seed %s: invalid error pattern: %w", seed.ID, err)
+			}
+			if seed.errorRe == nil {
+				return nil, fmt.Errorf("seed %s: missing error pattern", seed.ID)
+			}
+		}
+
+		packs = append(packs, &pack)
+	}
+
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Tool < packs[j].Tool })
+	return packs, nil
+}
+
+// compileSeedPattern compiles an optional regex
+func compileSeedPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// goos is the operating system seeds are matched for
+var goos = runtime.GOOS
+
+// Matches reports whether the seed applies to a failed command
+func (s *Seed) Matches(command, errorMsg string) bool {
+	if !s.forOS(goos) {
+		return false
+	}
+	if s.commandRe != nil && !s.commandRe.MatchString(strings.TrimSpace(command)) {
+		return false
+	}
+	return s.errorRe.MatchString(errorMsg)
+}
+
+// forOS reports whether the seed applies on an operating system, e.g. an
+// install command that needs Homebrew only applies on macOS
+func (s *Seed) forOS(os string) bool {
+	if len(s.OS) == 0 {
+		return true
+	}
+	for _, name := range s.OS {
+		if name == os {
+			return true
+		}
+	}
+	return false
+}
+
+// Response renders the seed as an AI response for the failed command.
+// {{command}} in the suggestion is the whole command; {{subcommand}} is
+// the command up to its subcommand and {{args}} the rest, so a flag can be
+// put where the tool expects it ("{{subcommand}} -lock-timeout=5m {{args}}"
+// turns terraform apply tfplan into terraform apply -lock-timeout=5m tfplan).
+func (s *Seed) Response(command string) *llm.Response {
+	command = strings.TrimSpace(command)
+	subcommand, args := splitSubcommand(command)
+	suggestion := strings.NewReplacer("{{command}}", command, "{{subcommand}}", subcommand, "{{args}}", args).Replace(s.Suggestion)
+
+	return &llm.Response{
+		Suggestion: strings.TrimSpace(suggestion),
+		RootCause:  s.RootCause,
+		Tip:        s.Tip,
+		Cached:     true,
+	}
+}
+
+// splitSubcommand splits a command after its first word that is not a
+// flag (terraform -chdir=infra apply | -auto-approve). An alias that
+// already includes the subcommand (tfa for terraform apply) ends the first
+// part itself.
+func splitSubcommand(command string) (string, string) {
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return "", ""
+	}
+
+	end := 1
+	if len(strings.Fields(validators.NewAliasMapper().ResolveAlias(words[0]))) == 1 {
+		for end < len(words) && strings.HasPrefix(words[end], "-") {
+			end++
+		}
+		if end < len(words) {
+			end++
+		}
+	}
+
+	// Cut the original text after the end-th word, keeping its spacing
+	offset := 0
+	for _, word := range words[:end] {
+		offset += strings.Index(command[offset:], word) + len(word)
+	}
+	return command[:offset], strings.TrimSpace(command[offset:])
+}
+
+// MatchSeed returns the first embedded seed matching a failed command.
+// The pack for the command's own tool is tried first, and command
+// patterns see aliases expanded (gst is matched as git status).
+// Seeds are consulted after learned entries and before the LLM.
+func (c *Cache) MatchSeed(command, errorMsg string) (*llm.Response, *Seed, bool) {
+	if errorMsg == "" {
+		return nil, nil, false
+	}
+
+	mapper := validators.NewAliasMapper()
+	expanded := mapper.ResolveAlias(command)
+	tool := mapper.GetToolName(command)
+	packs := make([]*SeedPack, 0, len(c.seeds))
+	for _, pack := range c.seeds {
+		if pack.Tool == tool {
+			packs = append([]*SeedPack{pack}, packs...)
+		} else {
+			packs = append(packs, pack)
+		}
+	}
+
+	for _, pack := range packs {
+		for _, seed := range pack.Seeds {
+			if seed.Matches(expanded, errorMsg) {
+				return seed.Response(command), seed, true
+			}
+		}
+	}
+
+	return nil, nil, false
+}
+
+// SeedPacks returns the embedded seed packs
+func (c *Cache) SeedPacks() []*SeedPack {
+	return c.seeds
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestMatchSeed(t *testing.T) {
+	packs, err := LoadSeedPacks()
+	if err != nil {
+		t.Fatalf("LoadSeedPacks: %v", err)
+	}
+	c := &Cache{seeds: packs}
+
+	tests := []struct {
+		goos    string
+		command string
+		error   string
+		want    string // Seed id, "" for no match
+	}{
+		{"linux", "git status", "fatal: not a git repository (or any of the parent directories): .git", "git-not-a-repo"},
+		{"linux", "gst", "fatal: not a git repository", "git-not-a-repo"},
+		{"linux", "ls", "fatal: not a git repository", ""},
+		{"linux", "make deploy", "Your local changes to the following files would be overwritten by merge", ""},
+		{"darwin", "kubectl get pods", "zsh: command not found: kubectl", "kubectl-not-installed"},
+		{"linux", "kubectl get pods", "zsh: command not found: kubectl", ""},
+		{"linux", "docker ps", "bash: docker: command not found", ""},
+		{"linux", "ansible-playbook site.yml", "bash: ansible-playbook: command not found", "ansible-not-installed"},
+		{"darwin", "docker ps", "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", "docker-daemon-not-running"},
+		{"linux", "docker ps", "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", "docker-daemon-not-running-linux"},
+		{"windows", "docker ps", "Cannot connect to the Docker daemon", ""},
+		{"linux", "tf apply", "Error: Error acquiring the state lock", "terraform-state-lock"},
+		{"linux", "tg plan", "Error: Error acquiring the state lock", "terragrunt-state-lock"},
+	}
+
+	defer func(saved string) { goos = saved }(goos)
+	for _, tt := range tests {
+		goos = tt.goos
+		got := ""
+		if _, seed, ok := c.MatchSeed(tt.command, tt.error); ok {
+			got = seed.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: %q failing with %q matched %q, want %q", tt.goos, tt.command, tt.error, got, tt.want)
+		}
+	}
+}
+
+func TestSeedResponse(t *testing.T) {
+	lock := &Seed{Suggestion: "{{subcommand}} -lock-timeout=5m {{args}}"}
+	whole := &Seed{Suggestion: "{{command}} --dry-run"}
+
+	tests := []struct {
+		seed    *Seed
+		command string
+		want    string
+	}{
+		{lock, "terraform apply", "terraform apply -lock-timeout=5m"},
+		{lock, "terraform apply tfplan", "terraform apply -lock-timeout=5m tfplan"},
+		{lock, "tf plan -out=tfplan -var 'a=b c'", "tf plan -lock-timeout=5m -out=tfplan -var 'a=b c'"},
+		{lock, "terraform -chdir=infra destroy -auto-approve", "terraform -chdir=infra destroy -lock-timeout=5m -auto-approve"},
+		{lock, "  terragrunt  apply  ", "terragrunt  apply -lock-timeout=5m"},
+		{lock, "gcb feature", "gcb -lock-timeout=5m feature"}, // The alias holds the subcommand
+		{lock, "terraform", "terraform -lock-timeout=5m"},
+		{whole, " kubectl apply -f x.yaml ", "kubectl apply -f x.yaml --dry-run"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.seed.Response(tt.command).Suggestion; got != tt.want {
+			t.Errorf("Response(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSeedPacksValid(t *testing.T) {
+	packs, err := LoadSeedPacks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]bool)
+	for _, pack := range packs {
+		for _, seed := range pack.Seeds {
+			if ids[seed.ID] {
+				t.Errorf("duplicate seed id %s", seed.ID)
+			}
+			ids[seed.ID] = true
+			if seed.Suggestion == "" || seed.RootCause == "" {
+				t.Errorf("seed %s has no suggestion or root cause", seed.ID)
+			}
+			for _, os := range seed.OS {
+				if os != "darwin" && os != "linux" && os != "windows" {
+					t.Errorf("seed %s: unknown os %q", seed.ID, os)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// Storage backends
+const (
+	// BackendJSON stores entries in a single indented JSON file (default)
+	BackendJSON = "json"
+
+	// BackendBolt stores entries in an embedded bbolt key-value database
+	// with indexes on tool and last-used time
+	BackendBolt = "bolt"
+)
+
+// Backends lists the supported storage backends
+var Backends = []string{BackendJSON, BackendBolt}
+
+// StoredEntry is an entry together with the key it is stored under
+type StoredEntry struct {
+	Key   string
+	Entry *Entry
+}
+
+// Query selects entries from a Store
+type Query struct {
+	Tool        string // Only entries for this tool (resolved name, e.g. "kubectl")
+	RecentFirst bool   // Order by last-used time, newest first
+	Limit       int    // Maximum number of entries (0 = no limit)
+}
+
+// Store persists personal cache entries
+type Store interface {
+	// Get returns the entry for key, or nil if there is none
+	Get(key string) (*Entry, error)
+
+	// GetMany returns the entries stored under keys, read together; keys
+	// without an entry are left out
+	GetMany(keys []string) (map[string]*Entry, error)
+
+	// Put creates or replaces the entry for key
+	Put(key string, entry *Entry) error
+
+	// Delete removes the entry for key
+	Delete(key string) error
+
+	// List returns entries matching the query
+	List(q Query) ([]StoredEntry, error)
+
+	// Count returns the number of stored entries
+	Count() (int, error)
+
+	// Clear removes all entries
+	Clear() error
+
+	// Location returns the file backing the store
+	Location() string
+
+	// Close releases the store
+	Close() error
+}
+
+// StoreFile returns the file used by a backend inside the ai directory
+func StoreFile(dir, backend string) string {
+	if backend == BackendBolt {
+		return filepath.Join(dir, "cache.db")
+	}
+	return filepath.Join(dir, "cache.json")
+}
+
+// OpenStore opens the store for a backend inside the ai directory.
+// An empty backend selects the JSON store.
+func OpenStore(dir, backend string) (Store, error) {
+	switch backend {
+	case "", BackendJSON:
+		return OpenJSONStore(StoreFile(dir, BackendJSON))
+	case BackendBolt:
+		return OpenBoltStore(StoreFile(dir, BackendBolt))
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q (supported: json, bolt)", backend)
+	}
+}
+
+// Migrate copies every personal entry from one backend to another and
+// returns the number of entries copied. The source store is left in place.
+func Migrate(dir, from, to string) (int, error) {
+	if from == "" {
+		from = BackendJSON
+	}
+	if from == to {
+		return 0, fmt.Errorf("cache already uses the %s backend", to)
+	}
+
+	src, err := OpenStore(dir, from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s store: %w", from, err)
+	}
+	defer src.Close()
+
+	dst, err := OpenStore(dir, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s store: %w", to, err)
+	}
+	defer dst.Close()
+
+	entries, err := src.List(Query{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s store: %w", from, err)
+	}
+
+	if err := dst.Clear(); err != nil {
+		return 0, fmt.Errorf("failed to clear %s store: %w", to, err)
+	}
+
+	for _, stored := range entries {
+		if err := dst.Put(stored.Key, stored.Entry); err != nil {
+			return 0, fmt.Errorf("failed to write %s store: %w", to, err)
+		}
+	}
+
+	return len(entries), nil
+}
+
+// toolOf returns the tool an entry belongs to, resolving aliases
+func toolOf(entry *Entry) string {
+	return validators.NewAliasMapper().GetToolName(entry.Command)
+}
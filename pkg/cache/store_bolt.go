@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names used by the bolt store
+var (
+	bucketEntries    = []byte("entries")
+	bucketByTool     = []byte("by_tool")      // tool \x00 key -> nil
+	bucketByLastUsed = []byte("by_last_used") // big-endian last_used + key -> nil
+)
+
+// BoltStore keeps entries in an embedded bbolt database. Only the entries
+// a query touches are decoded, so lookups stay fast as the cache grows.
+//
+// bbolt locks the whole file while it is open, so the database is opened
+// for each operation (shared for reads, exclusive for writes) rather than
+// for the life of the process; otherwise one ai-helper waiting on the LLM
+// or the menu would lock out every other.
+type BoltStore struct {
+	file string
+}
+
+// boltTimeout bounds the wait for another process's lock
+const boltTimeout = time.Second
+
+// OpenBoltStore opens (or creates) the bolt database at file
+func OpenBoltStore(file string) (*BoltStore, error) {
+	s := &BoltStore{file: file}
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketEntries, bucketByTool, bucketByLastUsed} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// view runs fn in a read transaction on a read-only open of the database
+func (s *BoltStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.file, 0644, &bolt.Options{Timeout: boltTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.file, err)
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// update runs fn in a write transaction
+func (s *BoltStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.file, 0644, &bolt.Options{Timeout: boltTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.file, err)
+	}
+	if err := db.Update(fn); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// Get returns the entry for key
+func (s *BoltStore) Get(key string) (*Entry, error) {
+	var entry *Entry
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		entry, err = decodeEntry(tx.Bucket(bucketEntries).Get([]byte(key)))
+		return err
+	})
+	return entry, err
+}
+
+// GetMany returns the entries for keys in one read transaction
+func (s *BoltStore) GetMany(keys []string) (map[string]*Entry, error) {
+	found := make(map[string]*Entry, len(keys))
+	err := s.view(func(tx *bolt.Tx) error {
+		entries := tx.Bucket(bucketEntries)
+		for _, key := range keys {
+			entry, err := decodeEntry(entries.Get([]byte(key)))
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				found[key] = entry
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Put creates or replaces the entry for key and updates the indexes
+func (s *BoltStore) Put(key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		if err := removeIndexes(tx, key); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketEntries).Put([]byte(key), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketByTool).Put(toolIndexKey(toolOf(entry), key), nil); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketByLastUsed).Put(lastUsedIndexKey(entry.LastUsed, key), nil)
+	})
+}
+
+// Delete removes the entry for key
+func (s *BoltStore) Delete(key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if err := removeIndexes(tx, key); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketEntries).Delete([]byte(key))
+	})
+}
+
+// List returns entries matching the query, using the tool index to
+// narrow the scan and the last-used index for recency order
+func (s *BoltStore) List(q Query) ([]StoredEntry, error) {
+	var out []StoredEntry
+	err := s.view(func(tx *bolt.Tx) error {
+		entries := tx.Bucket(bucketEntries)
+
+		// Keys allowed by the tool filter (nil = all)
+		var allowed map[string]bool
+		if q.Tool != "" {
+			allowed = make(map[string]bool)
+			prefix := toolIndexKey(q.Tool, "")
+			c := tx.Bucket(bucketByTool).Cursor()
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				allowed[string(k[len(prefix):])] = true
+			}
+		}
+
+		add := func(key []byte) (bool, error) {
+			if allowed != nil && !allowed[string(key)] {
+				return true, nil
+			}
+			entry, err := decodeEntry(entries.Get(key))
+			if err != nil || entry == nil {
+				return err == nil, err
+			}
+			out = append(out, StoredEntry{Key: string(key), Entry: entry})
+			return q.Limit <= 0 || len(out) < q.Limit, nil
+		}
+
+		if q.RecentFirst {
+			c := tx.Bucket(bucketByLastUsed).Cursor()
+			for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+				if len(k) < 8 {
+					continue
+				}
+				more, err := add(k[8:])
+				if err != nil {
+					return err
+				}
+				if !more {
+					break
+				}
+			}
+			return nil
+		}
+
+		c := entries.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			more, err := add(k)
+			if err != nil {
+				return err
+			}
+			if !more {
+				break
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Count returns the number of stored entries
+func (s *BoltStore) Count() (int, error) {
+	count := 0
+	err := s.view(func(tx *bolt.Tx) error {
+		count = tx.Bucket(bucketEntries).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// Clear removes all entries and indexes
+func (s *BoltStore) Clear() error {
+	return s.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketEntries, bucketByTool, bucketByLastUsed} {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Location returns the database file
+func (s *BoltStore) Location() string {
+	return s.file
+}
+
+// Close does nothing; the database is only open during an operation
+func (s *BoltStore) Close() error {
+	return nil
+}
+
+// removeIndexes drops the index records of the entry currently stored under key
+func removeIndexes(tx *bolt.Tx, key string) error {
+	current, err := decodeEntry(tx.Bucket(bucketEntries).Get([]byte(key)))
+	if err != nil || current == nil {
+		// A corrupt record has no trustworthy index keys; overwrite it
+		return nil
+	}
+	if err := tx.Bucket(bucketByTool).Delete(toolIndexKey(toolOf(current), key)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketByLastUsed).Delete(lastUsedIndexKey(current.LastUsed, key))
+}
+
+// decodeEntry decodes a stored entry (nil data yields a nil entry)
+func decodeEntry(data []byte) (*Entry, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("corrupt cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// toolIndexKey builds a by_tool index key
+func toolIndexKey(tool, key string) []byte {
+	return []byte(tool + "\x00" + key)
+}
+
+// lastUsedIndexKey builds a by_last_used index key that sorts by time
+func lastUsedIndexKey(lastUsed int64, key string) []byte {
+	buf := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(buf, uint64(lastUsed))
+	return append(buf, key...)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+)
+
+// JSONStore keeps all entries in memory and rewrites a single JSON file
+// on every change. The file format matches the bash version.
+type JSONStore struct {
+	file    string
+	entries map[string]*Entry
+}
+
+// OpenJSONStore loads the JSON store from file. A missing or corrupt file
+// starts an empty store.
+func OpenJSONStore(file string) (*JSONStore, error) {
+	s := &JSONStore{
+		file:    file,
+		entries: make(map[string]*Entry),
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	// Might be old bash format or corrupted; start with an empty cache
+	// instead of failing
+	if err := json.Unmarshal(data, &s.entries); err != nil || s.entries == nil {
+		s.entries = make(map[string]*Entry)
+	}
+
+	return s, nil
+}
+
+// Get returns the entry for key
+func (s *JSONStore) Get(key string) (*Entry, error) {
+	return s.entries[key], nil
+}
+
+// GetMany returns the entries for keys
+func (s *JSONStore) GetMany(keys []string) (map[string]*Entry, error) {
+	found := make(map[string]*Entry, len(keys))
+	for _, key := range keys {
+		if entry, ok := s.entries[key]; ok {
+			found[key] = entry
+		}
+	}
+	return found, nil
+}
+
+// Put creates or replaces the entry for key
+func (s *JSONStore) Put(key string, entry *Entry) error {
+	s.entries[key] = entry
+	return s.save()
+}
+
+// Delete removes the entry for key
+func (s *JSONStore) Delete(key string) error {
+	delete(s.entries, key)
+	return s.save()
+}
+
+// List returns entries matching the query
+func (s *JSONStore) List(q Query) ([]StoredEntry, error) {
+	out := make([]StoredEntry, 0, len(s.entries))
+	for key, entry := range s.entries {
+		if q.Tool != "" && toolOf(entry) != q.Tool {
+			continue
+		}
+		out = append(out, StoredEntry{Key: key, Entry: entry})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if q.RecentFirst && out[i].Entry.LastUsed != out[j].Entry.LastUsed {
+			return out[i].Entry.LastUsed > out[j].Entry.LastUsed
+		}
+		return out[i].Key < out[j].Key
+	})
+
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out, nil
+}
+
+// Count returns the number of stored entries
+func (s *JSONStore) Count() (int, error) {
+	return len(s.entries), nil
+}
+
+// Clear removes all entries
+func (s *JSONStore) Clear() error {
+	s.entries = make(map[string]*Entry)
+	return s.save()
+}
+
+// Location returns the cache file
+func (s *JSONStore) Location() string {
+	return s.file
+}
+
+// Close is a no-op; every change is written immediately
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+// save writes the cache file
+func (s *JSONStore) save() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.file, data, 0644)
+}
@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testEntries are stored under their key by fillStore
+var testEntries = map[string]*Entry{
+	"k1": {Command: "kubectl get pods", Error: "e1", Fix: "✓ kubectl get pods -A", LastUsed: 300, Hits: 1},
+	"k2": {Command: "k logs web", Error: "e2", Fix: "✓ kubectl logs deploy/web", LastUsed: 100, Hits: 4},
+	"d1": {Command: "docker ps", Error: "e3", Fix: "✓ docker ps -a", LastUsed: 200},
+	"t1": {Command: "tf plan", Error: "e4", Fix: "✓ terraform init", LastUsed: 400},
+}
+
+// openStores returns an empty store of every backend
+func openStores(t *testing.T) map[string]Store {
+	t.Helper()
+	stores := make(map[string]Store)
+	for _, backend := range Backends {
+		store, err := OpenStore(t.TempDir(), backend)
+		if err != nil {
+			t.Fatalf("OpenStore(%s): %v", backend, err)
+		}
+		t.Cleanup(func() { store.Close() })
+		stores[backend] = store
+	}
+	return stores
+}
+
+func fillStore(t *testing.T, store Store) {
+	t.Helper()
+	for key, entry := range testEntries {
+		e := *entry
+		if err := store.Put(key, &e); err != nil {
+			t.Fatalf("Put(%s): %v", key, err)
+		}
+	}
+}
+
+func storedKeys(entries []StoredEntry) []string {
+	keys := make([]string, len(entries))
+	for i, stored := range entries {
+		keys[i] = stored.Key
+	}
+	return keys
+}
+
+func TestStoreList(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"all by key", Query{}, []string{"d1", "k1", "k2", "t1"}},
+		{"recent first", Query{RecentFirst: true}, []string{"t1", "k1", "d1", "k2"}},
+		{"limit", Query{RecentFirst: true, Limit: 2}, []string{"t1", "k1"}},
+		{"tool with aliases", Query{Tool: "kubectl"}, []string{"k1", "k2"}},
+		{"tool recent first", Query{Tool: "kubectl", RecentFirst: true, Limit: 1}, []string{"k1"}},
+		{"terraform alias", Query{Tool: "terraform"}, []string{"t1"}},
+		{"no entries", Query{Tool: "helm"}, []string{}},
+	}
+
+	for backend, store := range openStores(t) {
+		fillStore(t, store)
+		for _, tt := range tests {
+			got, err := store.List(tt.query)
+			if err != nil {
+				t.Fatalf("%s: List: %v", backend, err)
+			}
+			if keys := storedKeys(got); !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("%s: %s: List = %q, want %q", backend, tt.name, keys, tt.want)
+			}
+		}
+	}
+}
+
+func TestStoreGetPutDelete(t *testing.T) {
+	for backend, store := range openStores(t) {
+		fillStore(t, store)
+
+		entry, err := store.Get("k2")
+		if err != nil || entry == nil || entry.Hits != 4 {
+			t.Errorf("%s: Get(k2) = %+v, %v", backend, entry, err)
+		}
+		if entry, err := store.Get("missing"); entry != nil || err != nil {
+			t.Errorf("%s: Get(missing) = %+v, %v; want nil", backend, entry, err)
+		}
+
+		found, err := store.GetMany([]string{"k1", "missing", "t1"})
+		if err != nil {
+			t.Fatalf("%s: GetMany: %v", backend, err)
+		}
+		if len(found) != 2 || found["k1"].Command != "kubectl get pods" || found["t1"].Command != "tf plan" {
+			t.Errorf("%s: GetMany = %v, want k1 and t1", backend, found)
+		}
+
+		// Replacing an entry moves it in the tool and recency indexes
+		if err := store.Put("k1", &Entry{Command: "docker images", LastUsed: 50}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := store.List(Query{Tool: "kubectl"}); !reflect.DeepEqual(storedKeys(got), []string{"k2"}) {
+			t.Errorf("%s: kubectl entries after replace = %q", backend, storedKeys(got))
+		}
+		if got, _ := store.List(Query{RecentFirst: true}); !reflect.DeepEqual(storedKeys(got), []string{"t1", "d1", "k2", "k1"}) {
+			t.Errorf("%s: recency after replace = %q", backend, storedKeys(got))
+		}
+
+		if err := store.Delete("d1"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := store.List(Query{Tool: "docker"}); !reflect.DeepEqual(storedKeys(got), []string{"k1"}) {
+			t.Errorf("%s: docker entries after delete = %q", backend, storedKeys(got))
+		}
+		if n, _ := store.Count(); n != 3 {
+			t.Errorf("%s: Count = %d, want 3", backend, n)
+		}
+
+		if err := store.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := store.Count(); n != 0 {
+			t.Errorf("%s: Count after Clear = %d", backend, n)
+		}
+	}
+}
+
+func TestStoreReopen(t *testing.T) {
+	for _, backend := range Backends {
+		dir := t.TempDir()
+		store, err := OpenStore(dir, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fillStore(t, store)
+		store.Close()
+
+		store, err = OpenStore(dir, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := store.Count(); n != len(testEntries) {
+			t.Errorf("%s: reopened store has %d entries, want %d", backend, n, len(testEntries))
+		}
+		store.Close()
+	}
+
+	if _, err := OpenStore(t.TempDir(), "sqlite"); err == nil {
+		t.Error("OpenStore should reject an unknown backend")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		from, to string
+		err      bool
+	}{
+		{"", BackendBolt, false},
+		{BackendJSON, BackendBolt, false},
+		{BackendBolt, BackendJSON, false},
+		{BackendJSON, BackendJSON, true},
+		{"", BackendJSON, true},
+		{BackendJSON, "sqlite", true},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		src, err := OpenStore(dir, tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fillStore(t, src)
+		src.Close()
+
+		// Entries already in the destination are replaced
+		if tt.to == BackendBolt {
+			dst, _ := OpenStore(dir, tt.to)
+			dst.Put("stale", &Entry{Command: "ls"})
+			dst.Close()
+		}
+
+		n, err := Migrate(dir, tt.from, tt.to)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Migrate(%q, %q) should fail", tt.from, tt.to)
+			}
+			continue
+		}
+		if err != nil || n != len(testEntries) {
+			t.Fatalf("Migrate(%q, %q) = %d, %v; want %d entries", tt.from, tt.to, n, err, len(testEntries))
+		}
+
+		dst, err := OpenStore(dir, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := dst.List(Query{})
+		if !reflect.DeepEqual(storedKeys(got), []string{"d1", "k1", "k2", "t1"}) {
+			t.Errorf("Migrate(%q, %q) stored %q", tt.from, tt.to, storedKeys(got))
+		}
+		if entry, _ := dst.Get("k2"); entry == nil || *entry != *testEntries["k2"] {
+			t.Errorf("Migrate(%q, %q) changed k2: %+v", tt.from, tt.to, entry)
+		}
+		dst.Close()
+
+		if src, _ := OpenStore(dir, tt.from); src != nil {
+			if n, _ := src.Count(); n != len(testEntries) {
+				t.Errorf("Migrate(%q, %q) changed the source store", tt.from, tt.to)
+			}
+			src.Close()
+		}
+	}
+}
+
+// countingStore counts the reads of single entries
+type countingStore struct {
+	Store
+	reads int
+}
+
+func (s *countingStore) Get(key string) (*Entry, error) {
+	s.reads++
+	return s.Store.Get(key)
+}
+
+func (s *countingStore) GetMany(keys []string) (map[string]*Entry, error) {
+	s.reads++
+	return s.Store.GetMany(keys)
+}
+
+func TestCacheReadsOnce(t *testing.T) {
+	for backend, store := range openStores(t) {
+		fillStore(t, store)
+		counting := &countingStore{Store: store}
+		c := &Cache{store: counting}
+
+		if records := c.Records(ListOptions{}); len(records) != len(testEntries) {
+			t.Errorf("%s: Records = %d records, want %d", backend, len(records), len(testEntries))
+		}
+		if counting.reads > 1 {
+			t.Errorf("%s: Records read the store %d times, want once", backend, counting.reads)
+		}
+
+		counting.reads = 0
+		scope := Scope{Repo: "/src/app", KubeContext: "prod", Workspace: "default"}
+		if _, ok := c.Get("kubectl get pods", "nothing cached", scope); ok {
+			t.Errorf("%s: Get found an entry that was never stored", backend)
+		}
+		if counting.reads != 1 {
+			t.Errorf("%s: Get read the store %d times for %d scopes, want once", backend, counting.reads, len(scope.candidates()))
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
 )
 
 // ActivationMode defines how AI assistance is triggered
@@ -35,8 +38,25 @@ const (
 	ProviderOpenCode LLMProvider = "opencode"
 )
 
+// DangerPolicy defines how strictly dangerous AI suggestions are handled
+type DangerPolicy string
+
+const (
+	// PolicyStrict - Block every finding that would otherwise warn or confirm
+	PolicyStrict DangerPolicy = "strict"
+
+	// PolicyStandard - Apply each security rule's own action (default)
+	PolicyStandard DangerPolicy = "standard"
+
+	// PolicyPermissive - Relax block to confirm (except CRITICAL) and confirm to warn
+	PolicyPermissive DangerPolicy = "permissive"
+)
+
 // Config represents the user's configuration preferences
 type Config struct {
+	// Version is the config file format (see CurrentVersion)
+	Version int `json:"version"`
+
 	// ActivationMode controls how AI assistance is triggered
 	ActivationMode ActivationMode `json:"activation_mode"`
 
@@ -52,10 +72,44 @@ type Config struct {
 	// PreferredModel is the default model to use
 	PreferredModel string `json:"preferred_model"`
 
+	// OllamaURL is the Ollama server address (empty means http://localhost:11434)
+	OllamaURL string `json:"ollama_url"`
+
 	// ToolSpecificModes allows per-tool activation overrides
 	// Example: {"kubectl": "interactive", "docker": "auto"}
 	ToolSpecificModes map[string]ActivationMode `json:"tool_specific_modes"`
 
+	// CacheScopes narrows newly cached answers to the current context.
+	// Valid values: "repo", "kube_context", "tf_workspace". Empty means global.
+	// Example: ["kube_context"] keeps kubectl fixes per cluster
+	CacheScopes []string `json:"cache_scopes"`
+
+	// CacheBackend selects where personal cache entries are stored:
+	// "json" (single file, default) or "bolt" (embedded database for large caches).
+	// Switch with `ai-helper cache-migrate` so existing entries are carried over.
+	CacheBackend string `json:"cache_backend"`
+
+	// DangerPolicy controls which dangerous suggestions are blocked
+	// ("strict", "standard" or "permissive")
+	DangerPolicy DangerPolicy `json:"danger_policy"`
+
+	// ProductionPatterns are globs matched against the kube context and
+	// namespace, terraform workspace and AWS profile a command targets.
+	// Dangerous findings against a match are escalated one level.
+	ProductionPatterns []string `json:"production_patterns"`
+
+	// Profiles are named sets of settings, e.g. "offline" or "on-call".
+	// The active profile is applied after the user file and before the
+	// project file. Keys are the same as in this file.
+	Profiles map[string]map[string]interface{} `json:"profiles,omitempty"`
+
+	// ActiveProfile selects a profile ("" = none). Also set by AI_HELPER_PROFILE.
+	ActiveProfile string `json:"active_profile,omitempty"`
+
+	// Sources records which layer set each effective value, keyed by JSON
+	// name (tool modes as "tool_specific_modes.<tool>"). Filled by Resolve.
+	Sources map[string]string `json:"-"`
+
 	// SessionDisabled is used for temporary session-level disabling
 	// This is not saved to disk, only in-memory
 	SessionDisabled bool `json:"-"`
@@ -64,17 +118,24 @@ type Config struct {
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		ActivationMode:    ModeAuto,
-		AutoExecuteSafe:   false,
-		ShowConfidence:    true,
-		Provider:          ProviderOllama,
-		PreferredModel:    "", // Empty means auto-select
-		ToolSpecificModes: make(map[string]ActivationMode),
-		SessionDisabled:   false,
+		Version:            CurrentVersion,
+		ActivationMode:     ModeAuto,
+		AutoExecuteSafe:    false,
+		ShowConfidence:     true,
+		Provider:           ProviderOllama,
+		PreferredModel:     "", // Empty means auto-select
+		ToolSpecificModes:  make(map[string]ActivationMode),
+		CacheScopes:        []string{},
+		CacheBackend:       "json",
+		DangerPolicy:       PolicyStandard,
+		ProductionPatterns: append([]string(nil), envctx.DefaultProductionPatterns...),
+		SessionDisabled:    false,
 	}
 }
 
-// Load loads configuration from disk, returns default if not found
+// Load loads the user configuration file, returns default if not found.
+// Older file versions are migrated and written back (the original is kept
+// as <file>.bak); invalid files return a *ValidationError.
 func Load(configFile string) (*Config, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(configFile)
@@ -82,34 +143,88 @@ func Load(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// If file doesn't exist, return default config
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		return DefaultConfig(), nil
-	}
+	cfg := DefaultConfig()
+	cfg.Sources = make(map[string]string)
 
-	// Read and parse config file
-	data, err := os.ReadFile(configFile)
+	values, migrated, err := readFile(configFile)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
+		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		// If config is corrupted, return default
-		return DefaultConfig(), nil
+	if err := cfg.applyLayer(values, SourceUser+" "+configFile); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
 	}
+	cfg.normalize()
 
-	// Ensure map is initialized
-	if cfg.ToolSpecificModes == nil {
-		cfg.ToolSpecificModes = make(map[string]ActivationMode)
+	if migrated {
+		if err := cfg.upgrade(configFile); err != nil {
+			return nil, err
+		}
 	}
 
-	return &cfg, nil
+	return cfg, nil
+}
+
+// upgrade backs up an older config file and rewrites it in the current format
+func (c *Config) upgrade(configFile string) error {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configFile+".bak", data, 0644); err != nil {
+		return fmt.Errorf("failed to back up config: %w", err)
+	}
+	if err := c.Save(configFile); err != nil {
+		return fmt.Errorf("failed to upgrade config: %w", err)
+	}
+	return nil
 }
 
-// Save saves configuration to disk
+// normalize initializes maps and fills in defaults for empty values
+func (c *Config) normalize() {
+	if c.ToolSpecificModes == nil {
+		c.ToolSpecificModes = make(map[string]ActivationMode)
+	}
+	if c.CacheScopes == nil {
+		c.CacheScopes = []string{}
+	}
+	if c.CacheBackend == "" {
+		c.CacheBackend = "json"
+	}
+	if c.DangerPolicy == "" {
+		c.DangerPolicy = PolicyStandard
+	}
+	if c.ProductionPatterns == nil {
+		c.ProductionPatterns = append([]string(nil), envctx.DefaultProductionPatterns...)
+	}
+	c.Version = CurrentVersion
+}
+
+// Save saves configuration to disk. Only the keys read from the user file
+// or changed with Set are written, along with the version and profiles, so
+// every other key keeps following its default.
 func (c *Config) Save(configFile string) error {
-	data, err := json.MarshalIndent(c, "", "  ")
+	values, err := c.values()
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	saved := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		switch key {
+		case "version", "profiles", "active_profile": // The last two are omitted when empty
+			saved[key] = value
+		default:
+			if strings.HasPrefix(c.Source(key), SourceUser) {
+				saved[key] = value
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -161,3 +276,33 @@ func ValidateMode(mode string) bool {
 		return false
 	}
 }
+
+// ValidateCacheScope checks if a cache scope dimension is valid
+func ValidateCacheScope(scope string) bool {
+	switch scope {
+	case "repo", "kube_context", "tf_workspace":
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateCacheBackend checks if a cache storage backend is valid
+func ValidateCacheBackend(backend string) bool {
+	switch backend {
+	case "json", "bolt":
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateDangerPolicy checks if a danger policy string is valid
+func ValidateDangerPolicy(policy string) bool {
+	switch DangerPolicy(policy) {
+	case PolicyStrict, PolicyStandard, PolicyPermissive:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Get returns the value of a dotted key, e.g. "provider" or
+// "tool_specific_modes.kubectl"
+func (c *Config) Get(key string) (interface{}, error) {
+	top, sub, nested := strings.Cut(key, ".")
+	if _, ok := fieldByKey(top); !ok {
+		return nil, unknownKeyError(top)
+	}
+
+	values, err := c.values()
+	if err != nil {
+		return nil, err
+	}
+
+	value := values[top]
+	if !nested {
+		return value, nil
+	}
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a map", top)
+	}
+	v, ok := m[sub]
+	if !ok {
+		return nil, fmt.Errorf("%s is not set", key)
+	}
+	return v, nil
+}
+
+// Set parses and validates a string value for a dotted key and applies it.
+// Setting a whole map replaces it; setting "map.key" changes one entry.
+func (c *Config) Set(key, raw string) error {
+	top, sub, nested := strings.Cut(key, ".")
+	field, ok := fieldByKey(top)
+	if !ok {
+		return unknownKeyError(top)
+	}
+	if top == "version" {
+		return fmt.Errorf("version is managed by ai-helper")
+	}
+
+	var value interface{}
+	if nested {
+		if field.Type.Kind() != reflect.Map {
+			return fmt.Errorf("%s is not a map", top)
+		}
+		if sub == "" {
+			return fmt.Errorf("missing key after %q", top+".")
+		}
+		value = map[string]interface{}{sub: raw}
+	} else {
+		parsed, err := ParseValue(top, raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		value = parsed
+	}
+
+	if issues := validateValue(top, value); len(issues) > 0 {
+		return fmt.Errorf("%s: %s", issues[0].Key, issues[0].Message)
+	}
+
+	// A whole map replaces the current one instead of merging into it
+	if !nested && field.Type.Kind() == reflect.Map {
+		c.clearMap(top)
+	}
+
+	return c.applyLayer(map[string]interface{}{top: value}, SourceUser)
+}
+
+// Unset restores a key to its default, or removes one entry of a map key
+func (c *Config) Unset(key string) error {
+	top, sub, nested := strings.Cut(key, ".")
+	field, ok := fieldByKey(top)
+	if !ok {
+		return unknownKeyError(top)
+	}
+	if top == "version" {
+		return fmt.Errorf("version is managed by ai-helper")
+	}
+
+	target := reflect.ValueOf(c).Elem().FieldByName(field.Name)
+	if nested {
+		if field.Type.Kind() != reflect.Map {
+			return fmt.Errorf("%s is not a map", top)
+		}
+		k := reflect.ValueOf(sub).Convert(field.Type.Key())
+		if !target.MapIndex(k).IsValid() {
+			return fmt.Errorf("%s is not set", key)
+		}
+		target.SetMapIndex(k, reflect.Value{})
+		delete(c.Sources, key)
+		return nil
+	}
+
+	defaults := reflect.ValueOf(DefaultConfig()).Elem().FieldByName(field.Name)
+	target.Set(defaults)
+	if c.Sources != nil {
+		c.Sources[top] = SourceDefault
+		for k := range c.Sources {
+			if strings.HasPrefix(k, top+".") {
+				delete(c.Sources, k)
+			}
+		}
+	}
+	return nil
+}
+
+// values returns the config as raw JSON values keyed by JSON name
+func (c *Config) values() (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// clearMap empties a map field so a new value replaces it
+func (c *Config) clearMap(key string) {
+	field, _ := fieldByKey(key)
+	target := reflect.ValueOf(c).Elem().FieldByName(field.Name)
+	target.Set(reflect.MakeMap(field.Type))
+	for k := range c.Sources {
+		if strings.HasPrefix(k, key+".") {
+			delete(c.Sources, k)
+		}
+	}
+}
+
+// unknownKeyError reports an unknown key with a suggestion
+func unknownKeyError(key string) error {
+	if suggestion := closestKey(key); suggestion != "" {
+		return fmt.Errorf("unknown config key %q (did you mean %q?)", key, suggestion)
+	}
+	return fmt.Errorf("unknown config key %q", key)
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testConfig returns a config with a tool mode and a profile set
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Sources = make(map[string]string)
+	if err := cfg.Set("tool_specific_modes", "kubectl=manual,helm=auto"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetProfileValue("offline", "provider", "ollama"); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		key  string
+		want interface{}
+		err  string // Part of the expected error
+	}{
+		{key: "provider", want: string(DefaultConfig().Provider)},
+		{key: "show_confidence", want: DefaultConfig().ShowConfidence},
+		{key: "tool_specific_modes", want: map[string]interface{}{"kubectl": "manual", "helm": "auto"}},
+		{key: "tool_specific_modes.kubectl", want: "manual"},
+		{key: "profiles.offline", want: map[string]interface{}{"provider": "ollama"}},
+		{key: "tool_specific_modes.docker", err: "tool_specific_modes.docker is not set"},
+		{key: "provider.name", err: "provider is not a map"},
+		{key: "providr", err: `unknown config key "providr" (did you mean "provider"?)`},
+		{key: "providr.x", err: `unknown config key "providr"`},
+	}
+
+	cfg := testConfig(t)
+	for _, tt := range tests {
+		value, err := cfg.Get(tt.key)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Get(%q) error = %v, want %q", tt.key, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(value, tt.want) {
+			t.Errorf("Get(%q) = %#v, %v; want %#v", tt.key, value, err, tt.want)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		key   string
+		raw   string
+		check string      // Key read back after the change (key if empty)
+		want  interface{} // Value of check after the change
+		err   string      // Part of the expected error
+	}{
+		{key: "provider", raw: "opencode", want: "opencode"},
+		{key: "auto_execute_safe", raw: "1", want: true},
+		{key: "cache_scopes", raw: "repo,tf_workspace", want: []interface{}{"repo", "tf_workspace"}},
+		{key: "tool_specific_modes.docker", raw: "disabled", check: "tool_specific_modes", want: map[string]interface{}{"kubectl": "manual", "helm": "auto", "docker": "disabled"}},
+		{key: "tool_specific_modes.kubectl", raw: "auto", want: "auto"},
+		{key: "tool_specific_modes", raw: "docker=manual", check: "tool_specific_modes", want: map[string]interface{}{"docker": "manual"}},
+
+		{key: "tool_specific_modes.kubectl", raw: "sometimes", err: `tool_specific_modes.kubectl: invalid value "sometimes"`},
+		{key: "tool_specific_modes.", raw: "auto", err: `missing key after "tool_specific_modes."`},
+		{key: "provider.name", raw: "x", err: "provider is not a map"},
+		{key: "activation_mode", raw: "sometimes", err: "invalid value"},
+		{key: "show_confidence", raw: "maybe", err: `show_confidence: invalid boolean "maybe"`},
+		{key: "cache_scopes", raw: "branch", err: `invalid value "branch"`},
+		{key: "version", raw: "2", err: "managed by ai-helper"},
+		{key: "activaton_mode", raw: "auto", err: "did you mean"},
+	}
+
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		before, _ := cfg.values()
+		err := cfg.Set(tt.key, tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Set(%q, %q) error = %v, want %q", tt.key, tt.raw, err, tt.err)
+			}
+			if after, _ := cfg.values(); !reflect.DeepEqual(after, before) {
+				t.Errorf("Set(%q, %q) failed but changed the config", tt.key, tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Set(%q, %q): %v", tt.key, tt.raw, err)
+			continue
+		}
+
+		check := tt.check
+		if check == "" {
+			check = tt.key
+		}
+		if value, _ := cfg.Get(check); !reflect.DeepEqual(value, tt.want) {
+			t.Errorf("after Set(%q, %q): %s = %#v, want %#v", tt.key, tt.raw, check, value, tt.want)
+		}
+		if source := cfg.Source(tt.key); source != SourceUser {
+			t.Errorf("after Set(%q, %q): source %q, want %s", tt.key, tt.raw, source, SourceUser)
+		}
+	}
+}
+
+func TestUnset(t *testing.T) {
+	tests := []struct {
+		key   string
+		check string      // Key read back after the change
+		want  interface{} // Value of check after the change
+		err   string      // Part of the expected error
+	}{
+		{key: "tool_specific_modes.kubectl", check: "tool_specific_modes", want: map[string]interface{}{"helm": "auto"}},
+		{key: "tool_specific_modes", check: "tool_specific_modes", want: map[string]interface{}{}},
+		{key: "profiles.offline", check: "profiles", want: nil},
+		{key: "provider", check: "provider", want: string(DefaultConfig().Provider)},
+
+		{key: "tool_specific_modes.docker", err: "tool_specific_modes.docker is not set"},
+		{key: "provider.name", err: "provider is not a map"},
+		{key: "version", err: "managed by ai-helper"},
+		{key: "providr", err: "unknown config key"},
+	}
+
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		if err := cfg.Set("provider", "ollama"); err != nil {
+			t.Fatal(err)
+		}
+		err := cfg.Unset(tt.key)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Unset(%q) error = %v, want %q", tt.key, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unset(%q): %v", tt.key, err)
+			continue
+		}
+		if value, _ := cfg.Get(tt.check); !reflect.DeepEqual(value, tt.want) {
+			t.Errorf("after Unset(%q): %s = %#v, want %#v", tt.key, tt.check, value, tt.want)
+		}
+		// Top-level keys go back to the default; map entries lose their source
+		source, ok := cfg.Sources[tt.key]
+		if strings.Contains(tt.key, ".") == ok || (ok && source != SourceDefault) {
+			t.Errorf("after Unset(%q): source %q", tt.key, source)
+		}
+		for key := range cfg.Sources {
+			if strings.HasPrefix(key, tt.key+".") {
+				t.Errorf("after Unset(%q): %s still has source %q", tt.key, key, cfg.Sources[key])
+			}
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProjectFileName is the per-repository config file, found by walking up
+// from the working directory
+const ProjectFileName = ".ai-helper.yaml"
+
+// Configuration layers, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceUser    = "user"
+	SourceProfile = "profile"
+	SourceProject = "project"
+	SourceEnv     = "env"
+)
+
+// projectKeys are the keys a project file may set. A project file comes
+// with whatever repository is cloned, so it cannot point the helper at
+// another server, change what counts as production or loosen the danger
+// policy (see applyProject).
+var projectKeys = []string{"activation_mode", "provider", "preferred_model", "tool_specific_modes", "danger_policy"}
+
+// isProjectKey reports whether a project file may set key
+func isProjectKey(key string) bool {
+	for _, allowed := range projectKeys {
+		if key == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// dangerRank orders danger policies from the most permissive
+var dangerRank = map[DangerPolicy]int{PolicyPermissive: 0, PolicyStandard: 1, PolicyStrict: 2}
+
+// EnvPrefix prefixes the environment variable of every config key,
+// e.g. AI_HELPER_ACTIVATION_MODE for activation_mode
+const EnvPrefix = "AI_HELPER_"
+
+// envAliases are short environment variable names kept for convenience.
+// The canonical AI_HELPER_<KEY> variable wins when both are set.
+var envAliases = []struct {
+	Name string
+	Key  string
+}{
+	{"AI_HELPER_MODE", "activation_mode"},
+	{"AI_HELPER_MODEL", "preferred_model"},
+	{"AI_HELPER_PROFILE", "active_profile"},
+}
+
+// Resolve builds the effective configuration for dir by applying, in order:
+// defaults, the user file, the active profile, the nearest project file,
+// then AI_HELPER_* environment variables. Sources records which layer set
+// each value.
+func Resolve(userFile, dir string) (*Config, error) {
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(userFile), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Sources = make(map[string]string)
+	for _, key := range Keys() {
+		cfg.Sources[key] = SourceDefault
+	}
+
+	// User file (migrated and rewritten if it is an older version)
+	values, migrated, err := readFile(userFile)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := cfg.applyLayer(values, SourceUser+" "+userFile); err != nil {
+			return nil, fmt.Errorf("%s: %w", userFile, err)
+		}
+		// Load rewrites the file in the current format
+		if migrated {
+			if _, err := Load(userFile); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Active profile, between the user and project layers
+	if err := cfg.applyProfile(); err != nil {
+		return nil, err
+	}
+
+	// Project file (never rewritten; it belongs to the repository)
+	if projectFile := FindProjectFile(dir); projectFile != "" {
+		projectValues, _, err := readFile(projectFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.applyProject(projectValues, projectFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Environment variables
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	cfg.normalize()
+	return cfg, nil
+}
+
+// applyProject applies the values of a project file, which readFile
+// limited to projectKeys. danger_policy is only accepted if it is at least
+// as strict as the policy already in effect.
+func (c *Config) applyProject(values map[string]interface{}, file string) error {
+	if policy, ok := values["danger_policy"].(string); ok && dangerRank[DangerPolicy(policy)] < dangerRank[c.DangerPolicy] {
+		data, _ := os.ReadFile(file)
+		return &ValidationError{Source: file, Issues: []Issue{{
+			Line:    locate(file, data, "danger_policy"),
+			Key:     "danger_policy",
+			Message: fmt.Sprintf("a project may only make the danger policy stricter than %q (from %s)", c.DangerPolicy, c.Source("danger_policy")),
+		}}}
+	}
+	if err := c.applyLayer(values, SourceProject+" "+file); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// EnvName returns the environment variable that overrides a config key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnv applies AI_HELPER_* variables. Values are parsed according to
+// the field type: booleans accept strconv.ParseBool values, lists are
+// comma-separated, and maps take "key=value,..." pairs. A single tool mode
+// can be set with AI_HELPER_TOOL_SPECIFIC_MODES_<TOOL> (underscores in the
+// tool name stand for dashes).
+func (c *Config) applyEnv() error {
+	var names []string
+	keys := make(map[string]string)
+	for _, alias := range envAliases {
+		names = append(names, alias.Name)
+		keys[alias.Name] = alias.Key
+	}
+	for _, key := range Keys() {
+		if key == "version" {
+			continue
+		}
+		names = append(names, EnvName(key))
+		keys[EnvName(key)] = key
+	}
+
+	for _, name := range names {
+		raw, ok := os.LookupEnv(name)
+		if !ok || raw == "" {
+			continue
+		}
+		value, err := ParseValue(keys[name], raw)
+		if err != nil {
+			return &ValidationError{Source: name, Issues: []Issue{{Key: keys[name], Message: err.Error()}}}
+		}
+		if issues := validateValue(keys[name], value); len(issues) > 0 {
+			return &ValidationError{Source: name, Issues: issues}
+		}
+		if err := c.applyLayer(map[string]interface{}{keys[name]: value}, SourceEnv+" "+name); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	// Per-tool modes, sorted for deterministic precedence
+	prefix := EnvName("tool_specific_modes") + "_"
+	var toolVars []string
+	for _, env := range os.Environ() {
+		name, raw, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, prefix) && raw != "" {
+			toolVars = append(toolVars, name)
+		}
+	}
+	sort.Strings(toolVars)
+	for _, name := range toolVars {
+		tool := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, prefix)), "_", "-")
+		modes := map[string]interface{}{tool: os.Getenv(name)}
+		if issues := validateValue("tool_specific_modes", modes); len(issues) > 0 {
+			return &ValidationError{Source: name, Issues: issues}
+		}
+		if err := c.applyLayer(map[string]interface{}{"tool_specific_modes": modes}, SourceEnv+" "+name); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ParseValue converts a command-line or environment string to the JSON
+// shape of a key. Lists are comma-separated ("global" clears cache_scopes)
+// and maps take "key=value,..." pairs.
+func ParseValue(key, raw string) (interface{}, error) {
+	field, ok := fieldByKey(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown config key %q", key)
+	}
+	if key == "cache_scopes" && raw == "global" {
+		return []string{}, nil
+	}
+
+	switch field.Type.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", raw)
+		}
+		return b, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		return n, nil
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	case reflect.Map:
+		pairs := make(map[string]interface{})
+		for _, pair := range strings.Split(raw, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("expected key=value pairs, got %q", pair)
+			}
+			pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		return pairs, nil
+	default:
+		return raw, nil
+	}
+}
+
+// fieldByKey finds the config field with the given JSON name
+func fieldByKey(key string) (reflect.StructField, bool) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if jsonKey(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// FindProjectFile walks up from dir looking for ProjectFileName
+func FindProjectFile(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		file := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Keys returns the JSON names of all persisted config fields
+func Keys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonKey(t.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// jsonKey returns the JSON name of a struct field ("" if not serialized)
+func jsonKey(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// applyLayer merges the values of one layer into c. Maps are merged key
+// by key, so a project can override a single tool mode.
+func (c *Config) applyLayer(values map[string]interface{}, source string) error {
+	if c.Sources == nil {
+		c.Sources = make(map[string]string)
+	}
+	known := make(map[string]bool)
+	for _, key := range Keys() {
+		known[key] = true
+	}
+
+	layer := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if known[key] {
+			layer[key] = value
+		}
+	}
+
+	data, err := json.Marshal(layer)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return err
+	}
+
+	for key, value := range layer {
+		if nested, ok := value.(map[string]interface{}); ok {
+			for sub := range nested {
+				c.Sources[key+"."+sub] = source
+			}
+		}
+		c.Sources[key] = source
+	}
+	return nil
+}
+
+// Source returns which layer set a key ("default" if unknown)
+func (c *Config) Source(key string) string {
+	if source, ok := c.Sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
@@ -0,0 +1,416 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// clearEnv hides any AI_HELPER_* variables of the environment running the
+// tests; empty variables are ignored by Resolve
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range os.Environ() {
+		if name, _, _ := strings.Cut(env, "="); strings.HasPrefix(name, EnvPrefix) {
+			t.Setenv(name, "")
+		}
+	}
+}
+
+// writeFile writes a file, creating its directory
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	clearEnv(t)
+	root := t.TempDir()
+	userFile := filepath.Join(root, "home", ".ai", "config.json")
+	repo := filepath.Join(root, "repo")
+	dir := filepath.Join(repo, "modules", "vpc")
+
+	writeFile(t, userFile, `{
+  "version": 1,
+  "activation_mode": "interactive",
+  "provider": "opencode",
+  "show_confidence": false,
+  "tool_specific_modes": {"kubectl": "interactive", "docker": "manual"}
+}`)
+	writeFile(t, filepath.Join(repo, ProjectFileName), `
+activation_mode: manual
+tool_specific_modes:
+  kubectl: auto
+`)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AI_HELPER_PROVIDER", "ollama")
+	t.Setenv("AI_HELPER_TOOL_SPECIFIC_MODES_TERRAFORM", "disabled")
+
+	cfg, err := Resolve(userFile, dir)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		source string // Layer, without the file or variable
+	}{
+		{"danger_policy", "standard", SourceDefault},
+		{"show_confidence", false, SourceUser},
+		{"tool_specific_modes.docker", "manual", SourceUser},
+		{"activation_mode", "manual", SourceProject},
+		{"tool_specific_modes.kubectl", "auto", SourceProject},
+		{"provider", "ollama", SourceEnv},
+		{"tool_specific_modes.terraform", "disabled", SourceEnv},
+	}
+	for _, tt := range tests {
+		value, err := cfg.Get(tt.key)
+		if err != nil {
+			t.Errorf("Get(%q): %v", tt.key, err)
+			continue
+		}
+		if value != tt.value {
+			t.Errorf("%s = %v, want %v", tt.key, value, tt.value)
+		}
+		if source := cfg.Source(tt.key); strings.Fields(source)[0] != tt.source {
+			t.Errorf("%s came from %q, want %s", tt.key, source, tt.source)
+		}
+	}
+}
+
+func TestResolveWithoutFiles(t *testing.T) {
+	clearEnv(t)
+	root := t.TempDir()
+
+	cfg, err := Resolve(filepath.Join(root, ".ai", "config.json"), root)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.ActivationMode != DefaultConfig().ActivationMode || cfg.Source("activation_mode") != SourceDefault {
+		t.Errorf("activation_mode = %s from %s, want the default", cfg.ActivationMode, cfg.Source("activation_mode"))
+	}
+}
+
+func TestResolveInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		project string
+		env     [2]string
+	}{
+		{name: "user file", user: `{"version": 1, "activation_mode": "sometimes"}`},
+		{name: "project file", project: "danger_policy: reckless\n"},
+		{name: "environment", env: [2]string{"AI_HELPER_SHOW_CONFIDENCE", "maybe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			root := t.TempDir()
+			userFile := filepath.Join(root, ".ai", "config.json")
+			if tt.user != "" {
+				writeFile(t, userFile, tt.user)
+			}
+			if tt.project != "" {
+				writeFile(t, filepath.Join(root, ProjectFileName), tt.project)
+			}
+			if tt.env[0] != "" {
+				t.Setenv(tt.env[0], tt.env[1])
+			}
+
+			var verr *ValidationError
+			if _, err := Resolve(userFile, root); !errors.As(err, &verr) {
+				t.Errorf("Resolve error = %v, want a *ValidationError", err)
+			}
+		})
+	}
+}
+
+// savedKeys returns the keys written to a config file
+func savedKeys(t *testing.T, file string) []string {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSaveWritesSetKeys(t *testing.T) {
+	clearEnv(t)
+	root := t.TempDir()
+	file := filepath.Join(root, ".ai", "config.json")
+
+	steps := []struct {
+		change func(c *Config) error
+		want   []string
+	}{
+		{func(c *Config) error { return c.Set("activation_mode", "interactive") }, []string{"activation_mode", "version"}},
+		{func(c *Config) error { return c.Set("tool_specific_modes.kubectl", "manual") }, []string{"activation_mode", "tool_specific_modes", "version"}},
+		{func(c *Config) error { return c.Unset("activation_mode") }, []string{"tool_specific_modes", "version"}},
+		{func(c *Config) error { return c.SetProfileValue("offline", "provider", "ollama") }, []string{"profiles", "tool_specific_modes", "version"}},
+	}
+
+	for i, step := range steps {
+		cfg, err := Load(file)
+		if err != nil {
+			t.Fatalf("step %d: Load: %v", i, err)
+		}
+		if err := step.change(cfg); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if err := cfg.Save(file); err != nil {
+			t.Fatalf("step %d: Save: %v", i, err)
+		}
+		if got := savedKeys(t, file); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("step %d: saved %q, want %q", i, got, step.want)
+		}
+	}
+
+	// Keys that were never set still follow the defaults
+	cfg, err := Resolve(file, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source := cfg.Source("show_confidence"); source != SourceDefault {
+		t.Errorf("show_confidence came from %q, want %s", source, SourceDefault)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want interface{}
+	}{
+		{"auto_execute_safe", "true", true},
+		{"preferred_model", "llama3", "llama3"},
+		{"cache_scopes", "repo, kube_context", []string{"repo", "kube_context"}},
+		{"cache_scopes", "global", []string{}},
+		{"tool_specific_modes", "kubectl=auto,docker=manual", map[string]interface{}{"kubectl": "auto", "docker": "manual"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseValue(tt.key, tt.raw)
+		if err != nil {
+			t.Errorf("ParseValue(%q, %q): %v", tt.key, tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseValue(%q, %q) = %#v, want %#v", tt.key, tt.raw, got, tt.want)
+		}
+	}
+
+	for _, bad := range [][2]string{{"auto_execute_safe", "maybe"}, {"tool_specific_modes", "kubectl"}, {"no_such_key", "x"}} {
+		if _, err := ParseValue(bad[0], bad[1]); err == nil {
+			t.Errorf("ParseValue(%q, %q) should fail", bad[0], bad[1])
+		}
+	}
+}
+
+func TestResolveProjectLayer(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string // danger_policy in the user file
+		project string
+		want    map[string]interface{}
+		err     string // Part of the expected error
+	}{
+		{
+			name:    "allowed keys",
+			project: "activation_mode: manual\nprovider: ollama\npreferred_model: qwen\ntool_specific_modes:\n  helm: auto\n",
+			want:    map[string]interface{}{"activation_mode": "manual", "provider": "ollama", "preferred_model": "qwen", "tool_specific_modes.helm": "auto"},
+		},
+		{name: "ollama_url", project: "ollama_url: http://attacker:11434\n", err: "ollama_url: not allowed in a project file"},
+		{name: "production_patterns", project: "production_patterns: []\n", err: "production_patterns: not allowed"},
+		{name: "auto_execute_safe", project: "auto_execute_safe: true\n", err: "auto_execute_safe: not allowed"},
+		{name: "unknown key", project: "activaton_mode: auto\n", err: "unknown key"},
+
+		{name: "stricter policy", user: "standard", project: "danger_policy: strict\n", want: map[string]interface{}{"danger_policy": "strict"}},
+		{name: "same policy", user: "strict", project: "danger_policy: strict\n", want: map[string]interface{}{"danger_policy": "strict"}},
+		{name: "stricter than permissive", user: "permissive", project: "danger_policy: standard\n", want: map[string]interface{}{"danger_policy": "standard"}},
+		{name: "looser policy", project: "danger_policy: permissive\n", err: "danger_policy: a project may only make the danger policy stricter"},
+		{name: "looser than strict", user: "strict", project: "danger_policy: standard\n", err: `stricter than "strict"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			root := t.TempDir()
+			userFile := filepath.Join(root, ".ai", "config.json")
+			if tt.user != "" {
+				writeFile(t, userFile, `{"version": 1, "danger_policy": "`+tt.user+`"}`)
+			}
+			writeFile(t, filepath.Join(root, ProjectFileName), tt.project)
+
+			cfg, err := Resolve(userFile, root)
+			if tt.err != "" {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Resolve error = %v, want a *ValidationError with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			for key, want := range tt.want {
+				if value, _ := cfg.Get(key); value != want {
+					t.Errorf("%s = %v, want %v", key, value, want)
+				}
+				if source := cfg.Source(key); strings.Fields(source)[0] != SourceProject {
+					t.Errorf("%s came from %q, want %s", key, source, SourceProject)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		want   map[string]interface{}
+		source map[string]string // Variable each key came from
+	}{
+		{
+			name:   "canonical name",
+			env:    map[string]string{"AI_HELPER_PROVIDER": "ollama"},
+			want:   map[string]interface{}{"provider": "ollama"},
+			source: map[string]string{"provider": "AI_HELPER_PROVIDER"},
+		},
+		{
+			name:   "aliases",
+			env:    map[string]string{"AI_HELPER_MODE": "manual", "AI_HELPER_MODEL": "qwen"},
+			want:   map[string]interface{}{"activation_mode": "manual", "preferred_model": "qwen"},
+			source: map[string]string{"activation_mode": "AI_HELPER_MODE", "preferred_model": "AI_HELPER_MODEL"},
+		},
+		{
+			name:   "canonical name wins over alias",
+			env:    map[string]string{"AI_HELPER_MODE": "manual", "AI_HELPER_ACTIVATION_MODE": "auto"},
+			want:   map[string]interface{}{"activation_mode": "auto"},
+			source: map[string]string{"activation_mode": "AI_HELPER_ACTIVATION_MODE"},
+		},
+		{
+			name: "typed values",
+			env:  map[string]string{"AI_HELPER_SHOW_CONFIDENCE": "0", "AI_HELPER_AUTO_EXECUTE_SAFE": "true"},
+			want: map[string]interface{}{"show_confidence": false, "auto_execute_safe": true},
+		},
+		{
+			name: "map",
+			env:  map[string]string{"AI_HELPER_TOOL_SPECIFIC_MODES": "kubectl=interactive, helm=auto"},
+			want: map[string]interface{}{"tool_specific_modes.kubectl": "interactive", "tool_specific_modes.helm": "auto"},
+		},
+		{
+			name: "per-tool variable wins over the map",
+			env: map[string]string{
+				"AI_HELPER_TOOL_SPECIFIC_MODES":                "kubectl=interactive",
+				"AI_HELPER_TOOL_SPECIFIC_MODES_KUBECTL":        "disabled",
+				"AI_HELPER_TOOL_SPECIFIC_MODES_DOCKER_COMPOSE": "manual",
+			},
+			want: map[string]interface{}{"tool_specific_modes.kubectl": "disabled", "tool_specific_modes.docker-compose": "manual"},
+			source: map[string]string{
+				"tool_specific_modes.kubectl":        "AI_HELPER_TOOL_SPECIFIC_MODES_KUBECTL",
+				"tool_specific_modes.docker-compose": "AI_HELPER_TOOL_SPECIFIC_MODES_DOCKER_COMPOSE",
+			},
+		},
+		{
+			name: "empty variables are ignored",
+			env:  map[string]string{"AI_HELPER_PROVIDER": "", "AI_HELPER_TOOL_SPECIFIC_MODES_HELM": ""},
+			want: map[string]interface{}{"provider": string(DefaultConfig().Provider), "tool_specific_modes.helm": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			root := t.TempDir()
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Resolve(filepath.Join(root, ".ai", "config.json"), root)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			for key, want := range tt.want {
+				value, _ := cfg.Get(key)
+				if !reflect.DeepEqual(value, want) {
+					t.Errorf("%s = %#v, want %#v", key, value, want)
+				}
+			}
+			for key, name := range tt.source {
+				if source := cfg.Source(key); source != SourceEnv+" "+name {
+					t.Errorf("%s came from %q, want %s", key, source, name)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		key   string // Key named by the error
+	}{
+		{"AI_HELPER_SHOW_CONFIDENCE", "maybe", "show_confidence"},
+		{"AI_HELPER_MODE", "sometimes", "activation_mode"},
+		{"AI_HELPER_DANGER_POLICY", "reckless", "danger_policy"},
+		{"AI_HELPER_CACHE_SCOPES", "repo,branch", "cache_scopes"},
+		{"AI_HELPER_TOOL_SPECIFIC_MODES", "kubectl", "tool_specific_modes"},
+		{"AI_HELPER_TOOL_SPECIFIC_MODES_HELM", "sometimes", "tool_specific_modes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			root := t.TempDir()
+			t.Setenv(tt.name, tt.value)
+
+			_, err := Resolve(filepath.Join(root, ".ai", "config.json"), root)
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Resolve error = %v, want a *ValidationError", err)
+			}
+			if verr.Source != tt.name || len(verr.Issues) == 0 || !strings.HasPrefix(verr.Issues[0].Key, tt.key) {
+				t.Errorf("Resolve error = %+v, want an issue with %s from %s", verr, tt.key, tt.name)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"provider":                    "AI_HELPER_PROVIDER",
+		"tool_specific_modes":         "AI_HELPER_TOOL_SPECIFIC_MODES",
+		"tool_specific_modes.kubectl": "AI_HELPER_TOOL_SPECIFIC_MODES_KUBECTL",
+	}
+	for key, want := range tests {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
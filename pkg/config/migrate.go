@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CurrentVersion is the config file format written by this release
+const CurrentVersion = 1
+
+// migrations upgrade raw config values; migrations[i] goes from version i to i+1
+var migrations = []func(values map[string]interface{}){
+	migrateV0,
+}
+
+// migrate upgrades raw config values to CurrentVersion in place and
+// reports whether an upgrade was needed
+func migrate(values map[string]interface{}) (bool, error) {
+	version, err := versionOf(values)
+	if err != nil {
+		return false, err
+	}
+	if version > CurrentVersion {
+		return false, fmt.Errorf("version %d is newer than this ai-helper supports (%d); please upgrade ai-helper", version, CurrentVersion)
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		migrations[v](values)
+	}
+	values["version"] = CurrentVersion
+
+	return version < CurrentVersion, nil
+}
+
+// versionOf reads the version key (files without one are version 0)
+func versionOf(values map[string]interface{}) (int, error) {
+	raw, ok := values["version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+
+	switch v := raw.(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) && v >= 0 {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("expected a non-negative integer, got %v", raw)
+}
+
+// migrateV0 upgrades files written before versioning. Hand-edited files
+// often carry cache_scopes as a comma-separated string and null maps.
+func migrateV0(values map[string]interface{}) {
+	if scopes, ok := values["cache_scopes"].(string); ok {
+		list := []interface{}{}
+		if scopes != "global" {
+			for _, scope := range strings.Split(scopes, ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					list = append(list, scope)
+				}
+			}
+		}
+		values["cache_scopes"] = list
+	}
+
+	for _, key := range []string{"tool_specific_modes", "cache_scopes"} {
+		if value, ok := values[key]; ok && value == nil {
+			delete(values, key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		want     map[string]interface{}
+		migrated bool
+		err      string // Part of the expected error
+	}{
+		{
+			name:     "unversioned scopes string",
+			values:   map[string]interface{}{"cache_scopes": "repo, ,kube_context"},
+			want:     map[string]interface{}{"version": 1, "cache_scopes": []interface{}{"repo", "kube_context"}},
+			migrated: true,
+		},
+		{
+			name:     "global scope",
+			values:   map[string]interface{}{"version": nil, "cache_scopes": "global"},
+			want:     map[string]interface{}{"version": 1, "cache_scopes": []interface{}{}},
+			migrated: true,
+		},
+		{
+			name:     "null maps",
+			values:   map[string]interface{}{"version": 0.0, "tool_specific_modes": nil, "cache_scopes": nil, "provider": "ollama"},
+			want:     map[string]interface{}{"version": 1, "provider": "ollama"},
+			migrated: true,
+		},
+		{
+			name:   "current version",
+			values: map[string]interface{}{"version": 1.0, "cache_scopes": "repo"},
+			want:   map[string]interface{}{"version": 1, "cache_scopes": "repo"},
+		},
+		{name: "newer version", values: map[string]interface{}{"version": 2}, err: "newer than this ai-helper supports"},
+		{name: "negative version", values: map[string]interface{}{"version": -1.0}, err: "non-negative integer"},
+		{name: "string version", values: map[string]interface{}{"version": "1"}, err: "non-negative integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, err := migrate(tt.values)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("migrate error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			if migrated != tt.migrated || !reflect.DeepEqual(tt.values, tt.want) {
+				t.Errorf("migrate = %v, %#v; want %v, %#v", migrated, tt.values, tt.migrated, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUpgrades(t *testing.T) {
+	clearEnv(t)
+	file := filepath.Join(t.TempDir(), ".ai", "config.json")
+	const old = `{"activation_mode": "manual", "cache_scopes": "repo", "tool_specific_modes": null}`
+	writeFile(t, file, old)
+
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ActivationMode != ModeManual || !reflect.DeepEqual(cfg.CacheScopes, []string{"repo"}) {
+		t.Errorf("Load = mode %s, scopes %q", cfg.ActivationMode, cfg.CacheScopes)
+	}
+
+	if backup, _ := os.ReadFile(file + ".bak"); string(backup) != old {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+	report, err := ValidateFile(file)
+	if err != nil || report.Version != CurrentVersion || report.Migrated {
+		t.Errorf("upgraded file: ValidateFile = %+v, %v; want version %d", report, err, CurrentVersion)
+	}
+	if got := savedKeys(t, file); !reflect.DeepEqual(got, []string{"activation_mode", "cache_scopes", "version"}) {
+		t.Errorf("upgraded file has keys %q", got)
+	}
+
+	// A current file is not rewritten again
+	if err := os.Remove(file + ".bak"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file + ".bak"); !os.IsNotExist(err) {
+		t.Error("Load backed up a current file")
+	}
+}
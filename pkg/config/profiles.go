@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// profileExcludedKeys cannot be set inside a profile
+var profileExcludedKeys = []string{"version", "profiles", "active_profile"}
+
+// ProfileNames returns the defined profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetProfileValue parses, validates and stores one setting of a profile,
+// creating the profile if needed
+func (c *Config) SetProfileValue(profile, key, raw string) error {
+	if profile == "" {
+		return fmt.Errorf("empty profile name")
+	}
+	for _, excluded := range profileExcludedKeys {
+		if key == excluded {
+			return fmt.Errorf("%s is not allowed inside a profile", key)
+		}
+	}
+
+	value, err := ParseValue(key, raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if issues := validateValue(key, value); len(issues) > 0 {
+		return fmt.Errorf("%s: %s", issues[0].Key, issues[0].Message)
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]map[string]interface{})
+	}
+	if c.Profiles[profile] == nil {
+		c.Profiles[profile] = make(map[string]interface{})
+	}
+	c.Profiles[profile][key] = value
+	return nil
+}
+
+// DeleteProfile removes a profile and deactivates it if it was active
+func (c *Config) DeleteProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q is not defined", name)
+	}
+	delete(c.Profiles, name)
+	if c.ActiveProfile == name {
+		c.ActiveProfile = ""
+	}
+	return nil
+}
+
+// applyProfile applies the active profile. The selection comes from the
+// user file, then AI_HELPER_PROFILE or AI_HELPER_ACTIVE_PROFILE.
+func (c *Config) applyProfile() error {
+	source := c.Source("active_profile")
+
+	for _, name := range []string{"AI_HELPER_PROFILE", EnvName("active_profile")} {
+		if value := os.Getenv(name); value != "" {
+			c.ActiveProfile = value
+			source = SourceEnv + " " + name
+		}
+	}
+
+	if c.ActiveProfile == "" {
+		return nil
+	}
+
+	values, ok := c.Profiles[c.ActiveProfile]
+	if !ok {
+		return fmt.Errorf("active profile %q (from %s) is not defined", c.ActiveProfile, source)
+	}
+	return c.applyLayer(values, SourceProfile+" "+c.ActiveProfile)
+}
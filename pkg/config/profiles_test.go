@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveProfiles(t *testing.T) {
+	const user = `{
+  "version": 1,
+  "activation_mode": "interactive",
+  "preferred_model": "llama3",
+  "active_profile": %q,
+  "profiles": {
+    "offline": {"provider": "ollama", "activation_mode": "manual"},
+    "on-call": {"danger_policy": "strict", "preferred_model": "qwen"}
+  }
+}`
+
+	tests := []struct {
+		name    string
+		active  string // active_profile in the user file
+		project string
+		env     string // AI_HELPER_PROFILE
+		want    map[string]string
+		sources map[string]string
+		err     string // Part of the expected error
+	}{
+		{
+			name:    "no profile",
+			want:    map[string]string{"activation_mode": "interactive", "danger_policy": "standard"},
+			sources: map[string]string{"activation_mode": SourceUser},
+		},
+		{
+			name:    "user selection",
+			active:  "offline",
+			want:    map[string]string{"activation_mode": "manual", "provider": "ollama", "preferred_model": "llama3"},
+			sources: map[string]string{"activation_mode": SourceProfile, "preferred_model": SourceUser},
+		},
+		{
+			name:    "project overrides the profile",
+			active:  "offline",
+			project: "activation_mode: auto\n",
+			want:    map[string]string{"activation_mode": "auto", "provider": "ollama"},
+			sources: map[string]string{"activation_mode": SourceProject, "provider": SourceProfile},
+		},
+		{
+			name:    "project selection",
+			active:  "offline",
+			project: "active_profile: on-call\n",
+			err:     "active_profile: not allowed in a project file",
+		},
+		{
+			name:    "project profile",
+			project: "profiles:\n  release:\n    danger_policy: strict\n",
+			err:     "profiles: not allowed in a project file",
+		},
+		{
+			name:   "environment selection",
+			active: "offline",
+			env:    "on-call",
+			want:   map[string]string{"danger_policy": "strict", "activation_mode": "interactive"},
+		},
+		{
+			name:   "undefined profile",
+			active: "missing",
+			err:    `active profile "missing" (from user`,
+		},
+		{
+			name: "undefined profile from the environment",
+			env:  "missing",
+			err:  "from env AI_HELPER_PROFILE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			root := t.TempDir()
+			userFile := filepath.Join(root, ".ai", "config.json")
+			writeFile(t, userFile, fmt.Sprintf(user, tt.active))
+			if tt.project != "" {
+				writeFile(t, filepath.Join(root, ProjectFileName), tt.project)
+			}
+			if tt.env != "" {
+				t.Setenv("AI_HELPER_PROFILE", tt.env)
+			}
+
+			cfg, err := Resolve(userFile, root)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Resolve error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+
+			for key, want := range tt.want {
+				if value, _ := cfg.Get(key); value != want {
+					t.Errorf("%s = %v, want %s", key, value, want)
+				}
+			}
+			for key, want := range tt.sources {
+				if source := cfg.Source(key); strings.Fields(source)[0] != want {
+					t.Errorf("%s came from %q, want %s", key, source, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetProfileValue(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"provider", "ollama", true},
+		{"cache_scopes", "repo,kube_context", true},
+		{"activation_mode", "sometimes", false},
+		{"active_profile", "other", false},
+		{"profiles", "x=y", false},
+		{"version", "1", false},
+		{"no_such_key", "x", false},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		err := cfg.SetProfileValue("offline", tt.key, tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("SetProfileValue(%q, %q) error = %v, want ok %v", tt.key, tt.value, err, tt.ok)
+		}
+		if tt.ok && cfg.Profiles["offline"][tt.key] == nil {
+			t.Errorf("SetProfileValue(%q, %q) did not store the value", tt.key, tt.value)
+		}
+	}
+
+	if err := DefaultConfig().SetProfileValue("", "provider", "ollama"); err == nil {
+		t.Error("SetProfileValue should reject an empty profile name")
+	}
+}
+
+func TestDeleteProfile(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.SetProfileValue("offline", "provider", "ollama"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ActiveProfile = "offline"
+
+	if err := cfg.DeleteProfile("offline"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ActiveProfile != "" || len(cfg.ProfileNames()) != 0 {
+		t.Errorf("after delete: active %q, profiles %q", cfg.ActiveProfile, cfg.ProfileNames())
+	}
+	if err := cfg.DeleteProfile("offline"); err == nil {
+		t.Error("deleting an undefined profile should fail")
+	}
+}
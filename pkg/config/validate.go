@@ -0,0 +1,389 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a single problem found in a config source
+type Issue struct {
+	Line    int    // 1-based line in the file (0 if unknown)
+	Key     string // Dotted key, e.g. "tool_specific_modes.kubectl"
+	Message string
+}
+
+// ValidationError lists every issue found in one config source
+type ValidationError struct {
+	Source string // File path or environment variable
+	Issues []Issue
+}
+
+// Error formats the issues as "source:line: key: message", one per line
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		location := e.Source
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", e.Source, issue.Line)
+		}
+		if issue.Key != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s: %s", location, issue.Key, issue.Message))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", location, issue.Message))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FileReport summarizes a config file check
+type FileReport struct {
+	Version  int  // Version found in the file (0 = unversioned)
+	Migrated bool // Whether the file needs (or received) a migration
+}
+
+// ValidateFile checks a config file without changing it. YAML files
+// (.yaml/.yml) are parsed as project files, anything else as JSON.
+func ValidateFile(file string) (*FileReport, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	_, report, err := check(file, data)
+	return report, err
+}
+
+// readFile parses, migrates and validates a config file. It reports
+// whether the values were migrated from an older version.
+func readFile(file string) (map[string]interface{}, bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false, err
+	}
+
+	values, report, err := check(file, data)
+	if err != nil {
+		return nil, false, err
+	}
+	return values, report.Migrated, nil
+}
+
+// check parses, migrates and validates the contents of a config file
+func check(file string, data []byte) (map[string]interface{}, *FileReport, error) {
+	values, err := parseFile(file, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versionIssue := func(err error) error {
+		return &ValidationError{Source: file, Issues: []Issue{{Line: locate(file, data, "version"), Key: "version", Message: err.Error()}}}
+	}
+
+	version, err := versionOf(values)
+	if err != nil {
+		return nil, nil, versionIssue(err)
+	}
+	migrated, err := migrate(values)
+	if err != nil {
+		return nil, nil, versionIssue(err)
+	}
+
+	issues := validateValues(values)
+	if isYAML(file) {
+		issues = append(issues, projectIssues(values)...)
+	}
+	if len(issues) > 0 {
+		for i := range issues {
+			issues[i].Line = locate(file, data, issues[i].Key)
+		}
+		sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+		return nil, nil, &ValidationError{Source: file, Issues: issues}
+	}
+
+	// Project files belong to the repository and are never rewritten
+	return values, &FileReport{Version: version, Migrated: migrated && !isYAML(file)}, nil
+}
+
+// parseFile decodes a config file into raw values
+func parseFile(file string, data []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if len(bytes.TrimSpace(data)) == 0 {
+		return values, nil
+	}
+
+	if isYAML(file) {
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, &ValidationError{Source: file, Issues: []Issue{{Message: strings.TrimPrefix(err.Error(), "yaml: ")}}}
+		}
+		if values == nil {
+			values = make(map[string]interface{})
+		}
+		return values, nil
+	}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		issue := Issue{Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			issue.Line = lineAt(data, int(syntaxErr.Offset))
+		case errors.As(err, &typeErr):
+			issue.Line = lineAt(data, int(typeErr.Offset))
+			issue.Message = "config must be a JSON object"
+		}
+		return nil, &ValidationError{Source: file, Issues: []Issue{issue}}
+	}
+	return values, nil
+}
+
+// validateValues checks every key of a config layer
+func validateValues(values map[string]interface{}) []Issue {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var issues []Issue
+	for _, key := range keys {
+		issues = append(issues, validateValue(key, values[key])...)
+	}
+	return issues
+}
+
+// projectIssues reports known keys a project file may not set
+func projectIssues(values map[string]interface{}) []Issue {
+	var issues []Issue
+	for key := range values {
+		if _, known := fieldByKey(key); !known || key == "version" || isProjectKey(key) {
+			continue
+		}
+		issues = append(issues, Issue{Key: key, Message: "not allowed in a project file (set it in your personal config)"})
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return issues
+}
+
+// validateValue checks the type and allowed values of one key
+func validateValue(key string, value interface{}) []Issue {
+	field, ok := fieldByKey(key)
+	if !ok {
+		message := "unknown key"
+		if suggestion := closestKey(key); suggestion != "" {
+			message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		return []Issue{{Key: key, Message: message}}
+	}
+
+	// Type check by decoding into the field type
+	data, err := json.Marshal(value)
+	if err != nil {
+		return []Issue{{Key: key, Message: err.Error()}}
+	}
+	target := reflect.New(field.Type)
+	if err := json.Unmarshal(data, target.Interface()); err != nil {
+		return []Issue{{Key: key, Message: fmt.Sprintf("expected %s, got %s", typeName(field.Type), string(data))}}
+	}
+
+	var issues []Issue
+	invalid := func(k, v, allowed string) {
+		issues = append(issues, Issue{Key: k, Message: fmt.Sprintf("invalid value %q (use: %s)", v, allowed)})
+	}
+
+	switch key {
+	case "activation_mode":
+		if mode := target.Elem().String(); !ValidateMode(mode) {
+			invalid(key, mode, "auto, interactive, manual, disabled")
+		}
+	case "provider":
+		if provider := LLMProvider(target.Elem().String()); provider != ProviderOllama && provider != ProviderOpenCode {
+			invalid(key, string(provider), "ollama, opencode")
+		}
+	case "danger_policy":
+		if policy := target.Elem().String(); !ValidateDangerPolicy(policy) {
+			invalid(key, policy, "strict, standard, permissive")
+		}
+	case "cache_backend":
+		if backend := target.Elem().String(); !ValidateCacheBackend(backend) {
+			invalid(key, backend, "json, bolt")
+		}
+	case "cache_scopes":
+		for _, scope := range target.Elem().Interface().([]string) {
+			if !ValidateCacheScope(scope) {
+				invalid(key, scope, "repo, kube_context, tf_workspace")
+			}
+		}
+	case "production_patterns":
+		for _, pattern := range target.Elem().Interface().([]string) {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				issues = append(issues, Issue{Key: key, Message: fmt.Sprintf("invalid pattern %q (use globs such as *prod*)", pattern)})
+			}
+		}
+	case "profiles":
+		profiles := target.Elem().Interface().(map[string]map[string]interface{})
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			issues = append(issues, validateProfile(name, profiles[name])...)
+		}
+	case "tool_specific_modes":
+		modes := target.Elem().Interface().(map[string]ActivationMode)
+		tools := make([]string, 0, len(modes))
+		for tool := range modes {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			if tool == "" {
+				issues = append(issues, Issue{Key: key, Message: "empty tool name"})
+			} else if !ValidateMode(string(modes[tool])) {
+				invalid(key+"."+tool, string(modes[tool]), "auto, interactive, manual, disabled")
+			}
+		}
+	}
+
+	return issues
+}
+
+// validateProfile checks the settings of one profile
+func validateProfile(name string, values map[string]interface{}) []Issue {
+	prefix := "profiles." + name
+	if name == "" {
+		return []Issue{{Key: "profiles", Message: "empty profile name"}}
+	}
+
+	var issues []Issue
+	for _, issue := range validateValues(values) {
+		issue.Key = prefix + "." + issue.Key
+		issues = append(issues, issue)
+	}
+	for _, key := range profileExcludedKeys {
+		if _, ok := values[key]; ok {
+			issues = append(issues, Issue{Key: prefix + "." + key, Message: "not allowed inside a profile"})
+		}
+	}
+	return issues
+}
+
+// typeName describes a field type for error messages
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int:
+		return "an integer"
+	case reflect.Slice:
+		return "a list of strings"
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Map {
+			return "a map of profile name to settings"
+		}
+		return "a map of tool to mode"
+	default:
+		return "a string"
+	}
+}
+
+// closestKey suggests a known key for a misspelled one
+func closestKey(key string) string {
+	best, bestDistance := "", 4
+	for _, known := range Keys() {
+		if d := editDistance(key, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// locate finds the line of a dotted key in a config file (0 if not found)
+func locate(file string, data []byte, key string) int {
+	if isYAML(file) {
+		var root yaml.Node
+		if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+			return 0
+		}
+		node := root.Content[0]
+		line := 0
+		for _, part := range strings.Split(key, ".") {
+			if node == nil || node.Kind != yaml.MappingNode {
+				return line
+			}
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == part {
+					line, next = node.Content[i].Line, node.Content[i+1]
+					break
+				}
+			}
+			if next == nil {
+				return line
+			}
+			node = next
+		}
+		return line
+	}
+
+	// JSON: find each path segment after the previous one
+	pos, found := 0, false
+	for _, part := range strings.Split(key, ".") {
+		idx := bytes.Index(data[pos:], []byte(`"`+part+`"`))
+		if idx < 0 {
+			break
+		}
+		pos += idx
+		found = true
+	}
+	if !found {
+		return 0
+	}
+	return lineAt(data, pos)
+}
+
+// lineAt converts a byte offset to a 1-based line number
+func lineAt(data []byte, offset int) int {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// isYAML reports whether a file should be parsed as YAML
+func isYAML(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".yaml" || ext == ".yml"
+}
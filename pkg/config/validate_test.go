@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string // File name, which selects JSON or YAML
+		content  string
+		version  int
+		migrated bool
+		issues   []string // "line: key: message" parts of the expected issues, in order
+	}{
+		{name: "current", file: "config.json", content: `{"version": 1, "provider": "ollama"}`, version: 1},
+		{name: "empty", file: "config.json", content: "\n", migrated: true},
+		{name: "unversioned", file: "config.json", content: `{"cache_scopes": "repo, kube_context"}`, migrated: true},
+		{
+			name:    "newer version",
+			file:    "config.json",
+			content: "{\n  \"version\": 7\n}",
+			issues:  []string{"2: version: version 7 is newer"},
+		},
+		{
+			name:    "bad version",
+			file:    "config.json",
+			content: `{"version": 1.5}`,
+			issues:  []string{"1: version: expected a non-negative integer"},
+		},
+		{
+			name:    "syntax error",
+			file:    "config.json",
+			content: "{\n  \"version\": 1,\n  \"provider\": ollama\n}",
+			issues:  []string{"3: : invalid character"},
+		},
+		{
+			name:    "not an object",
+			file:    "config.json",
+			content: `["version", 1]`,
+			issues:  []string{"1: : config must be a JSON object"},
+		},
+		{
+			name:    "issues by line",
+			file:    "config.json",
+			content: "{\n  \"version\": 1,\n  \"show_confidence\": \"yes\",\n  \"activaton_mode\": \"auto\",\n  \"tool_specific_modes\": {\"helm\": \"sometimes\"}\n}",
+			issues: []string{
+				`3: show_confidence: expected true or false, got "yes"`,
+				`4: activaton_mode: unknown key (did you mean "activation_mode"?)`,
+				`5: tool_specific_modes.helm: invalid value "sometimes"`,
+			},
+		},
+		{name: "project", file: ProjectFileName, content: "activation_mode: manual\ntool_specific_modes:\n  kubectl: auto\n"},
+		{
+			name:    "project with personal keys",
+			file:    ProjectFileName,
+			content: "activation_mode: manual\nollama_url: http://attacker:11434\ntool_specific_modes:\n  kubectl: sometimes\n",
+			issues: []string{
+				"2: ollama_url: not allowed in a project file",
+				`4: tool_specific_modes.kubectl: invalid value "sometimes"`,
+			},
+		},
+		{name: "invalid yaml", file: ProjectFileName, content: "activation_mode: [manual\n", issues: []string{"0: : "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), tt.file)
+			writeFile(t, file, tt.content)
+
+			report, err := ValidateFile(file)
+			if len(tt.issues) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateFile: %v", err)
+				}
+				if report.Version != tt.version || report.Migrated != tt.migrated {
+					t.Errorf("ValidateFile = %+v, want version %d, migrated %v", report, tt.version, tt.migrated)
+				}
+				if data, _ := os.ReadFile(file); string(data) != tt.content {
+					t.Errorf("ValidateFile changed the file to %s", data)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateFile error = %v, want a *ValidationError", err)
+			}
+			if verr.Source != file || len(verr.Issues) != len(tt.issues) {
+				t.Fatalf("ValidateFile error = %+v, want %d issues in %s", verr, len(tt.issues), file)
+			}
+			for i, want := range tt.issues {
+				issue := verr.Issues[i]
+				if got := fmt.Sprintf("%d: %s: %s", issue.Line, issue.Key, issue.Message); !strings.HasPrefix(got, want) {
+					t.Errorf("issue %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorFormat(t *testing.T) {
+	err := &ValidationError{Source: "config.json", Issues: []Issue{
+		{Line: 3, Key: "provider", Message: "invalid value"},
+		{Message: "unexpected end of JSON input"},
+	}}
+	want := "config.json:3: provider: invalid value\nconfig.json: unexpected end of JSON input"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
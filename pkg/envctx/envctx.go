@@ -0,0 +1,221 @@
+package envctx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment describes where a command runs: the git repository,
+// the active kube context and namespace, the terraform workspace and
+// the AWS profile
+type Environment struct {
+	// RepoRoot is the root of the enclosing git repository (empty outside a repo)
+	RepoRoot string
+
+	// KubeContext is the current-context from the kubeconfig (empty if none)
+	KubeContext string
+
+	// KubeNamespace is the namespace of the current context (empty if unset)
+	KubeNamespace string
+
+	// TerraformWorkspace is the selected terraform workspace (empty outside a terraform dir)
+	TerraformWorkspace string
+
+	// AWSProfile is the profile selected through the environment (empty if none)
+	AWSProfile string
+}
+
+// Detect inspects dir and the process environment
+func Detect(dir string) *Environment {
+	context := CurrentKubeContext()
+	return &Environment{
+		RepoRoot:           FindRepoRoot(dir),
+		KubeContext:        context,
+		KubeNamespace:      KubeNamespace(context),
+		TerraformWorkspace: TerraformWorkspace(dir),
+		AWSProfile:         AWSProfile(),
+	}
+}
+
+// FindUp walks up from dir and returns the first regular file called name
+// ("" if none is found)
+func FindUp(dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// FindRepoRoot walks up from dir looking for a .git directory or file
+func FindRepoRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// KubeconfigFiles returns the kubeconfig files in effect:
+// the entries of $KUBECONFIG, or ~/.kube/config
+func KubeconfigFiles() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return splitKubeconfig(env)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// splitKubeconfig splits a $KUBECONFIG-style list of files
+func splitKubeconfig(value string) []string {
+	var files []string
+	for _, f := range filepath.SplitList(value) {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// kubeconfig holds the parts of a kubeconfig file we care about
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// readKubeconfigs parses the kubeconfig files, skipping missing or
+// invalid ones
+func readKubeconfigs(files []string) []kubeconfig {
+	var configs []kubeconfig
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		var cfg kubeconfig
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			continue
+		}
+		configs = append(configs, cfg)
+	}
+	return configs
+}
+
+// CurrentKubeContext returns the current-context of the first kubeconfig
+// file that sets one, following kubectl's merge rules
+func CurrentKubeContext() string {
+	return currentContext(KubeconfigFiles())
+}
+
+func currentContext(files []string) string {
+	for _, cfg := range readKubeconfigs(files) {
+		if cfg.CurrentContext != "" {
+			return cfg.CurrentContext
+		}
+	}
+	return ""
+}
+
+// KubeNamespace returns the namespace set on a context, taken from the
+// first kubeconfig file that defines the context
+func KubeNamespace(context string) string {
+	return contextNamespace(KubeconfigFiles(), context)
+}
+
+func contextNamespace(files []string, context string) string {
+	if context == "" {
+		return ""
+	}
+	for _, cfg := range readKubeconfigs(files) {
+		for _, ctx := range cfg.Contexts {
+			if ctx.Name == context {
+				return ctx.Context.Namespace
+			}
+		}
+	}
+	return ""
+}
+
+// awsProfileVars are the variables that select an AWS profile, by precedence
+var awsProfileVars = []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_VAULT"}
+
+// AWSProfile returns the profile the AWS CLI would use from the
+// environment: $AWS_PROFILE, $AWS_DEFAULT_PROFILE, then $AWS_VAULT
+func AWSProfile() string {
+	for _, name := range awsProfileVars {
+		if profile := os.Getenv(name); profile != "" {
+			return profile
+		}
+	}
+	return ""
+}
+
+// TerraformWorkspace returns the selected workspace for dir: $TF_WORKSPACE,
+// then .terraform/environment, then "default" if dir is an initialized
+// terraform directory. Returns empty string otherwise.
+func TerraformWorkspace(dir string) string {
+	if ws := os.Getenv("TF_WORKSPACE"); ws != "" {
+		return ws
+	}
+
+	dataDir := os.Getenv("TF_DATA_DIR")
+	if dataDir == "" {
+		dataDir = ".terraform"
+	}
+	if !filepath.IsAbs(dataDir) {
+		dataDir = filepath.Join(dir, dataDir)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dataDir, "environment")); err == nil {
+		if ws := strings.TrimSpace(string(data)); ws != "" {
+			return ws
+		}
+	}
+
+	if info, err := os.Stat(dataDir); err == nil && info.IsDir() {
+		return "default"
+	}
+	return ""
+}
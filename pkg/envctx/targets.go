@@ -0,0 +1,171 @@
+package envctx
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultProductionPatterns are the globs that mark an environment as
+// production when none are configured
+var DefaultProductionPatterns = []string{"*prod*", "*prd*"}
+
+// Target kinds
+const (
+	TargetKubeContext        = "kube context"
+	TargetKubeNamespace      = "kube namespace"
+	TargetTerraformWorkspace = "terraform workspace"
+	TargetAWSProfile         = "AWS profile"
+)
+
+// Target is one environment a command acts on
+type Target struct {
+	Kind string
+	Name string
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s %q", t.Kind, t.Name)
+}
+
+// Tools grouped by the environment they act on
+var (
+	kubeTools      = []string{"kubectl", "helm", "k9s", "stern", "flux", "kubecolor"}
+	terraformTools = []string{"terraform", "terragrunt", "tofu"}
+	awsTools       = []string{"aws", "eksctl", "sam", "cdk"}
+)
+
+// Targets returns the environments tool acts on when run with args and
+// the inline assignments of the command line (KUBECONFIG=... kubectl ...).
+// Flags on the command (--context, --kube-context, --kubeconfig,
+// -n/--namespace, --profile) take precedence over the assignments, which
+// take precedence over the detected environment.
+func (e *Environment) Targets(tool string, args, assigns []string) []Target {
+	var targets []Target
+	add := func(kind, name string) {
+		if name != "" {
+			targets = append(targets, Target{Kind: kind, Name: name})
+		}
+	}
+
+	switch {
+	case contains(kubeTools, tool):
+		context := flagValue(args, "--context", "--kube-context")
+		namespace := flagValue(args, "-n", "--namespace")
+
+		files := KubeconfigFiles()
+		kubeconfig := flagValue(args, "--kubeconfig")
+		if kubeconfig == "" {
+			kubeconfig, _ = assignValue(assigns, "KUBECONFIG")
+		}
+		switch {
+		case kubeconfig != "":
+			files = splitKubeconfig(kubeconfig)
+			if context == "" {
+				context = currentContext(files)
+			}
+		case context == "":
+			context = e.KubeContext
+		}
+
+		if namespace == "" {
+			if context == e.KubeContext && kubeconfig == "" {
+				namespace = e.KubeNamespace
+			} else {
+				namespace = contextNamespace(files, context)
+			}
+		}
+		add(TargetKubeContext, context)
+		add(TargetKubeNamespace, namespace)
+	case contains(terraformTools, tool):
+		workspace, _ := assignValue(assigns, "TF_WORKSPACE")
+		if workspace == "" {
+			workspace = e.TerraformWorkspace
+		}
+		add(TargetTerraformWorkspace, workspace)
+	case contains(awsTools, tool):
+		profile := flagValue(args, "--profile")
+		if profile == "" {
+			profile = e.AWSProfile
+			for _, name := range awsProfileVars {
+				if value, _ := assignValue(assigns, name); value != "" {
+					profile = value
+					break
+				}
+			}
+		}
+		add(TargetAWSProfile, profile)
+	}
+	return targets
+}
+
+// Production returns the targets of tool that match a production pattern
+func (e *Environment) Production(tool string, args, assigns []string, patterns []string) []Target {
+	var production []Target
+	for _, target := range e.Targets(tool, args, assigns) {
+		if IsProduction(target.Name, patterns) {
+			production = append(production, target)
+		}
+	}
+	return production
+}
+
+// IsProduction reports whether name matches any of the glob patterns,
+// ignoring case
+func IsProduction(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribeTargets joins targets for a message: kube context "prod-eu", ...
+func DescribeTargets(targets []Target) string {
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = target.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// flagValue returns the value of the first of names found in args,
+// accepting "--flag value" and "--flag=value"
+func flagValue(args []string, names ...string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			return ""
+		}
+		for _, name := range names {
+			if arg == name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if value, ok := strings.CutPrefix(arg, name+"="); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// assignValue returns the value of the last NAME=value assignment of
+// name, as the shell applies them in order; ok is false if there is none
+func assignValue(assigns []string, name string) (value string, ok bool) {
+	for _, assign := range assigns {
+		if v, found := strings.CutPrefix(assign, name+"="); found {
+			value, ok = v, true
+		}
+	}
+	return value, ok
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
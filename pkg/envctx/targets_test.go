@@ -0,0 +1,209 @@
+package envctx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testKubeconfig = `current-context: %s
+contexts:
+  - name: staging
+    context:
+      namespace: web
+  - name: prod-eu
+    context:
+      namespace: payments
+`
+
+// writeKubeconfig writes a kubeconfig selecting context and returns its path
+func writeKubeconfig(t *testing.T, dir, context string) string {
+	t.Helper()
+	file := filepath.Join(dir, context+".yaml")
+	write(t, file, fmt.Sprintf(testKubeconfig, context))
+	return file
+}
+
+func TestTargets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KUBECONFIG", writeKubeconfig(t, dir, "staging"))
+	prod := writeKubeconfig(t, dir, "prod-eu")
+
+	env := &Environment{KubeContext: "staging", KubeNamespace: "web", TerraformWorkspace: "dev", AWSProfile: "sandbox"}
+
+	kube := func(context, namespace string) []Target {
+		return []Target{{TargetKubeContext, context}, {TargetKubeNamespace, namespace}}
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    []string
+		assigns []string
+		want    []Target
+	}{
+		{"detected context", "kubectl", []string{"get", "pods"}, nil, kube("staging", "web")},
+		{"context flag", "kubectl", []string{"--context", "prod-eu", "delete", "pod", "x"}, nil, kube("prod-eu", "payments")},
+		{"context flag with value", "helm", []string{"uninstall", "x", "--kube-context=prod-eu"}, nil, kube("prod-eu", "payments")},
+		{"namespace flag", "kubectl", []string{"-n", "kube-system", "get", "pods"}, nil, kube("staging", "kube-system")},
+		{"KUBECONFIG assignment", "kubectl", []string{"delete", "pod", "x"}, []string{"KUBECONFIG=" + prod}, kube("prod-eu", "payments")},
+		{"kubeconfig flag", "kubectl", []string{"--kubeconfig", prod, "get", "pods"}, nil, kube("prod-eu", "payments")},
+		{"context flag over KUBECONFIG", "kubectl", []string{"--context=staging", "get", "pods"}, []string{"KUBECONFIG=" + prod}, kube("staging", "web")},
+		{"flags after --", "kubectl", []string{"exec", "x", "--", "sh", "--context", "prod-eu"}, nil, kube("staging", "web")},
+
+		{"detected workspace", "terraform", []string{"destroy"}, nil, []Target{{TargetTerraformWorkspace, "dev"}}},
+		{"TF_WORKSPACE assignment", "tofu", []string{"destroy"}, []string{"TF_WORKSPACE=prod"}, []Target{{TargetTerraformWorkspace, "prod"}}},
+		{"empty TF_WORKSPACE", "terraform", []string{"apply"}, []string{"TF_WORKSPACE="}, []Target{{TargetTerraformWorkspace, "dev"}}},
+
+		{"detected profile", "aws", []string{"s3", "ls"}, nil, []Target{{TargetAWSProfile, "sandbox"}}},
+		{"AWS_PROFILE assignment", "aws", []string{"s3", "ls"}, []string{"AWS_PROFILE=prod"}, []Target{{TargetAWSProfile, "prod"}}},
+		{"last assignment wins", "aws", []string{"s3", "ls"}, []string{"AWS_PROFILE=prod", "AWS_PROFILE=dev"}, []Target{{TargetAWSProfile, "dev"}}},
+		{"AWS_VAULT assignment", "eksctl", []string{"delete", "cluster"}, []string{"AWS_VAULT=prod"}, []Target{{TargetAWSProfile, "prod"}}},
+		{"profile flag over assignment", "aws", []string{"--profile", "dev", "s3", "ls"}, []string{"AWS_PROFILE=prod"}, []Target{{TargetAWSProfile, "dev"}}},
+
+		{"other tool", "ls", []string{"-la"}, []string{"AWS_PROFILE=prod"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := env.Targets(tt.tool, tt.args, tt.assigns); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Targets(%s %q, %q) = %v, want %v", tt.tool, tt.args, tt.assigns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProduction(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	env := &Environment{KubeContext: "prod-eu", AWSProfile: "dev"}
+
+	tests := []struct {
+		tool    string
+		args    []string
+		assigns []string
+		want    int // Number of production targets
+	}{
+		{"kubectl", []string{"get", "pods"}, nil, 1},
+		{"kubectl", []string{"--context", "staging", "get", "pods"}, nil, 0},
+		{"aws", []string{"s3", "ls"}, nil, 0},
+		{"aws", []string{"s3", "ls"}, []string{"AWS_PROFILE=acme-PRD"}, 1},
+	}
+
+	for _, tt := range tests {
+		if got := env.Production(tt.tool, tt.args, tt.assigns, DefaultProductionPatterns); len(got) != tt.want {
+			t.Errorf("Production(%s %q, %q) = %v, want %d targets", tt.tool, tt.args, tt.assigns, got, tt.want)
+		}
+	}
+}
+
+func TestIsProduction(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     bool
+	}{
+		{"prod-eu", DefaultProductionPatterns, true},
+		{"acme-PRD-1", DefaultProductionPatterns, true},
+		{"staging", DefaultProductionPatterns, false},
+		{"", DefaultProductionPatterns, false},
+		{"live", []string{"live", "*-live"}, true},
+		{"prod-eu", []string{"live"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsProduction(tt.name, tt.patterns); got != tt.want {
+			t.Errorf("IsProduction(%q, %q) = %v, want %v", tt.name, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestTerraformWorkspace(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+		want  string
+	}{
+		{"not terraform", func(t *testing.T, dir string) {}, ""},
+		{"initialized", func(t *testing.T, dir string) {
+			mkdir(t, filepath.Join(dir, ".terraform"))
+		}, "default"},
+		{"selected", func(t *testing.T, dir string) {
+			mkdir(t, filepath.Join(dir, ".terraform"))
+			write(t, filepath.Join(dir, ".terraform", "environment"), "prod\n")
+		}, "prod"},
+		{"TF_WORKSPACE", func(t *testing.T, dir string) {
+			t.Setenv("TF_WORKSPACE", "staging")
+		}, "staging"},
+		{"TF_DATA_DIR", func(t *testing.T, dir string) {
+			mkdir(t, filepath.Join(dir, "data"))
+			write(t, filepath.Join(dir, "data", "environment"), "qa")
+			t.Setenv("TF_DATA_DIR", "data")
+		}, "qa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TF_WORKSPACE", "")
+			t.Setenv("TF_DATA_DIR", "")
+			dir := t.TempDir()
+			tt.setup(t, dir)
+			if got := TerraformWorkspace(dir); got != tt.want {
+				t.Errorf("TerraformWorkspace = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAWSProfile(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"AWS_VAULT": "vault"}, "vault"},
+		{map[string]string{"AWS_DEFAULT_PROFILE": "default", "AWS_VAULT": "vault"}, "default"},
+		{map[string]string{"AWS_PROFILE": "prod", "AWS_DEFAULT_PROFILE": "default"}, "prod"},
+	}
+
+	for _, tt := range tests {
+		for _, name := range awsProfileVars {
+			t.Setenv(name, tt.env[name])
+		}
+		if got := AWSProfile(); got != tt.want {
+			t.Errorf("AWSProfile() with %v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestKubeContext(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.yaml")
+	write(t, empty, "contexts: []\n")
+	t.Setenv("KUBECONFIG", empty+string(os.PathListSeparator)+writeKubeconfig(t, dir, "prod-eu"))
+
+	if got := CurrentKubeContext(); got != "prod-eu" {
+		t.Errorf("CurrentKubeContext() = %q, want the first file that sets one", got)
+	}
+	if got := KubeNamespace("staging"); got != "web" {
+		t.Errorf("KubeNamespace(staging) = %q, want web", got)
+	}
+	if got := KubeNamespace("missing"); got != "" {
+		t.Errorf("KubeNamespace(missing) = %q, want none", got)
+	}
+}
+
+func mkdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func write(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -106,7 +106,7 @@ func ShowErrorMenu(command string, errorMsg string) *MenuResult {
 	menu.AddOption("1", "Get AI suggestion", "Let AI analyze and suggest a fix", "ai")
 	menu.AddOption("2", "Show manual", "Display manual page for this command", "manual")
 	menu.AddOption("3", "Skip", "Continue without fixing", "skip")
-	menu.AddOption("4", "Disable AI for session", "Turn off AI until terminal restart", "disable")
+	menu.AddOption("4", "Disable AI for session", "Turn off AI in this terminal for a while or until 'ai-helper resume'", "disable")
 	
 	return menu.Show()
 }
@@ -155,6 +155,40 @@ func ShowDangerousCommandWarning(command string, warning string) bool {
 	return ShowConfirmation("Are you sure you want to proceed?")
 }
 
+// Risk acknowledgement answers
+const (
+	AckDeclined = ""
+	AckOnce     = "once"
+	AckAlways   = "always"
+)
+
+// AcknowledgeRisk shows the security findings for a suggestion that has
+// just been printed and asks whether to keep it: once, always for this
+// project, or not at all
+func AcknowledgeRisk(findings string) string {
+	fmt.Println()
+	fmt.Println(ui.Colorize(ui.RedBold, "⚠️  DANGEROUS COMMAND DETECTED"))
+	fmt.Println(ui.Colorize(ui.Red, findings))
+	fmt.Println()
+	fmt.Printf("%s %s ",
+		ui.Colorize(ui.Yellow, "Acknowledge the risk?"),
+		ui.Colorize(ui.Dim, "(y = this time, a = always in this project, n):"))
+
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return AckDeclined
+	}
+
+	switch strings.TrimSpace(strings.ToLower(input)) {
+	case "y", "yes":
+		return AckOnce
+	case "a", "always":
+		return AckAlways
+	}
+	return AckDeclined
+}
+
 // Prompt displays a prompt and returns user input
 func Prompt(message string) string {
 	fmt.Print(ui.Colorize(ui.Cyan, message+" "))
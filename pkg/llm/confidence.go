@@ -2,6 +2,8 @@ package llm
 
 import (
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
 )
 
 // ConfidenceLevel represents the confidence in an AI suggestion.
@@ -14,20 +16,28 @@ const (
 )
 
 // CalculateConfidence determines confidence level based on multiple factors.
-func CalculateConfidence(response *Response, validationError error, commandComplexity int) (ConfidenceLevel, int) {
+func CalculateConfidence(response *Response, findings []validators.Finding, commandComplexity int) (ConfidenceLevel, int) {
 	score := 100 // Start with 100%
 
 	// Factor 1: Validation result (40% weight)
-	if validationError != nil {
-		errorMsg := validationError.Error()
-		if strings.Contains(errorMsg, "🚨 BLOCKED") {
-			score -= 50 // Critical validation failure
-		} else if strings.Contains(errorMsg, "⚠️") {
-			score -= 20 // Warning
-		} else {
-			score -= 30 // General validation failure
+	// The worst finding sets the penalty; every further one costs a little
+	worst := validators.Severity("")
+	for _, finding := range findings {
+		if finding.Severity.Rank() > worst.Rank() {
+			worst = finding.Severity
 		}
 	}
+	switch worst {
+	case validators.SeverityBlock:
+		score -= 50 // Critical validation failure
+	case validators.SeverityWarn:
+		score -= 20 // Warning
+	case validators.SeverityInfo:
+		score -= 5
+	}
+	if len(findings) > 1 {
+		score -= 5 * (len(findings) - 1)
+	}
 
 	// Factor 2: Command structure quality (30% weight)
 	if response.Suggestion == "" {
@@ -54,6 +54,7 @@ CRITICAL RULES:
 1. DO NOT output "Thinking..." or any reasoning process
 2. START IMMEDIATELY with ✓ followed by the command
 3. NO verbose reasoning, NO process explanation
+4. NEVER put passwords, tokens or keys in the command; use "$VARIABLES" or files
 
 Query: %s
 Context: %s
@@ -74,6 +75,7 @@ CRITICAL RULES:
 2. DO NOT start with "Okay," "Let me," "Wait," or any explanation
 3. START IMMEDIATELY with ✓ followed by the corrected command
 4. NO thinking blocks, NO verbose reasoning, NO process explanation
+5. NEVER put passwords, tokens or keys in the command; use "$VARIABLES" or files
 
 Command: %s
 Error: %s
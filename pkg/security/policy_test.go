@@ -0,0 +1,64 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// policyFile writes a policy file and returns its path
+func policyFile(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), PolicyFileName)
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadPolicyProjectOnlyTightens(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		err      string // Part of the expected error, "" if it loads
+	}{
+		{"raise severity", "rules:\n  - id: chown-recursive\n    severity: CRITICAL\n", ""},
+		{"raise action", "rules:\n  - id: secret-literal\n    action: block\n", ""},
+		{"same values", "rules:\n  - id: rm-rf-root\n    severity: CRITICAL\n    action: block\n", ""},
+		{"new rule", "rules:\n  - id: no-force-push\n    severity: HIGH\n    action: confirm\n    commands: [git]\n    flags: [force]\n", ""},
+		{"disable", "rules:\n  - id: rm-rf-root\n    disabled: true\n", "cannot disable it"},
+		{"lower severity", "rules:\n  - id: rm-rf-root\n    severity: LOW\n", "cannot lower its severity"},
+		{"weaker action", "rules:\n  - id: rm-rf-root\n    action: log\n", "cannot weaken its action"},
+		{"change matcher", "rules:\n  - id: rm-rf-root\n    args: ['^/nothing$']\n", "cannot change what it matches"},
+		{"change scope", "rules:\n  - id: download-pipe-shell\n    scope: line\n", "cannot change what it matches"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPolicy("", policyFile(t, "version: 1\n"+tt.override))
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("LoadPolicy: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("LoadPolicy error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyUserMayWeaken(t *testing.T) {
+	user := policyFile(t, "version: 1\nrules:\n  - id: chown-recursive\n    disabled: true\n  - id: rm-rf-root\n    action: confirm\n")
+	policy, err := LoadPolicy(user, "")
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+
+	result, err := NewScanner(policy).Scan("chown -R me /srv && rm -rf /")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := ruleIDs(result); len(ids) != 1 || ids[0] != "rm-rf-root" || result.Findings[0].Action != ActionConfirm {
+		t.Errorf("findings %q, want only rm-rf-root as confirm", ids)
+	}
+}
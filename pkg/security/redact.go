@@ -0,0 +1,101 @@
+package security
+
+import (
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// redactionRule replaces matches of a pattern with a placeholder
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+
+	// kind names the secret the pattern finds and value is the submatch
+	// holding it (0 for the whole match)
+	kind  string
+	value int
+}
+
+// redactionRules are applied in order, most specific first. DetectSecrets
+// uses the same patterns.
+var redactionRules = []redactionRule{
+	// Private key blocks
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), "<redacted:private-key>", SecretPrivateKey, 0},
+	// AWS access key IDs
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), "<redacted:aws-key>", SecretAWSKey, 0},
+	// GitHub / GitLab / Slack tokens
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), "<redacted:token>", SecretToken, 0},
+	{regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`), "<redacted:token>", SecretToken, 0},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9\-]{10,}\b`), "<redacted:token>", SecretToken, 0},
+	// JSON Web Tokens
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\b`), "<redacted:jwt>", SecretJWT, 0},
+	// Bearer / basic auth headers
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+([A-Za-z0-9\-._~+/]{8,}=*)`), "$1 <redacted>", SecretAuthHeader, 2},
+	// Credentials embedded in URLs
+	{regexp.MustCompile(`://[^/\s:@]+:([^/\s@]+)@`), "://<redacted>@", SecretURLCredentials, 1},
+	// Kubernetes secret literals (any key: the value is the secret)
+	{regexp.MustCompile(`(--from-literal=)([^=\s"']+)=("[^"]*"|'[^']*'|\S+)`), "$1$2=<redacted>", SecretLiteral, 3},
+	// Secret flags followed by their value: --password hunter2
+	{regexp.MustCompile(`(?i)(--(?:[a-z0-9]+-)*(?:password|passwd|secret|token|api-key|access-key)\b)(\s+)("[^"]*"|'[^']*'|[^\s\-<>|&;]\S*)`), "$1$2<redacted>", SecretFlag, 3},
+	// key=value style secrets
+	{regexp.MustCompile(`(?i)\b([A-Z0-9_\-]*(?:password|passwd|secret|token|api[_\-]?key|access[_\-]?key)[A-Z0-9_\-]*)(\s*[=:]\s*)("[^"]*"|'[^']*'|\S+)`), "$1$2<redacted>", SecretAssignment, 3},
+}
+
+// detailRule replaces personal details, which are not secrets, with a
+// placeholder; keep spares matches that only look like one
+type detailRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+	keep        func(match string) bool
+}
+
+// detailRules are applied after redactionRules
+var detailRules = []detailRule{
+	// Email addresses, but not SSH remotes (git@github.com:org/repo)
+	{regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b:?`), "<redacted:email>", isSSHRemote},
+	// IPv4 addresses, but not the unspecified and loopback addresses
+	// commands bind to (0.0.0.0:8080, 127.0.0.1)
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), "<redacted:ip>", isLocalAddress},
+}
+
+// isSSHRemote reports whether an email-like match is an SSH remote: a host
+// followed by ":path", or the git user of a hosting service
+func isSSHRemote(match string) bool {
+	return strings.HasSuffix(match, ":") || strings.HasPrefix(match, "git@")
+}
+
+// isLocalAddress reports whether an IPv4-like match is an address that says
+// nothing about the user's network, or not an address at all (999.1.1.1)
+func isLocalAddress(match string) bool {
+	ip := net.ParseIP(match)
+	return ip == nil || ip.IsUnspecified() || ip.IsLoopback()
+}
+
+// Redact masks secrets and user-specific details (home directory, emails,
+// IP addresses) so text can be shared outside the local machine
+func Redact(text string) string {
+	if text == "" {
+		return text
+	}
+
+	for _, rule := range redactionRules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	for _, rule := range detailRules {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.keep(match) {
+				return match
+			}
+			return rule.replacement
+		})
+	}
+
+	// Replace the user's home directory with ~
+	if home, err := os.UserHomeDir(); err == nil && home != "" && home != "/" {
+		text = strings.ReplaceAll(text, home, "~")
+	}
+
+	return text
+}
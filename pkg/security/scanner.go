@@ -2,92 +2,321 @@ package security
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
+	"github.com/amaslovskyi/ai-helper/pkg/shell"
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
 )
 
-// DangerousPattern represents a dangerous command pattern
-type DangerousPattern struct {
-	Pattern     string
-	Description string
-	Severity    string
+// Scanner scans commands against a security policy
+type Scanner struct {
+	policy *Policy
+
+	// Optional environment used to escalate findings against production
+	env        *envctx.Environment
+	production []string
 }
 
-// Scanner scans commands for dangerous patterns
-type Scanner struct {
-	patterns []DangerousPattern
-}
-
-// NewScanner creates a new security scanner
-func NewScanner() *Scanner {
-	return &Scanner{
-		patterns: []DangerousPattern{
-			{"rm -rf /", "recursive deletion of root", "CRITICAL"},
-			{"rm -rf *", "recursive deletion of all files", "CRITICAL"},
-			{"rm -rf ~", "recursive deletion of home directory", "CRITICAL"},
-			{"rm -rf $HOME", "recursive deletion of home directory", "CRITICAL"},
-			{"> /dev/sda", "writing to disk device", "CRITICAL"},
-			{"dd if=/dev/zero", "disk overwrite", "CRITICAL"},
-			{"mkfs.", "filesystem formatting", "CRITICAL"},
-			{"DROP DATABASE", "database deletion", "CRITICAL"},
-			{"DROP TABLE", "table deletion", "CRITICAL"},
-			{"TRUNCATE", "data truncation", "HIGH"},
-			{"chmod -R 777", "insecure permissions", "HIGH"},
-			{"chmod 777", "insecure permissions", "MEDIUM"},
-			{"chown -R", "ownership change (use with caution)", "MEDIUM"},
-			{":(){ :|:& };:", "fork bomb", "CRITICAL"},
-			{"--no-preserve-root", "disables root protection", "CRITICAL"},
-			{"mv .* /dev/null", "move to null device", "HIGH"},
-			{"kubectl delete", "kubernetes resource deletion", "MEDIUM"},
-			{"terraform destroy", "infrastructure destruction", "MEDIUM"},
-		},
-	}
-}
-
-// Scan checks if a command contains dangerous patterns
-func (s *Scanner) Scan(command string) (*DangerResult, error) {
-	cmdLower := strings.ToLower(command)
-
-	for _, pattern := range s.patterns {
-		if strings.Contains(cmdLower, strings.ToLower(pattern.Pattern)) {
-			return &DangerResult{
-				IsDangerous: true,
-				Pattern:     pattern.Pattern,
-				Description: pattern.Description,
-				Severity:    pattern.Severity,
-				Command:     command,
-			}, nil
-		}
-	}
-
-	return &DangerResult{IsDangerous: false}, nil
-}
-
-// DangerResult represents the result of a security scan
-type DangerResult struct {
-	IsDangerous bool
-	Pattern     string
-	Description string
-	Severity    string
-	Command     string
-}
-
-// Warning returns a formatted warning message
-func (r *DangerResult) Warning() string {
-	if !r.IsDangerous {
+// NewScanner creates a scanner for a policy (nil uses the built-in policy)
+func NewScanner(policy *Policy) *Scanner {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	return &Scanner{policy: policy}
+}
+
+// Policy returns the policy the scanner applies
+func (s *Scanner) Policy() *Policy {
+	return s.policy
+}
+
+// SetEnvironment enables escalation: findings for commands that target a
+// kube context/namespace, terraform workspace or AWS profile matching one
+// of the production patterns are raised one severity and action level
+func (s *Scanner) SetEnvironment(env *envctx.Environment, productionPatterns []string) {
+	s.env = env
+	s.production = productionPatterns
+}
+
+// Finding is one rule that matched a command
+type Finding struct {
+	Rule  *Rule
+	Match string // The text that triggered the rule
+
+	// Command is the simple command that matched (nil for line-scoped rules)
+	Command *shell.Command
+
+	// Severity and Action start as the rule's and are raised for production
+	Severity string
+	Action   Action
+
+	// Production lists the production environments the command targets
+	Production []envctx.Target
+
+	// Allowed is the allowlist entry that downgraded the finding, if any
+	Allowed *Allow
+
+	// Secrets are the secrets a secrets rule found, with alternatives
+	Secrets []Secret
+}
+
+// ScanResult holds every finding for a command, most severe first
+type ScanResult struct {
+	Command  string
+	Findings []Finding
+}
+
+// Scan parses a command line and checks every simple command it runs
+// against every rule, reporting all matches
+func (s *Scanner) Scan(command string) (*ScanResult, error) {
+	result := &ScanResult{Command: command}
+	commands := parseCommands(command)
+	words := commandWords(command)
+	secrets := DetectSecrets(command)
+
+	for _, rule := range s.policy.Rules {
+		if rule.Disabled {
+			continue
+		}
+
+		if rule.Scope == ScopeLine {
+			if match := rule.matchLine(command, words, secrets); match != "" {
+				finding := Finding{Rule: rule, Match: match, Severity: rule.Severity, Action: rule.Action}
+				if rule.Secrets {
+					finding.Secrets = secrets
+				}
+				result.Findings = append(result.Findings, finding)
+			}
+			continue
+		}
+
+		// Every matching command is escalated on its own; the rule reports
+		// the most severe of them
+		var worst *Finding
+		for _, cmd := range commands {
+			if !rule.matchCommand(cmd) {
+				continue
+			}
+			finding := Finding{Rule: rule, Match: cmd.String(), Command: cmd, Severity: rule.Severity, Action: rule.Action}
+			s.escalate(&finding)
+			if worst == nil || severityRank[finding.Severity] > severityRank[worst.Severity] {
+				worst = &finding
+			}
+		}
+		if worst != nil {
+			result.Findings = append(result.Findings, *worst)
+		}
+	}
+
+	s.allow(result)
+
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return severityRank[result.Findings[i].Severity] > severityRank[result.Findings[j].Severity]
+	})
+
+	return result, nil
+}
+
+// escalate raises a finding one level when its command targets production
+func (s *Scanner) escalate(finding *Finding) {
+	if s.env == nil {
+		return
+	}
+
+	finding.Production = s.env.Production(finding.Command.Name, finding.Command.Args, finding.Command.Assigns, s.production)
+	if len(finding.Production) == 0 {
+		return
+	}
+
+	finding.Severity = raise(severityRank, finding.Severity)
+	finding.Action = raise(actionRank, finding.Action)
+}
+
+// allow downgrades findings covered by the policy's allowlist. CRITICAL
+// findings that block are never downgraded.
+func (s *Scanner) allow(result *ScanResult) {
+	for i := range result.Findings {
+		finding := &result.Findings[i]
+		if finding.Severity == "CRITICAL" && finding.Action == ActionBlock {
+			continue
+		}
+
+		for _, allow := range s.policy.Allow {
+			if !allow.matches(result.Command, *finding) {
+				continue
+			}
+			finding.Allowed = allow
+			if actionRank[allow.Action] < actionRank[finding.Action] {
+				finding.Action = allow.Action
+			}
+			break
+		}
+	}
+}
+
+// raise returns the next value up in a rank table (unchanged at the top)
+func raise[T comparable](ranks map[T]int, value T) T {
+	next := value
+	for candidate, rank := range ranks {
+		if rank == ranks[value]+1 {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// parseCommands returns the simple commands of a line with aliases such
+// as k or tf expanded. A line the shell parser rejects is treated as a
+// single command.
+func parseCommands(command string) []*shell.Command {
+	commands, err := shell.Parse(command)
+	if err != nil {
+		words := commandWords(command)
+		if len(words) == 0 {
+			return nil
+		}
+		commands = []*shell.Command{{Name: words[0], Args: words[1:]}}
+	}
+
+	aliases := validators.NewAliasMapper()
+	for _, cmd := range commands {
+		if aliases.IsAlias(cmd.Name) {
+			expanded := strings.Fields(aliases.ResolveAlias(cmd.Name))
+			cmd.Name = expanded[0]
+			cmd.Args = append(expanded[1:], cmd.Args...)
+		}
+	}
+	return commands
+}
+
+// commandWords splits a command into words, dropping surrounding quotes
+func commandWords(command string) []string {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		fields[i] = strings.Trim(field, `"'`)
+	}
+	return fields
+}
+
+// IsDangerous reports whether any rule matched
+func (r *ScanResult) IsDangerous() bool {
+	return len(r.Findings) > 0
+}
+
+// Action returns the most restrictive action across all findings after
+// applying the danger policy ("strict", "standard" or "permissive")
+func (r *ScanResult) Action(dangerPolicy string) Action {
+	var strongest Action
+	for _, finding := range r.Findings {
+		action := finding.action(dangerPolicy)
+		if actionRank[action] > actionRank[strongest] {
+			strongest = action
+		}
+	}
+	return strongest
+}
+
+// Visible returns the findings worth showing (everything except log-only)
+// under the danger policy
+func (r *ScanResult) Visible(dangerPolicy string) []Finding {
+	var visible []Finding
+	for _, finding := range r.Findings {
+		if finding.action(dangerPolicy) != ActionLog {
+			visible = append(visible, finding)
+		}
+	}
+	return visible
+}
+
+// action is the finding's action under the danger policy. An allowlisted
+// finding keeps the project's decision, so strict does not raise it again.
+func (f Finding) action(dangerPolicy string) Action {
+	if f.Allowed != nil {
+		return f.Action
+	}
+	return ApplyDangerPolicy(f.Action, f.Severity, dangerPolicy)
+}
+
+// Allowed returns the ids of the rules the allowlist downgraded
+func (r *ScanResult) Allowed() []string {
+	var ids []string
+	for _, finding := range r.Findings {
+		if finding.Allowed != nil {
+			ids = append(ids, finding.Rule.ID)
+		}
+	}
+	return ids
+}
+
+// Summary lists findings as "SEVERITY description: rationale" lines
+// with their safer alternatives
+func Summary(findings []Finding) string {
+	var b strings.Builder
+	for i, finding := range findings {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		rule := finding.Rule
+		fmt.Fprintf(&b, "[%s] %s (%s)", finding.Severity, rule.Description, rule.ID)
+		if len(finding.Production) > 0 {
+			fmt.Fprintf(&b, "\n    Target: PRODUCTION %s", envctx.DescribeTargets(finding.Production))
+		}
+		if rule.Rationale != "" {
+			fmt.Fprintf(&b, "\n    Why: %s", rule.Rationale)
+		}
+		for _, secret := range finding.Secrets {
+			fmt.Fprintf(&b, "\n    Secret: %s (%s)\n      Instead: %s", secret.Text, secret.Kind, secret.Alternative)
+		}
+		if rule.Alternative != "" && len(finding.Secrets) == 0 {
+			fmt.Fprintf(&b, "\n    Safer: %s", rule.Alternative)
+		}
+		if finding.Allowed != nil {
+			fmt.Fprintf(&b, "\n    Note: %s", finding.Allowed.Describe())
+		}
+	}
+	return b.String()
+}
+
+// Production returns the distinct production environments targeted by
+// the findings
+func (r *ScanResult) Production() []envctx.Target {
+	var targets []envctx.Target
+	seen := make(map[envctx.Target]bool)
+	for _, finding := range r.Findings {
+		for _, target := range finding.Production {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// Warning returns a formatted warning message for blocked suggestions
+func (r *ScanResult) Warning() string {
+	if !r.IsDangerous() {
 		return ""
 	}
 
-	return fmt.Sprintf(`🚨 DANGER: Command contains potentially destructive pattern: %s
-⚠️  This could cause data loss or system damage! (%s)
-📋 Command: %s
+	target := ""
+	if production := r.Production(); len(production) > 0 {
+		target = fmt.Sprintf("\n🏭 Target: PRODUCTION %s", envctx.DescribeTargets(production))
+	}
+
+	return fmt.Sprintf(`🚨 DANGER: Command matches %d security rule(s):
+%s
+📋 Command: %s%s
 
 If you're ABSOLUTELY SURE this is safe, you can:
   1. Review the command carefully
   2. Test in a safe environment first
   3. Execute manually after verification`,
-		r.Pattern,
-		r.Description,
+		len(r.Findings),
+		Summary(r.Findings),
 		r.Command,
+		target,
 	)
 }
-
@@ -0,0 +1,147 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Secret kinds
+const (
+	SecretPrivateKey     = "private key"
+	SecretAWSKey         = "AWS access key"
+	SecretToken          = "API token"
+	SecretJWT            = "JSON web token"
+	SecretAuthHeader     = "authorization header"
+	SecretURLCredentials = "credentials in URL"
+	SecretLiteral        = "secret literal"
+	SecretFlag           = "secret flag"
+	SecretAssignment     = "secret assignment"
+)
+
+// Secret is a secret or secret-shaped literal found in a command
+type Secret struct {
+	Kind string
+	Name string // Variable, flag or key the secret is assigned to, if any
+	Text string // The match with the secret masked
+
+	// Alternative keeps the value out of the command line
+	Alternative string
+}
+
+// placeholder matches values that stand for a secret instead of being one:
+// variable and command substitutions, <your-token>, {{ .token }}, xxx, ***
+var placeholder = regexp.MustCompile(`(?i)^(\$|<.*>$|\{\{|%|@|x+$|\*+$|\.+$|your[-_]|changeme$|redacted$)`)
+
+// nonSecretSuffixes end names that merely refer to a secret (--secret-id,
+// TOKEN_FILE, password_path)
+var nonSecretSuffixes = []string{"id", "name", "file", "path", "arn", "ref", "stdin", "env", "dir", "type", "ttl", "version"}
+
+// DetectSecrets returns the secrets in text, using the redaction patterns.
+// Values that are references or placeholders ($TOKEN, <password>) are not
+// secrets.
+func DetectSecrets(text string) []Secret {
+	var secrets []Secret
+	var covered [][2]int
+
+	for _, rule := range redactionRules {
+		for _, loc := range rule.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*rule.value], loc[2*rule.value+1]
+			if start < 0 || overlaps(covered, start, end) {
+				continue
+			}
+
+			value := strings.Trim(text[start:end], `"'`)
+			if value == "" || placeholder.MatchString(value) {
+				continue
+			}
+
+			match := text[loc[0]:loc[1]]
+			secret := Secret{
+				Kind: rule.kind,
+				Text: rule.pattern.ReplaceAllString(match, rule.replacement),
+			}
+			switch rule.kind {
+			case SecretLiteral:
+				secret.Name = text[loc[4]:loc[5]]
+			case SecretFlag:
+				secret.Name = text[loc[2]:loc[3]]
+			case SecretAssignment:
+				secret.Name = text[loc[2]:loc[3]]
+				if strings.HasSuffix(text[:loc[0]], "--") {
+					secret.Kind = SecretFlag
+					secret.Name = "--" + secret.Name
+					secret.Text = "--" + secret.Text
+				}
+				if refersToSecret(secret.Name) {
+					continue
+				}
+			}
+			secret.Alternative = alternative(secret)
+
+			covered = append(covered, [2]int{start, end})
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// ContainsSecret reports whether text has any secret in it
+func ContainsSecret(text string) bool {
+	return len(DetectSecrets(text)) > 0
+}
+
+// refersToSecret reports whether a name identifies a secret rather than
+// holding one, e.g. --secret-id or TOKEN_FILE
+func refersToSecret(name string) bool {
+	parts := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	if len(parts) == 0 {
+		return false
+	}
+	return contains(nonSecretSuffixes, parts[len(parts)-1])
+}
+
+// alternative suggests how to pass a secret without writing it out
+func alternative(secret Secret) string {
+	switch secret.Kind {
+	case SecretLiteral:
+		return fmt.Sprintf("Use --from-file=%s=./%s or --from-env-file=.env (keep the file out of git)", secret.Name, secret.Name)
+	case SecretFlag:
+		return fmt.Sprintf(`Pass it from a variable, %s "$%s", or use the tool's stdin or file option (e.g. --password-stdin)`,
+			secret.Name, envName(secret.Name))
+	case SecretAssignment:
+		name := envName(secret.Name)
+		return fmt.Sprintf(`Read it without echoing: read -rs %s && export %s, or load it from a file: %s="$(< ~/.secrets/%s)"`,
+			name, name, name, strings.ToLower(name))
+	case SecretAuthHeader:
+		return `Put the token in a variable: -H "Authorization: Bearer $TOKEN"`
+	case SecretURLCredentials:
+		return "Drop user:password@ from the URL; use ~/.netrc or a credential helper"
+	case SecretPrivateKey:
+		return "Reference the key file (e.g. -i ~/.ssh/id_ed25519) instead of pasting it"
+	}
+	return `Reference it from the environment ("$TOKEN") or a file instead of the literal`
+}
+
+// envName turns a flag or key into an environment variable name:
+// --api-key → API_KEY
+func envName(name string) string {
+	name = strings.TrimLeft(name, "-")
+	name = strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if name == "" {
+		return "SECRET"
+	}
+	return name
+}
+
+// overlaps reports whether [start, end) intersects a covered span
+func overlaps(covered [][2]int, start, end int) bool {
+	for _, span := range covered {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// EnvSessionID names the variable the shell integration exports so every
+// ai-helper call from one terminal shares a session
+const EnvSessionID = "AI_HELPER_SESSION_ID"
+
+// State is the persisted state of one shell session
+type State struct {
+	ID string `json:"id"`
+
+	// PID is the shell process; the state is discarded once it exits (0 = unknown)
+	PID int `json:"pid,omitempty"`
+
+	// Paused disables AI for the session
+	Paused bool `json:"paused"`
+
+	// Until is when the pause expires (unix seconds, 0 = until resume or
+	// the terminal closes)
+	Until int64 `json:"until,omitempty"`
+
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// Active reports whether the session is paused at the given time
+func (s *State) Active(now time.Time) bool {
+	return s.Paused && (s.Until == 0 || now.Unix() < s.Until)
+}
+
+// Store keeps session states as <dir>/<id>.json
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store in dir (usually ~/.ai/sessions)
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// CurrentID identifies the calling terminal: $AI_HELPER_SESSION_ID when
+// the shell integration set it, otherwise the parent (shell) process ID
+func CurrentID() string {
+	if id := os.Getenv(EnvSessionID); id != "" {
+		return id
+	}
+	return fmt.Sprintf("ppid-%d", os.Getppid())
+}
+
+// Load returns the state of a session (an empty state if none is stored)
+func (s *Store) Load(id string) (*State, error) {
+	data, err := os.ReadFile(s.file(id))
+	if os.IsNotExist(err) {
+		return &State{ID: id, PID: pidOf(id)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		// A corrupt state must not keep AI disabled
+		return &State{ID: id, PID: pidOf(id)}, nil
+	}
+	return &state, nil
+}
+
+// ParseDuration parses the length of a pause: any positive Go duration
+// (45s, 30m, 2h), or "" for a pause until resume (0)
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 30m, 2h)", s)
+	}
+	return d, nil
+}
+
+// Pause disables AI for a session, for d or indefinitely when d is 0
+func (s *Store) Pause(id string, d time.Duration) (*State, error) {
+	now := time.Now()
+	state := &State{
+		ID:        id,
+		PID:       pidOf(id),
+		Paused:    true,
+		UpdatedAt: now.Unix(),
+	}
+	if d > 0 {
+		state.Until = now.Add(d).Unix()
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.file(id), data, 0644); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Resume re-enables AI for a session
+func (s *Store) Resume(id string) error {
+	if err := os.Remove(s.file(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RecordSuggestion remembers the last suggestion shown in a session so the
+// shell integration can report when it is executed
+func (s *Store) RecordSuggestion(id, suggestion string) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	return os.WriteFile(s.suggestionFile(id), []byte(suggestion), 0600)
+}
+
+// Suggestion returns the last suggestion of a session and when it was
+// recorded ("" if there is none)
+func (s *Store) Suggestion(id string) (string, time.Time, error) {
+	file := s.suggestionFile(id)
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return string(data), info.ModTime(), nil
+}
+
+// ForgetSuggestion drops the last suggestion of a session
+func (s *Store) ForgetSuggestion(id string) error {
+	if err := os.Remove(s.suggestionFile(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Prune removes expired pauses and sessions whose shell has exited
+func (s *Store) Prune() error {
+	suggestions, err := filepath.Glob(filepath.Join(s.dir, "*.suggested"))
+	if err != nil {
+		return err
+	}
+	for _, file := range suggestions {
+		if pid := pidOf(strings.TrimSuffix(filepath.Base(file), ".suggested")); pid > 0 && !processAlive(pid) {
+			os.Remove(file)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		state, err := s.Load(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		if !state.Active(now) || (state.PID > 0 && !processAlive(state.PID)) {
+			os.Remove(file)
+		}
+	}
+	return nil
+}
+
+// file returns the path of a session's state file
+func (s *Store) file(id string) string {
+	return filepath.Join(s.dir, safeID(id)+".json")
+}
+
+// suggestionFile returns the path of a session's last suggestion
+func (s *Store) suggestionFile(id string) string {
+	return filepath.Join(s.dir, safeID(id)+".suggested")
+}
+
+// safeID keeps an ID (which comes from the environment) to a single path element
+func safeID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, id)
+}
+
+// pidOf extracts the shell PID from IDs like "zsh-1234" or "ppid-1234"
+func pidOf(id string) int {
+	prefix, rest, ok := strings.Cut(id, "-")
+	if !ok || (prefix != "zsh" && prefix != "bash" && prefix != "ppid") {
+		return 0
+	}
+	pid, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// processAlive reports whether a process exists
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
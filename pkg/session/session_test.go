@@ -0,0 +1,228 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// deadPID returns the PID of a process that has exited
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestPidOf(t *testing.T) {
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"zsh-1234", 1234},
+		{"bash-42", 42},
+		{"ppid-7", 7},
+		{"fish-1234", 0},
+		{"zsh-abc", 0},
+		{"zsh", 0},
+		{"tmux-pane-3", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := pidOf(tt.id); got != tt.want {
+			t.Errorf("pidOf(%q) = %d, want %d", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name  string
+		state State
+		want  bool
+	}{
+		{"not paused", State{}, false},
+		{"until resume", State{Paused: true}, true},
+		{"before expiry", State{Paused: true, Until: 1001}, true},
+		{"at expiry", State{Paused: true, Until: 1000}, false},
+		{"expired", State{Paused: true, Until: 999}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.Active(now); got != tt.want {
+			t.Errorf("%s: Active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, true},
+		{"45s", 45 * time.Second, true},
+		{"30m", 30 * time.Minute, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"0s", 0, false},
+		{"-5m", 0, false},
+		{"soon", 0, false},
+		{"30", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		duration time.Duration
+		pid      int
+	}{
+		{"until resume", "zsh-1234", 0, 1234},
+		{"for a while", "bash-99", 30 * time.Minute, 99},
+		{"unknown shell", "custom/id", time.Hour, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(filepath.Join(t.TempDir(), "sessions"))
+			before := time.Now()
+
+			if _, err := store.Pause(tt.id, tt.duration); err != nil {
+				t.Fatal(err)
+			}
+			state, err := store.Load(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !state.Active(time.Now()) || state.PID != tt.pid {
+				t.Errorf("after Pause: %+v, want active with PID %d", state, tt.pid)
+			}
+			if tt.duration == 0 && state.Until != 0 {
+				t.Errorf("Until = %d, want 0 for a pause until resume", state.Until)
+			}
+			if tt.duration > 0 {
+				if expiry := before.Add(tt.duration).Unix(); state.Until < expiry || state.Until > expiry+1 {
+					t.Errorf("Until = %d, want about %d", state.Until, expiry)
+				}
+				if state.Active(before.Add(tt.duration + time.Second)) {
+					t.Error("pause still active after it expired")
+				}
+			}
+
+			if err := store.Resume(tt.id); err != nil {
+				t.Fatal(err)
+			}
+			if state, _ := store.Load(tt.id); state.Active(time.Now()) {
+				t.Error("still paused after Resume")
+			}
+			if err := store.Resume(tt.id); err != nil {
+				t.Errorf("resuming twice: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "zsh-5.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := NewStore(dir).Load("zsh-5")
+	if err != nil || state.Active(time.Now()) || state.PID != 5 {
+		t.Errorf("Load(corrupt) = %+v, %v; want an inactive state", state, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	alive := fmt.Sprintf("ppid-%d", os.Getpid())
+	dead := fmt.Sprintf("zsh-%d", deadPID(t))
+	now := time.Now().Unix()
+
+	tests := []struct {
+		id    string
+		state State
+		kept  bool
+	}{
+		{alive, State{Paused: true}, true},
+		{"custom", State{Paused: true, Until: now + 600}, true},
+		{"expired", State{Paused: true, Until: now - 1}, false},
+		{"resumed", State{}, false},
+		{dead, State{Paused: true}, false},
+	}
+
+	dir := t.TempDir()
+	store := NewStore(dir)
+	for _, tt := range tests {
+		tt.state.ID, tt.state.PID = tt.id, pidOf(tt.id)
+		data, _ := json.Marshal(tt.state)
+		if err := os.WriteFile(store.file(tt.id), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{alive, dead} {
+		if err := store.RecordSuggestion(id, "kubectl get pods"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		_, err := os.Stat(store.file(tt.id))
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s: kept = %v, want %v", tt.id, kept, tt.kept)
+		}
+	}
+	if suggestion, _, _ := store.Suggestion(alive); suggestion == "" {
+		t.Error("Prune removed the suggestion of a live shell")
+	}
+	if suggestion, _, _ := store.Suggestion(dead); suggestion != "" {
+		t.Error("Prune kept the suggestion of an exited shell")
+	}
+}
+
+func TestSuggestion(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "sessions"))
+	if suggestion, _, err := store.Suggestion("zsh-1"); suggestion != "" || err != nil {
+		t.Errorf("Suggestion before any = %q, %v", suggestion, err)
+	}
+	if err := store.RecordSuggestion("zsh-1", "ls -la"); err != nil {
+		t.Fatal(err)
+	}
+	if suggestion, at, _ := store.Suggestion("zsh-1"); suggestion != "ls -la" || at.IsZero() {
+		t.Errorf("Suggestion = %q at %v", suggestion, at)
+	}
+	if err := store.ForgetSuggestion("zsh-1"); err != nil {
+		t.Fatal(err)
+	}
+	if suggestion, _, _ := store.Suggestion("zsh-1"); suggestion != "" {
+		t.Errorf("Suggestion after Forget = %q", suggestion)
+	}
+}
+
+func TestSafeID(t *testing.T) {
+	store := NewStore("/sessions")
+	if got := store.file("../../etc/passwd"); filepath.Dir(got) != "/sessions" {
+		t.Errorf("file escaped the store: %s", got)
+	}
+}
@@ -0,0 +1,576 @@
+package shell
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxDepth bounds how many nested scripts (bash -c, eval, watch) are parsed
+const maxDepth = 4
+
+// Redirect is one redirection of a simple command
+type Redirect struct {
+	Op     string // ">", ">>", "<", "&>", ...
+	Target string
+}
+
+// Command is one simple command as it would be executed, after wrappers
+// such as sudo, env, xargs or bash -c have been peeled off
+type Command struct {
+	// Name is the executable's base name ("rm" for /bin/rm)
+	Name string
+
+	// Args are the words after the name, with quotes removed. Expansions
+	// that cannot be resolved statically are kept as written ("$HOME").
+	// Arguments that xargs or find -exec fill in are included when the
+	// input is known ("echo / | xargs rm -rf" gives "rm -rf /").
+	Args []string
+
+	// Assigns are NAME=value environment assignments for the command
+	Assigns []string
+
+	// Wrappers lists the commands that launched this one, outermost first
+	Wrappers []string
+
+	Redirects []Redirect
+
+	// Input is the here-document or here-string fed to the command
+	Input string
+
+	// Stdin is the command piped into this one (nil if none)
+	Stdin *Command
+}
+
+// Words returns the name followed by the arguments
+func (c *Command) Words() []string {
+	return append([]string{c.Name}, c.Args...)
+}
+
+// String returns the command and its redirections as a single line
+func (c *Command) String() string {
+	s := strings.Join(c.Words(), " ")
+	for _, r := range c.Redirects {
+		s += " " + r.Op + " " + r.Target
+	}
+	return s
+}
+
+// Line returns the name and arguments as a shell line, quoting words
+// that need it. Expansions kept as written ("$HOME", "$(pwd)") are double
+// quoted so they still read as expansions. Assignments, wrappers and
+// redirections are left out.
+func (c *Command) Line() string {
+	const special = " \t\n'\"\\;&|<>()*?[]{}#`"
+
+	words := c.Words()
+	for i, word := range words {
+		switch {
+		case strings.Contains(word, "$"):
+			if strings.ContainsAny(word, special) {
+				words[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+			}
+		case word == "" || strings.ContainsAny(word, special):
+			if quoted, err := syntax.Quote(word, syntax.LangBash); err == nil {
+				words[i] = quoted
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Flags returns the options passed to the command: short flags are split
+// ("-rf" gives "r" and "f") and long flags lose their dashes and value
+// ("--force=true" gives "force"). Parsing stops at "--".
+func (c *Command) Flags() map[string]bool {
+	flags := make(map[string]bool)
+	for _, arg := range c.Args {
+		switch {
+		case arg == "--":
+			return flags
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			flags[name] = true
+		case len(arg) > 1 && arg[0] == '-' && !isNumber(arg[1:]):
+			for _, r := range arg[1:] {
+				flags[string(r)] = true
+			}
+		}
+	}
+	return flags
+}
+
+// HasFlag reports whether any of names was passed as a flag
+func (c *Command) HasFlag(names ...string) bool {
+	flags := c.Flags()
+	for _, name := range names {
+		if flags[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Positional returns the arguments that are not flags
+func (c *Command) Positional() []string {
+	var positional []string
+	for i, arg := range c.Args {
+		if arg == "--" {
+			return append(positional, c.Args[i+1:]...)
+		}
+		if len(arg) > 1 && arg[0] == '-' && !isNumber(arg[1:]) {
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return positional
+}
+
+// Parse parses a command line and returns every simple command it runs:
+// pipeline stages, list members, subshells, command substitutions and
+// scripts passed to bash -c, eval, watch, xargs or find -exec
+func Parse(src string) ([]*Command, error) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walker{vars: make(map[string]string), printer: syntax.NewPrinter(syntax.SingleLine(true))}
+	for _, stmt := range file.Stmts {
+		w.stmt(stmt, nil, nil)
+	}
+	return w.commands, nil
+}
+
+// walker collects simple commands from a syntax tree
+type walker struct {
+	commands []*Command
+	vars     map[string]string // Literal assignments seen so far (X=/tmp)
+	printer  *syntax.Printer
+	depth    int
+}
+
+// stmt records the commands of a statement and returns the last one, which
+// is what a following pipeline stage reads from
+func (w *walker) stmt(s *syntax.Stmt, stdin *Command, wrappers []string) *Command {
+	switch cmd := s.Cmd.(type) {
+	case *syntax.CallExpr:
+		return w.call(cmd, s.Redirs, stdin, wrappers)
+	case *syntax.BinaryCmd:
+		if cmd.Op == syntax.Pipe || cmd.Op == syntax.PipeAll {
+			return w.stmt(cmd.Y, w.stmt(cmd.X, stdin, wrappers), wrappers)
+		}
+		w.stmt(cmd.X, stdin, wrappers)
+		return w.stmt(cmd.Y, stdin, wrappers)
+	case nil:
+		return nil
+	default:
+		// Subshells, blocks, loops, conditionals and functions
+		w.nested(cmd, stdin, wrappers)
+		return nil
+	}
+}
+
+// nested records every statement found below node
+func (w *walker) nested(node syntax.Node, stdin *Command, wrappers []string) {
+	if node == nil {
+		return
+	}
+	syntax.Walk(node, func(n syntax.Node) bool {
+		if s, ok := n.(*syntax.Stmt); ok {
+			w.stmt(s, stdin, wrappers)
+			return false
+		}
+		return true
+	})
+}
+
+// call records a simple command
+func (w *walker) call(ce *syntax.CallExpr, redirs []*syntax.Redirect, stdin *Command, wrappers []string) *Command {
+	// Command substitutions run whatever the command turns out to be
+	for _, assign := range ce.Assigns {
+		w.nested(assign.Value, nil, wrappers)
+	}
+	for _, arg := range ce.Args {
+		w.nested(arg, nil, wrappers)
+	}
+
+	if len(ce.Args) == 0 {
+		for _, assign := range ce.Assigns {
+			if assign.Value != nil {
+				w.vars[assign.Name.Value] = w.word(assign.Value)
+			}
+		}
+		return nil
+	}
+
+	cmd := &Command{Stdin: stdin, Wrappers: wrappers}
+	for _, assign := range ce.Assigns {
+		value := ""
+		if assign.Value != nil {
+			value = w.word(assign.Value)
+		}
+		cmd.Assigns = append(cmd.Assigns, assign.Name.Value+"="+value)
+	}
+	for _, r := range redirs {
+		switch {
+		case r.Hdoc != nil:
+			cmd.Input += w.word(r.Hdoc)
+		case r.Op == syntax.WordHdoc:
+			cmd.Input += w.word(r.Word)
+		case r.Word != nil:
+			cmd.Redirects = append(cmd.Redirects, Redirect{Op: r.Op.String(), Target: w.word(r.Word)})
+		}
+	}
+
+	words := make([]string, len(ce.Args))
+	for i, arg := range ce.Args {
+		words[i] = w.word(arg)
+	}
+	return w.unwrap(cmd, words)
+}
+
+// Options that take a value, per wrapper
+var wrapperOptions = map[string]string{
+	"sudo":    "ugCDprtUTh",
+	"doas":    "uC",
+	"env":     "uCS",
+	"nice":    "n",
+	"ionice":  "cnp",
+	"timeout": "sk",
+	"watch":   "nd",
+	"xargs":   "IinLPdEsa",
+	"time":    "fo",
+	"stdbuf":  "ioe",
+}
+
+// unwrap peels wrappers off words and records the command they run
+func (w *walker) unwrap(cmd *Command, words []string) *Command {
+loop:
+	for {
+		name := path.Base(words[0])
+		var rest []string
+
+		switch name {
+		case "sudo", "doas", "nice", "ionice", "nohup", "time", "command", "exec", "builtin", "stdbuf":
+			rest = skipOptions(words[1:], wrapperOptions[name])
+		case "env":
+			rest = skipOptions(words[1:], wrapperOptions[name])
+			for len(rest) > 0 && strings.Contains(rest[0], "=") {
+				cmd.Assigns = append(cmd.Assigns, rest[0])
+				rest = rest[1:]
+			}
+		case "timeout":
+			rest = skipOptions(words[1:], wrapperOptions[name])
+			if len(rest) > 0 {
+				rest = rest[1:] // The duration
+			}
+		case "xargs":
+			rest = skipOptions(words[1:], wrapperOptions[name])
+			if len(rest) == 0 {
+				rest = []string{"echo"}
+			}
+			if items := pipedItems(cmd.Stdin); len(items) > 0 {
+				if replace := xargsReplace(words[1:]); replace != "" {
+					rest = substitute(rest, replace, items)
+				} else {
+					rest = append(append([]string(nil), rest...), items...)
+				}
+			}
+		case "watch":
+			if script := skipOptions(words[1:], wrapperOptions[name]); len(script) > 0 {
+				return w.script(cmd, name, strings.Join(script, " "))
+			}
+			break loop
+		case "eval":
+			if len(words) > 1 {
+				return w.script(cmd, name, strings.Join(words[1:], " "))
+			}
+			break loop
+		case "bash", "sh", "zsh", "dash", "ksh":
+			if script, ok := shellScript(words[1:]); ok {
+				return w.script(cmd, name, script)
+			}
+			break loop
+		case "find":
+			return w.find(cmd, words)
+		default:
+			break loop
+		}
+
+		// A bare wrapper ("sudo -v") is the command itself
+		if len(rest) == 0 {
+			break
+		}
+		cmd.Wrappers = append(append([]string(nil), cmd.Wrappers...), name)
+		words = rest
+	}
+
+	cmd.Name = path.Base(words[0])
+	cmd.Args = words[1:]
+	w.commands = append(w.commands, cmd)
+	return cmd
+}
+
+// script records the commands of a script run by a wrapper (bash -c, eval,
+// watch) and returns the last one
+func (w *walker) script(cmd *Command, wrapper, src string) *Command {
+	wrappers := append(append([]string(nil), cmd.Wrappers...), wrapper)
+
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil || w.depth >= maxDepth {
+		// Unparsable: treat the script as one command so rules still see its words
+		fields := strings.Fields(src)
+		if len(fields) == 0 {
+			return nil
+		}
+		inner := &Command{Name: path.Base(fields[0]), Args: fields[1:], Wrappers: wrappers, Stdin: cmd.Stdin}
+		w.commands = append(w.commands, inner)
+		return inner
+	}
+
+	w.depth++
+	defer func() { w.depth-- }()
+
+	var last *Command
+	for _, stmt := range file.Stmts {
+		last = w.stmt(stmt, cmd.Stdin, wrappers)
+	}
+	return last
+}
+
+// find records a find command and any command it runs with -exec/-execdir/-ok
+func (w *walker) find(cmd *Command, words []string) *Command {
+	cmd.Name = "find"
+	cmd.Args = words[1:]
+	w.commands = append(w.commands, cmd)
+
+	for i := 1; i < len(words); i++ {
+		switch words[i] {
+		case "-exec", "-execdir", "-ok", "-okdir":
+			end := i + 1
+			for end < len(words) && words[end] != ";" && words[end] != "+" {
+				end++
+			}
+			if end > i+1 {
+				run := words[i+1 : end]
+				if paths, ok := findPaths(words[:i]); ok {
+					run = substitute(run, "{}", paths)
+				}
+				inner := &Command{Wrappers: append(append([]string(nil), cmd.Wrappers...), "find")}
+				w.unwrap(inner, run)
+			}
+			i = end
+		}
+	}
+	return cmd
+}
+
+// findOptions are the find primaries that neither filter nor act, and the
+// number of values each takes
+var findOptions = map[string]int{
+	"-depth": 0, "-d": 0, "-xdev": 0, "-mount": 0, "-noleaf": 0,
+	"-ignore_readdir_race": 0, "-print": 0, "-print0": 0, "-maxdepth": 1,
+}
+
+// findPaths returns the starting points of a find command (its words up to
+// an action) when every one of them is matched, that is when no test such
+// as -name or -type filters them out
+func findPaths(words []string) ([]string, bool) {
+	args := words[1:]
+	for len(args) > 0 && (args[0] == "-H" || args[0] == "-L" || args[0] == "-P") {
+		args = args[1:]
+	}
+
+	var paths []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") && args[0] != "(" && args[0] != "!" {
+		paths = append(paths, args[0])
+		args = args[1:]
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	for len(args) > 0 {
+		n, ok := findOptions[args[0]]
+		if !ok || len(args) <= n {
+			return nil, false
+		}
+		args = args[1+n:]
+	}
+	return paths, true
+}
+
+// pipedItems returns the items a command writes for xargs to read, when
+// they are known without running it: the arguments of echo or printf, or
+// the paths a find without tests prints
+func pipedItems(c *Command) []string {
+	if c == nil {
+		return nil
+	}
+	switch c.Name {
+	case "echo":
+		return c.Positional()
+	case "printf":
+		if args := c.Positional(); len(args) > 1 {
+			return args[1:]
+		}
+	case "find":
+		if paths, ok := findPaths(c.Words()); ok {
+			return paths
+		}
+	}
+	return nil
+}
+
+// xargsReplace returns the replace string set with -I, -i or --replace,
+// or "" if xargs appends its input instead
+func xargsReplace(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-":
+			return ""
+		case arg == "-I" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "-I") && len(arg) > 2:
+			return arg[2:]
+		case arg == "-i" || arg == "--replace":
+			return "{}"
+		case strings.HasPrefix(arg, "--replace="):
+			return strings.TrimPrefix(arg, "--replace=")
+		case len(arg) == 2 && strings.ContainsRune(wrapperOptions["xargs"], rune(arg[1])):
+			i++ // The option's value
+		}
+	}
+	return ""
+}
+
+// substitute replaces placeholder in words with each of items, repeating
+// a word once per item
+func substitute(words []string, placeholder string, items []string) []string {
+	out := make([]string, 0, len(words))
+	for _, word := range words {
+		if !strings.Contains(word, placeholder) {
+			out = append(out, word)
+			continue
+		}
+		for _, item := range items {
+			out = append(out, strings.ReplaceAll(word, placeholder, item))
+		}
+	}
+	return out
+}
+
+// shellScript returns the script passed to a shell with -c
+func shellScript(args []string) (string, bool) {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+			return "", false
+		}
+		if strings.Contains(arg, "c") && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// skipOptions drops leading options; valued lists the short options that
+// take a separate value
+func skipOptions(args []string, valued string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return args[1:]
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return args
+		}
+		args = args[1:]
+		if !strings.HasPrefix(arg, "--") && len(arg) == 2 && strings.ContainsRune(valued, rune(arg[1])) && len(args) > 0 {
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// word renders a word without quotes, substituting known literal variables
+func (w *walker) word(word *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		w.part(&b, part, false)
+	}
+	return b.String()
+}
+
+func (w *walker) part(b *strings.Builder, part syntax.WordPart, quoted bool) {
+	switch p := part.(type) {
+	case *syntax.Lit:
+		b.WriteString(unescape(p.Value, quoted))
+	case *syntax.SglQuoted:
+		b.WriteString(p.Value)
+	case *syntax.DblQuoted:
+		for _, inner := range p.Parts {
+			w.part(b, inner, true)
+		}
+	case *syntax.ParamExp:
+		if value, ok := w.vars[p.Param.Value]; ok && isPlain(p) {
+			b.WriteString(value)
+			return
+		}
+		w.print(b, part)
+	default:
+		w.print(b, part)
+	}
+}
+
+// print writes a node as shell source
+func (w *walker) print(b *strings.Builder, node syntax.Node) {
+	var buf bytes.Buffer
+	if err := w.printer.Print(&buf, node); err == nil {
+		b.WriteString(buf.String())
+	}
+}
+
+// isPlain reports whether a parameter expansion is just $NAME or ${NAME}
+func isPlain(p *syntax.ParamExp) bool {
+	return p.Param != nil && p.Exp == nil && p.Repl == nil && p.Index == nil && p.Slice == nil && !p.Length && !p.Excl && !p.Width
+}
+
+// unescape removes backslash escapes (only those the shell honours inside
+// double quotes when quoted)
+func unescape(s string, quoted bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			next := s[i+1]
+			if !quoted || strings.IndexByte("$`\"\\\n", next) >= 0 {
+				if next != '\n' {
+					b.WriteByte(next)
+				}
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
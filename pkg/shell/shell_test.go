@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// summary renders the commands a line runs as "wrappers:name args", one
+// per command
+func summary(commands []*Command) []string {
+	lines := make([]string, 0, len(commands))
+	for _, c := range commands {
+		line := c.String()
+		if len(c.Wrappers) > 0 {
+			line = strings.Join(c.Wrappers, ",") + ":" + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"simple", "rm -rf ./build", []string{"rm -rf ./build"}},
+		{"path is reduced to its base name", "/bin/rm -rf /", []string{"rm -rf /"}},
+		{"quotes are removed", `echo "a b" 'c'`, []string{"echo a b c"}},
+		{"pipeline", "ls | grep x", []string{"ls", "grep x"}},
+		{"list", "cd /tmp && rm -rf x; ls", []string{"cd /tmp", "rm -rf x", "ls"}},
+		{"subshell", "(rm -rf /)", []string{"rm -rf /"}},
+		{"command substitution", "echo $(rm -rf /)", []string{"rm -rf /", "echo $(rm -rf /)"}},
+		{"redirect", "echo x > /dev/sda", []string{"echo x > /dev/sda"}},
+		{"sudo", "sudo -u root rm -rf /", []string{"sudo:rm -rf /"}},
+		{"env", "env FOO=1 rm -rf /", []string{"env:rm -rf /"}},
+		{"timeout", "timeout 5 rm -rf /", []string{"timeout:rm -rf /"}},
+		{"nested wrappers", "sudo nice -n 5 rm -rf /", []string{"sudo,nice:rm -rf /"}},
+		{"bare wrapper", "sudo -v", []string{"sudo -v"}},
+		{"bash -c", `bash -c "rm -rf /"`, []string{"bash:rm -rf /"}},
+		{"eval", `eval "rm -rf /"`, []string{"eval:rm -rf /"}},
+		{"literal variable", "X=/; rm -rf $X", []string{"rm -rf /"}},
+		{"unknown variable", "rm -rf $HOME", []string{"rm -rf $HOME"}},
+
+		// xargs appends what is piped into it when that is known
+		{"xargs", "echo / | xargs rm -rf", []string{"echo /", "xargs:rm -rf /"}},
+		{"xargs replace string", "echo / | xargs -I{} rm -rf {}", []string{"echo /", "xargs:rm -rf /"}},
+		{"xargs -I with a value", "echo / | xargs -I % rm -rf %", []string{"echo /", "xargs:rm -rf /"}},
+		{"xargs from printf", `printf '%s\n' / | xargs rm -rf`, []string{`printf %s\n /`, "xargs:rm -rf /"}},
+		{"xargs from find", "find / -print0 | xargs -0 rm -rf", []string{"find / -print0", "xargs:rm -rf /"}},
+		{"xargs from filtered find", "find / -name x | xargs rm -rf", []string{"find / -name x", "xargs:rm -rf"}},
+		{"xargs from unknown input", "cat list | xargs rm -rf", []string{"cat list", "xargs:rm -rf"}},
+		{"bare xargs", "echo / | xargs", []string{"echo /", "xargs:echo /"}},
+
+		// find -exec runs its command on the starting points when no test
+		// filters them
+		{"find -exec", `find / -exec rm -rf {} \;`, []string{"find / -exec rm -rf {} ;", "find:rm -rf /"}},
+		{"find -exec +", "find ~ -maxdepth 0 -exec rm -rf {} +", []string{"find ~ -maxdepth 0 -exec rm -rf {} +", "find:rm -rf ~"}},
+		{"find -execdir", `find /a /b -execdir rm -rf {} \;`, []string{"find /a /b -execdir rm -rf {} ;", "find:rm -rf /a /b"}},
+		{"find without a path", `find -exec rm -rf {} \;`, []string{"find -exec rm -rf {} ;", "find:rm -rf ."}},
+		{"filtered find -exec", `find / -name "*.log" -exec rm -f {} \;`, []string{"find / -name *.log -exec rm -f {} ;", "find:rm -f {}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.line, err)
+			}
+			if got := summary(commands); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStdin(t *testing.T) {
+	commands, err := Parse("curl -s https://x | sudo bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := commands[len(commands)-1]
+	if last.Name != "bash" || last.Stdin == nil || last.Stdin.Name != "curl" {
+		t.Errorf("bash should read from curl, got %q fed by %v", last.Name, last.Stdin)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse("echo 'unterminated"); err == nil {
+		t.Error("Parse should fail on an unterminated quote")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-rf", "/"}, []string{"f", "r"}},
+		{[]string{"--force=true", "--recursive", "x"}, []string{"force", "recursive"}},
+		{[]string{"-n", "-5"}, []string{"n"}},
+		{[]string{"-r", "--", "-f"}, []string{"r"}},
+	}
+
+	for _, tt := range tests {
+		c := &Command{Name: "rm", Args: tt.args}
+		var got []string
+		for _, name := range []string{"f", "force", "n", "r", "recursive"} {
+			if c.HasFlag(name) {
+				got = append(got, name)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("flags of %q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPositional(t *testing.T) {
+	c := &Command{Name: "rm", Args: []string{"-rf", "a", "-1", "--", "-b"}}
+	want := []string{"a", "-1", "-b"}
+	if got := c.Positional(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Positional() = %q, want %q", got, want)
+	}
+}
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		command *Command
+		want    string
+	}{
+		{&Command{Name: "git", Args: []string{"commit", "-m", "fix bug"}}, `git commit -m 'fix bug'`},
+		{&Command{Name: "echo", Args: []string{"$HOME"}}, "echo $HOME"},
+		{&Command{Name: "echo", Args: []string{"$(pwd) dir"}}, `echo "$(pwd) dir"`},
+		{&Command{Name: "echo", Args: []string{""}}, "echo ''"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.command.Line(); got != tt.want {
+			t.Errorf("Line() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package toolversion
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Timeout bounds each run of a tool to ask its version
+var Timeout = 3 * time.Second
+
+// versionArgs are the arguments that make each tool print its version
+// quickly and without contacting a server
+var versionArgs = map[string][]string{
+	"ansible":    {"--version"},
+	"argocd":     {"version", "--client", "--short"},
+	"docker":     {"--version"},
+	"git":        {"--version"},
+	"helm":       {"version", "--short"},
+	"kubectl":    {"version", "--client"},
+	"terraform":  {"version"},
+	"terragrunt": {"--version"},
+}
+
+// entry is a cached version together with the binary it was read from; it
+// is stale once the binary is replaced
+type entry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Version string    `json:"version,omitempty"` // Empty if the tool printed none
+}
+
+var (
+	mu        sync.Mutex
+	cacheFile string
+	cached    map[string]entry
+	detected  = map[string]Version{} // This process's lookups
+)
+
+// SetCacheFile sets where detected versions are kept between runs
+// (usually ~/.ai/tool-versions.json). Without it versions are only cached
+// for the life of the process.
+func SetCacheFile(file string) {
+	mu.Lock()
+	defer mu.Unlock()
+	cacheFile, cached = file, nil
+	detected = map[string]Version{}
+}
+
+// Lookup returns the installed version of tool, or the zero Version if the
+// tool is not installed or does not say. The version is read from the
+// cache unless the binary on PATH changed since it was detected.
+func Lookup(tool string) Version {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := detected[tool]; ok {
+		return v
+	}
+
+	v := lookup(tool)
+	detected[tool] = v
+	return v
+}
+
+func lookup(tool string) Version {
+	args, ok := versionArgs[tool]
+	if !ok {
+		args = []string{"--version"}
+	}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return Version{}
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Version{}
+	}
+
+	loadCache()
+	current := entry{Path: path, Size: info.Size(), ModTime: info.ModTime().UTC()}
+	if e, ok := cached[tool]; ok && e.Path == current.Path && e.Size == current.Size && e.ModTime.Equal(current.ModTime) {
+		v, _ := Parse(e.Version)
+		return v
+	}
+
+	v := detect(path, args)
+	current.Version = v.Raw
+	cached[tool] = current
+	saveCache()
+	return v
+}
+
+// detect runs the binary and parses the version it prints
+func detect(path string, args []string) Version {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	// Some tools print their version to stderr or exit non-zero after
+	// printing it
+	output, _ := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	v, _ := Parse(string(output))
+	return v
+}
+
+func loadCache() {
+	if cached != nil {
+		return
+	}
+	cached = map[string]entry{}
+	if cacheFile == "" {
+		return
+	}
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		if json.Unmarshal(data, &cached) != nil {
+			cached = map[string]entry{} // A corrupt cache is rebuilt
+		}
+	}
+}
+
+func saveCache() {
+	if cacheFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(cacheFile, append(data, '\n'), 0644)
+}
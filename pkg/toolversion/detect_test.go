@@ -0,0 +1,57 @@
+package toolversion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTool installs an executable named tool on PATH that prints output
+// and counts its runs in a file next to it
+func fakeTool(t *testing.T, dir, tool, output string) (runs func() int) {
+	t.Helper()
+	counter := filepath.Join(dir, tool+".runs")
+	script := "#!/bin/sh\necho run >> " + counter + "\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, tool), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	cacheFile := filepath.Join(t.TempDir(), "tool-versions.json")
+	runs := fakeTool(t, dir, "terragrunt", "terragrunt version v0.77.22")
+	fakeTool(t, dir, "helm", "no version here")
+
+	SetCacheFile(cacheFile)
+	if v := Lookup("terragrunt"); v.String() != "0.77.22" {
+		t.Errorf("Lookup(terragrunt) = %s, want 0.77.22", v)
+	}
+	if v := Lookup("helm"); !v.IsZero() {
+		t.Errorf("Lookup(helm) = %s, want unknown", v)
+	}
+	if v := Lookup("kubectl"); !v.IsZero() {
+		t.Errorf("Lookup of a missing tool = %s, want unknown", v)
+	}
+
+	// A new process reads the cache instead of running the tool again
+	SetCacheFile(cacheFile)
+	if v := Lookup("terragrunt"); v.String() != "0.77.22" || runs() != 1 {
+		t.Errorf("cached Lookup = %s after %d runs, want 0.77.22 after 1", v, runs())
+	}
+
+	// Replacing the binary invalidates the cache
+	fakeTool(t, dir, "terragrunt", "terragrunt version v0.80.1 (upgraded)")
+	SetCacheFile(cacheFile)
+	if v := Lookup("terragrunt"); v.String() != "0.80.1" {
+		t.Errorf("Lookup after an upgrade = %s, want 0.80.1", v)
+	}
+
+	SetCacheFile("")
+}
@@ -0,0 +1,153 @@
+package toolversion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a tool version, compared by major, minor and patch. The zero
+// Version means unknown.
+type Version struct {
+	Major, Minor, Patch int
+	Raw                 string // As the tool printed it, e.g. "v1.30.2"
+}
+
+var (
+	// versionPattern finds the first dotted version in a tool's output
+	versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+	// constraintVersion is the version of a range constraint, which may
+	// leave out the minor and patch ("3", "0.77")
+	constraintVersion = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?$`)
+)
+
+// Parse finds the first version number in text ("Terraform v1.9.5",
+// "Docker version 27.3.1, build ce12230", "ansible [core 2.17.0]")
+func Parse(text string) (Version, bool) {
+	return match(versionPattern.FindStringSubmatch(text))
+}
+
+// match converts the submatches of a version pattern
+func match(m []string) (Version, bool) {
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Raw: m[0]}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, true
+}
+
+// MustParse parses a version and panics if there is none
+func MustParse(text string) Version {
+	v, ok := Parse(text)
+	if !ok {
+		panic(fmt.Sprintf("toolversion: no version in %q", text))
+	}
+	return v
+}
+
+// IsZero reports whether the version is unknown
+func (v Version) IsZero() bool {
+	return v.Raw == ""
+}
+
+// Compare returns -1, 0 or 1 as v is older than, the same as or newer
+// than o
+func (v Version) Compare(o Version) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		switch {
+		case d[0] < d[1]:
+			return -1
+		case d[0] > d[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// String returns the version as major.minor.patch
+func (v Version) String() string {
+	if v.IsZero() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// constraint is one comparison of a Range, e.g. ">=0.77"
+type constraint struct {
+	op      string
+	version Version
+}
+
+// Range is a set of versions written as space-separated comparisons that
+// must all hold, e.g. ">=1.18" or ">=0.73 <0.77". The empty range holds
+// every version.
+type Range struct {
+	text        string
+	constraints []constraint
+}
+
+// rangeOps are the comparison operators, longest first
+var rangeOps = []string{">=", "<=", ">", "<", "="}
+
+// ParseRange parses a version range
+func ParseRange(text string) (Range, error) {
+	r := Range{text: text}
+	for _, field := range strings.Fields(text) {
+		op := ""
+		for _, candidate := range rangeOps {
+			if strings.HasPrefix(field, candidate) {
+				op = candidate
+				break
+			}
+		}
+		v, ok := match(constraintVersion.FindStringSubmatch(strings.TrimPrefix(field, op)))
+		if op == "" || !ok {
+			return Range{}, fmt.Errorf("invalid version constraint %q in %q", field, text)
+		}
+		r.constraints = append(r.constraints, constraint{op, v})
+	}
+	return r, nil
+}
+
+// MustParseRange parses a version range and panics if it is invalid
+func MustParseRange(text string) Range {
+	r, err := ParseRange(text)
+	if err != nil {
+		panic("toolversion: " + err.Error())
+	}
+	return r
+}
+
+// Contains reports whether v is in the range
+func (r Range) Contains(v Version) bool {
+	for _, c := range r.constraints {
+		cmp := v.Compare(c.version)
+		ok := false
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the range as written
+func (r Range) String() string {
+	return r.text
+}
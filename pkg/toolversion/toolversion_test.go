@@ -0,0 +1,104 @@
+package toolversion
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // "" when there is no version
+	}{
+		{"Terraform v1.9.5\non linux_amd64", "1.9.5"},
+		{"Docker version 27.3.1, build ce12230", "27.3.1"},
+		{"ansible [core 2.17.0]", "2.17.0"},
+		{"v3.16.2+g13654a5", "3.16.2"},
+		{"Client Version: v1.30.2\nKustomize Version: v5.0.4", "1.30.2"},
+		{"terragrunt version v0.77.22", "0.77.22"},
+		{"git version 2.39", "2.39.0"},
+		{"command not found", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		v, ok := Parse(tt.text)
+		if ok != (tt.want != "") || (ok && v.String() != tt.want) {
+			t.Errorf("Parse(%q) = %s, %v; want %q", tt.text, v, ok, tt.want)
+		}
+	}
+}
+
+func TestVersionZero(t *testing.T) {
+	var v Version
+	if !v.IsZero() || v.String() != "unknown" {
+		t.Errorf("zero Version: IsZero %v, String %q", v.IsZero(), v.String())
+	}
+	if MustParse("0.0.0").IsZero() {
+		t.Error("0.0.0 is a known version")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"0.77.0", "1.0.0", -1},
+		{"2.0", "2.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		if got := MustParse(tt.a).Compare(MustParse(tt.b)); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		r       string
+		version string
+		want    bool
+	}{
+		{"", "0.1.0", true},
+		{">=0.77", "0.77.0", true},
+		{">=0.77", "0.76.9", false},
+		{">=0.73 <0.77", "0.75.2", true},
+		{">=0.73 <0.77", "0.77.0", false},
+		{">=0.73 <0.77", "0.72.1", false},
+		{"<3", "2.17.1", true},
+		{"<3", "3.0.0", false},
+		{">=3", "3.16.2", true},
+		{">1.5", "1.5.0", false},
+		{">1.5", "1.5.1", true},
+		{"<=1.5", "1.5.0", true},
+		{"=1.5.2", "1.5.2", true},
+		{"=1.5.2", "1.5.3", false},
+		{">=v1.18", "1.30.2", true},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.r)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.r, err)
+			continue
+		}
+		if got := r.Contains(MustParse(tt.version)); got != tt.want {
+			t.Errorf("%q contains %s = %v, want %v", tt.r, tt.version, got, tt.want)
+		}
+		if r.String() != tt.r {
+			t.Errorf("String() = %q, want %q", r.String(), tt.r)
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, text := range []string{"0.77", ">=", ">=x.y", "~1.2", ">=1.2 and <2"} {
+		if _, err := ParseRange(text); err == nil {
+			t.Errorf("ParseRange(%q) should fail", text)
+		}
+	}
+}
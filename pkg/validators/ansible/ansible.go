@@ -2,14 +2,25 @@ package ansible
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
 // Validator implements the Validator interface for ansible/ansible-playbook commands.
 type Validator struct {
 	validCommands    []string
 	dangerousModules []string
+	mistakes         []validators.Mistake
+}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:  "ansible",
+		Tools: []string{"ansible", "ansible-playbook", "ansible-vault", "ansible-galaxy", "ansible-config", "ansible-console", "ansible-doc", "ansible-inventory", "ansible-pull"},
+		New:   func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new ansible validator.
@@ -23,6 +34,17 @@ func NewValidator() *Validator {
 		dangerousModules: []string{
 			"shell", "command", "raw", "file", "copy", "template",
 		},
+		// Commonly hallucinated ansible flags
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `--force(\s|=|$)`, Message: "ansible-playbook does not have --force", Hint: "use --check for dry-run"},
+			{Pattern: `--yes`, Message: "ansible does not have --yes", Hint: "it doesn't prompt by default"},
+			{Pattern: `--auto-approve`, Message: "ansible does not have --auto-approve"},
+			{Pattern: `--dry-run`, Message: "ansible does not have --dry-run", Hint: "use --check for dry-run mode", Fix: "--check"},
+			{Pattern: `--parallel`, Message: "ansible does not have --parallel", Hint: "use -f or --forks to control parallelism", Fix: "--forks"},
+			{Pattern: `--inventory-file`, Message: "ansible does not have --inventory-file", Hint: "use -i or --inventory", Fix: "--inventory"},
+			{Pattern: `--playbook`, Message: "ansible-playbook doesn't need a --playbook flag", Hint: "just provide the playbook file"},
+			{Pattern: `--sudo`, Message: "--sudo is deprecated", Hint: "use --become instead", Fix: "--become"},
+		}),
 	}
 }
 
@@ -37,89 +59,101 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if an ansible command is valid.
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
+	findings := validators.FindMistakes("ansible/unknown-flag", command, v.mistakes)
+
+	// Check flags against the schema of the ansible command, if there is one
+	if fields := strings.Fields(command); len(fields) > 0 {
+		if checked, ok := schema.Check(fields[0], command); ok {
+			findings = validators.Merge(findings, checked)
+		}
 	}
 
 	// Check for dangerous operations
-	if warning := v.checkDangerousOps(command); warning != "" {
-		return fmt.Errorf("⚠️  Warning: %s", warning)
+	if finding, ok := v.checkDangerousOps(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for common mistakes
-	if err := v.checkCommonMistakes(command); err != nil {
-		return err
-	}
+	findings = append(findings, v.checkCommonMistakes(command)...)
 
-	return nil
+	return findings
 }
 
-// checkHallucinatedFlags checks for commonly hallucinated ansible flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`--force`:          "ansible-playbook does not have --force. Use --check for dry-run.",
-		`--yes`:            "ansible does not have --yes. It doesn't prompt by default.",
-		`--auto-approve`:   "ansible does not have --auto-approve.",
-		`--dry-run`:        "use --check (not --dry-run) for ansible dry-run mode.",
-		`--parallel`:       "use -f or --forks (not --parallel) to control parallelism.",
-		`--inventory-file`: "use -i or --inventory (not --inventory-file).",
-		`--playbook`:       "ansible-playbook doesn't need --playbook flag. Just provide the playbook file.",
-		`--sudo`:           "--sudo is deprecated. Use --become instead.",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
+// checkDangerousOps checks for dangerous ansible operations.
+func (v *Validator) checkDangerousOps(command string) (validators.Finding, bool) {
+	warn := func(start, end int, message, hint string) (validators.Finding, bool) {
+		return validators.NewFinding("ansible/dangerous", validators.SeverityWarn, command, start, end, message, hint), true
 	}
 
-	return nil
-}
-
-// checkDangerousOps checks for dangerous ansible operations.
-func (v *Validator) checkDangerousOps(command string) string {
 	// Check for dangerous modules in ad-hoc commands
 	if strings.Contains(command, "ansible ") && !strings.Contains(command, "ansible-") {
 		for _, module := range v.dangerousModules {
-			if strings.Contains(command, "-m "+module) || strings.Contains(command, "-module-name="+module) {
-				return fmt.Sprintf("Using module '%s' in ad-hoc command. This can be dangerous. Consider using a playbook for better control and logging.", module)
+			for _, flag := range []string{"-m " + module, "-module-name=" + module} {
+				if i := strings.Index(command, flag); i >= 0 {
+					return warn(i, i+len(flag),
+						fmt.Sprintf("Using module '%s' in ad-hoc command. This can be dangerous.", module),
+						"consider using a playbook for better control and logging")
+				}
 			}
 		}
 	}
 
 	// Check for become without limit
-	if (strings.Contains(command, "--become") || strings.Contains(command, "-b")) &&
-		!strings.Contains(command, "--limit") && !strings.Contains(command, "-l") {
-		return "Running with elevated privileges (--become) without --limit. This affects ALL hosts in the inventory!"
+	fields := strings.Fields(command)
+	if hasFlag(fields, 'b', "--become") && !hasFlag(fields, 'l', "--limit") {
+		return warn(len(command), len(command),
+			"Running with elevated privileges (--become) without --limit. This affects ALL hosts in the inventory!",
+			"add --limit <hosts>")
 	}
 
 	// Check for command module with rm -rf or similar
 	if strings.Contains(command, "-m shell") || strings.Contains(command, "-m command") {
-		if strings.Contains(command, "rm -rf") || strings.Contains(command, "rm -fr") {
-			return "Dangerous command detected: rm -rf in ansible shell/command module. This could delete critical files!"
+		for _, rm := range []string{"rm -rf", "rm -fr"} {
+			if i := strings.Index(command, rm); i >= 0 {
+				return warn(i, i+len(rm),
+					"Dangerous command detected: rm -rf in ansible shell/command module. This could delete critical files!",
+					"use the file module with state=absent")
+			}
 		}
 	}
 
-	return ""
+	return validators.Finding{}, false
+}
+
+// hasFlag reports whether an argument sets a flag, either -<short> (first
+// of several short flags, or with its value attached) or <long>
+func hasFlag(fields []string, short byte, long string) bool {
+	for _, field := range fields {
+		if field == long || strings.HasPrefix(field, long+"=") {
+			return true
+		}
+		if len(field) > 1 && field[0] == '-' && field[1] == short {
+			return true
+		}
+	}
+	return false
 }
 
 // checkCommonMistakes checks for common ansible command mistakes.
-func (v *Validator) checkCommonMistakes(command string) error {
+func (v *Validator) checkCommonMistakes(command string) []validators.Finding {
+	var findings []validators.Finding
+
 	// Check for missing inventory
 	if strings.Contains(command, "ansible-playbook") &&
 		!strings.Contains(command, "-i ") && !strings.Contains(command, "--inventory") {
-		return fmt.Errorf("⚠️  No inventory specified. Ansible will use default /etc/ansible/hosts. Is this intentional?")
+		findings = append(findings, validators.NewFinding("ansible/no-inventory", validators.SeverityWarn, command, len(command), len(command),
+			"No inventory specified. Ansible will use default /etc/ansible/hosts.", "add -i <inventory> unless this is intentional"))
 	}
 
 	// Check for --syntax-check with other flags that won't work
-	if strings.Contains(command, "--syntax-check") {
+	if i := strings.Index(command, "--syntax-check"); i >= 0 {
 		if strings.Contains(command, "--check") || strings.Contains(command, "--diff") {
-			return fmt.Errorf("--syntax-check only validates syntax. It doesn't run with --check or --diff.")
+			findings = append(findings, validators.NewFinding("ansible/syntax-check", validators.SeverityWarn, command, i, i+len("--syntax-check"),
+				"--syntax-check only validates syntax", "it doesn't run with --check or --diff"))
 		}
 	}
 
-	return nil
+	return findings
 }
-
@@ -0,0 +1,54 @@
+package ansible
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"ansible-playbook -i inv site.yml --dry-run", []spanOf{{"ansible/unknown-flag", 33, "--dry-run", "ansible-playbook -i inv site.yml --check"}}},
+		{"ansible all -i inv -m ping --sudo --limit web", []spanOf{{"ansible/unknown-flag", 27, "--sudo", "ansible all -i inv -m ping --become --limit web"}}},
+		{"ansible all -i inv -m shell -a 'rm -rf /tmp/x' --limit web", []spanOf{{"ansible/dangerous", 19, "-m shell", ""}}},
+		{"ansible-playbook -i inv site.yml --syntax-check --check", []spanOf{{"ansible/syntax-check", 33, "--syntax-check", ""}}},
+		{"ansible-playbook site.yml -b", []spanOf{
+			{"ansible/dangerous", 28, "", ""},
+			{"ansible/no-inventory", 28, "", ""},
+		}},
+		{"ansible-playbook -i inv site.yml -bK", []spanOf{{"ansible/dangerous", 36, "", ""}}},
+		{"ansible-playbook -i inv site.yml --become -lweb", nil},
+		// -b and -l inside other words are not flags
+		{"ansible-playbook -i inv site-backup.yml", nil},
+		{"ansible-playbook -i inv site-lb.yml --become", []spanOf{{"ansible/dangerous", 44, "", ""}}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -2,14 +2,25 @@ package argocd
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
 // Validator implements the Validator interface for argocd CLI commands.
 type Validator struct {
 	validSubcommands []string
 	dangerousOps     []string
+	mistakes         []validators.Mistake
+}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:  "argocd",
+		Tools: []string{"argocd"},
+		New:   func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new argocd validator.
@@ -23,6 +34,17 @@ func NewValidator() *Validator {
 		dangerousOps: []string{
 			"app delete", "app terminate-op", "admin", "cluster rm",
 		},
+		// Commonly hallucinated argocd flags
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `app create --auto-sync`, Message: "argocd app create does not have --auto-sync", Hint: "use --sync-policy automated", Fix: "app create --sync-policy automated"},
+			{Pattern: `app sync --wait`, Message: "argocd app sync does not have --wait", Hint: "use --timeout instead"},
+			{Pattern: `app delete --force`, Message: "argocd app delete does not have --force", Hint: "use --cascade to control deletion behavior"},
+			{Pattern: `\s--namespace(\s|=|$)`, Message: "argocd does not have --namespace", Hint: "use --dest-namespace for app destination, or --app-namespace for app placement"},
+			{Pattern: `app rollback`, Message: "argocd doesn't have 'app rollback'", Hint: "use 'app sync --revision <version>' instead"},
+			{Pattern: `--auto-approve`, Message: "argocd does not have --auto-approve"},
+			{Pattern: `app deploy`, Message: "argocd doesn't have 'app deploy'", Hint: "use 'app sync' instead", Fix: "app sync"},
+			{Pattern: `app list --sort(\s|=|$)`, Message: "argocd app list does not have --sort", Hint: "use --sort-by", Fix: "app list --sort-by$1"},
+		}),
 	}
 }
 
@@ -32,56 +54,36 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if an argocd command is valid.
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	if !strings.HasPrefix(command, "argocd") {
 		return nil // Not an argocd command
 	}
 
 	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
-	}
-
-	// Check for invalid subcommands
-	if err := v.checkSubcommand(command); err != nil {
-		return err
+	findings := validators.FindMistakes("argocd/unknown-flag", command, v.mistakes)
+
+	// Check subcommands and flags against the argocd schema if there is
+	// one, otherwise against the known subcommands
+	if checked, ok := schema.Check("argocd", command); ok {
+		findings = validators.Merge(findings, checked)
+	} else if finding, ok := v.checkSubcommand(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for dangerous operations
-	if warning := v.checkDangerousOps(command); warning != "" {
-		return fmt.Errorf("⚠️  Warning: %s", warning)
+	if finding, ok := v.checkDangerousOps(command); ok {
+		findings = append(findings, finding)
 	}
 
-	return nil
-}
-
-// checkHallucinatedFlags checks for commonly hallucinated argocd flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`app create --auto-sync`:    "use --sync-policy automated (not --auto-sync).",
-		`app sync --wait`:           "use --timeout instead of --wait.",
-		`app delete --force`:        "use --cascade (not --force) to control deletion behavior.",
-		`--namespace`:               "use --dest-namespace for app destination, or --app-namespace for app placement.",
-		`app rollback`:              "argocd doesn't have 'app rollback'. Use 'app sync --revision <version>' instead.",
-		`--auto-approve`:            "argocd does not have --auto-approve.",
-		`app deploy`:                "argocd doesn't have 'app deploy'. Use 'app sync' instead.",
-		`app list --sort`:           "use --sort-by (not --sort).",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
-	}
-
-	return nil
+	return findings
 }
 
 // checkSubcommand validates the argocd subcommand.
-func (v *Validator) checkSubcommand(command string) error {
+func (v *Validator) checkSubcommand(command string) (validators.Finding, bool) {
 	parts := strings.Fields(command)
 	if len(parts) < 2 {
-		return fmt.Errorf("incomplete argocd command")
+		return validators.NewFinding("argocd/incomplete", validators.SeverityBlock, command, 0, len(command),
+			"incomplete argocd command", "add a subcommand, e.g. 'argocd app list'"), true
 	}
 
 	subcommand := parts[1]
@@ -96,28 +98,34 @@ func (v *Validator) checkSubcommand(command string) error {
 	}
 
 	if !isValid {
-		return fmt.Errorf("'%s' is not a valid argocd subcommand. Use 'argocd --help' to see valid commands.", subcommand)
+		start, end := validators.WordSpan(command, 1)
+		return validators.NewFinding("argocd/unknown-subcommand", validators.SeverityBlock, command, start, end,
+			fmt.Sprintf("'%s' is not a valid argocd subcommand", subcommand), "use 'argocd --help' to see valid commands").DidYouMean(subcommand, v.validSubcommands), true
 	}
 
-	return nil
+	return validators.Finding{}, false
 }
 
 // checkDangerousOps checks for dangerous argocd operations.
-func (v *Validator) checkDangerousOps(command string) string {
+func (v *Validator) checkDangerousOps(command string) (validators.Finding, bool) {
 	for _, danger := range v.dangerousOps {
-		if strings.Contains(command, danger) {
-			switch {
-			case strings.Contains(danger, "delete"):
-				return "Dangerous: This will delete the ArgoCD application and potentially the deployed resources!"
-			case strings.Contains(danger, "admin"):
-				return "Admin commands can modify ArgoCD's core configuration. Be careful!"
-			case strings.Contains(danger, "terminate-op"):
-				return "Terminating an operation might leave the application in an inconsistent state."
-			case strings.Contains(danger, "cluster rm"):
-				return "This will remove cluster from ArgoCD, affecting all apps deployed to it!"
-			}
+		i := validators.IndexWord(command, danger)
+		if i < 0 {
+			continue
 		}
+
+		var message string
+		switch {
+		case strings.Contains(danger, "delete"):
+			message = "Dangerous: This will delete the ArgoCD application and potentially the deployed resources!"
+		case strings.Contains(danger, "admin"):
+			message = "Admin commands can modify ArgoCD's core configuration. Be careful!"
+		case strings.Contains(danger, "terminate-op"):
+			message = "Terminating an operation might leave the application in an inconsistent state."
+		case strings.Contains(danger, "cluster rm"):
+			message = "This will remove cluster from ArgoCD, affecting all apps deployed to it!"
+		}
+		return validators.NewFinding("argocd/dangerous", validators.SeverityWarn, command, i, i+len(danger), message, ""), true
 	}
-	return ""
+	return validators.Finding{}, false
 }
-
@@ -0,0 +1,48 @@
+package argocd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"argocd app create --auto-sync web", []spanOf{{"argocd/unknown-flag", 7, "app create --auto-sync", "argocd app create --sync-policy automated web"}}},
+		{"argocd app list --sort name", []spanOf{{"argocd/unknown-flag", 7, "app list --sort ", "argocd app list --sort-by name"}}},
+		{"argocd aplication list", []spanOf{{"argocd/unknown-subcommand", 7, "aplication", ""}}},
+		{"argocd", []spanOf{{"argocd/incomplete", 0, "argocd", ""}}},
+		{"argocd app delete web", []spanOf{{"argocd/dangerous", 7, "app delete", ""}}},
+		{"argocd cluster rm https://k8s", []spanOf{{"argocd/dangerous", 7, "cluster rm", ""}}},
+		{"argocd app get admin-ui", nil},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
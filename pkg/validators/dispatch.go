@@ -0,0 +1,173 @@
+package validators
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/shell"
+)
+
+// Segments splits a command line into the simple commands it runs
+// (pipeline stages, && and ; lists, subshells, $(...) and bash -c
+// scripts), with environment assignments and wrappers such as sudo, time,
+// watch or xargs removed. A line the shell parser rejects is returned
+// whole.
+func Segments(line string) []string {
+	var texts []string
+	for _, seg := range segments(line) {
+		texts = append(texts, seg.text)
+	}
+	return texts
+}
+
+// segment is a simple command of a line and where it starts in the line
+// (-1 if the line does not contain it verbatim)
+type segment struct {
+	text   string
+	offset int
+}
+
+// segments splits a line like Segments and locates each command. A
+// command that appears more than once is matched to its occurrences in
+// order, so repeated commands get their own offsets.
+func segments(line string) []segment {
+	commands, err := shell.Parse(line)
+	if err != nil {
+		trimmed := strings.TrimSpace(line)
+		return []segment{{trimmed, strings.Index(line, trimmed)}}
+	}
+
+	var segs []segment
+	claimed := make(map[int]bool)
+	for _, cmd := range commands {
+		if cmd.Name == "" {
+			continue
+		}
+		seg := segment{text: cmd.Line(), offset: -1}
+		for from := 0; from < len(line); {
+			i := strings.Index(line[from:], seg.text)
+			if i < 0 {
+				break
+			}
+			start := from + i
+			if !claimed[start] && wordBoundary(line, start, start+len(seg.text)) {
+				seg.offset = start
+				claimed[start] = true
+				break
+			}
+			from = start + 1
+		}
+		segs = append(segs, seg)
+	}
+	return segs
+}
+
+// wordBoundary reports whether line[start:end] is not part of a longer word
+func wordBoundary(line string, start, end int) bool {
+	const separators = " \t\n;&|()`"
+	return (start == 0 || strings.ContainsRune(separators, rune(line[start-1]))) &&
+		(end == len(line) || strings.ContainsRune(separators, rune(line[end])))
+}
+
+// Result holds the findings for every simple command of a line
+type Result struct {
+	Line     string
+	Findings []Finding
+}
+
+// ValidateLine dispatches each simple command of a line to the first
+// validator that can handle it and collects the findings
+func ValidateLine(line string, list []Validator) *Result {
+	result := &Result{Line: line}
+	for _, seg := range segments(line) {
+		for _, v := range list {
+			if v.CanValidate(seg.text) {
+				for _, finding := range v.Validate(seg.text) {
+					finding.Offset = seg.offset
+					result.Findings = append(result.Findings, finding)
+				}
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Max returns the highest severity among the findings ("" if none)
+func (r *Result) Max() Severity {
+	var max Severity
+	for _, finding := range r.Findings {
+		if finding.Severity.Rank() > max.Rank() {
+			max = finding.Severity
+		}
+	}
+	return max
+}
+
+// Blocked reports whether any finding blocks the line
+func (r *Result) Blocked() bool {
+	return r.Max() == SeverityBlock
+}
+
+// Select returns the findings at or above a severity
+func (r *Result) Select(min Severity) []Finding {
+	var selected []Finding
+	for _, finding := range r.Findings {
+		if finding.Severity.Rank() >= min.Rank() {
+			selected = append(selected, finding)
+		}
+	}
+	return selected
+}
+
+// Summary describes findings at or above a severity, one per line. The
+// command is named when the line runs more than one.
+func (r *Result) Summary(min Severity) string {
+	findings := r.Select(min)
+	lines := make([]string, len(findings))
+	for i, finding := range findings {
+		lines[i] = finding.String()
+		if strings.TrimSpace(finding.Command) != strings.TrimSpace(r.Line) {
+			lines[i] = fmt.Sprintf("`%s`: %s", finding.Command, lines[i])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Corrected applies the corrections of the blocking findings to the line,
+// each at the offset of its own command. It reports false unless every one
+// of them could be fixed; a correction applies only where its command
+// appears verbatim in the line.
+func (r *Result) Corrected() (string, bool) {
+	type edit struct {
+		start, end  int
+		replacement string
+	}
+
+	var edits []edit
+	for _, finding := range r.Select(SeverityBlock) {
+		offset := finding.Offset
+		if finding.Correction == "" || offset < 0 || offset > len(r.Line) || !strings.HasPrefix(r.Line[offset:], finding.Command) {
+			return "", false
+		}
+
+		e := edit{offset + finding.Span.Start, offset + finding.Span.End, finding.replacement()}
+		for _, other := range edits {
+			if e.start < other.end && other.start < e.end || e.start == other.start {
+				return "", false
+			}
+		}
+		edits = append(edits, e)
+	}
+	if len(edits) == 0 {
+		return "", false
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	line := r.Line
+	for _, e := range edits {
+		line = line[:e.start] + e.replacement + line[e.end:]
+	}
+	return line, true
+}
@@ -0,0 +1,179 @@
+package validators
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// typoValidator blocks "gte" in commands of its tool, with the fix "get"
+type typoValidator struct {
+	tool string
+}
+
+func (v typoValidator) CanValidate(command string) bool {
+	return strings.HasPrefix(command, v.tool+" ")
+}
+
+func (v typoValidator) Validate(command string) []Finding {
+	var findings []Finding
+	for i, word := range strings.Fields(command) {
+		if word == "gte" {
+			start, end := WordSpan(command, i)
+			findings = append(findings, NewFinding(v.tool+"/subcommand", SeverityBlock, command, start, end, "unknown subcommand 'gte'", "").
+				DidYouMean("gte", []string{"get"}))
+		}
+	}
+	return findings
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"kubectl get pods", []string{"kubectl get pods"}},
+		{"kubectl get pods | grep web && echo ok", []string{"kubectl get pods", "grep web", "echo ok"}},
+		{"sudo -u admin kubectl get pods; time helm list", []string{"kubectl get pods", "helm list"}},
+		{"KUBECONFIG=x kubectl get pods", []string{"kubectl get pods"}},
+		{"bash -c 'kubectl get pods'", []string{"kubectl get pods"}},
+		{"(cd infra && terraform plan)", []string{"cd infra", "terraform plan"}},
+		{"kubectl logs $(kubectl get pod -o name)", []string{"kubectl get pod -o name", `kubectl logs "$(kubectl get pod -o name)"`}},
+		{"echo 'unterminated", []string{"echo 'unterminated"}},
+	}
+
+	for _, tt := range tests {
+		if got := Segments(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Segments(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSegmentOffsets(t *testing.T) {
+	tests := []struct {
+		line string
+		want []int
+	}{
+		{"kubectl gte pods", []int{0}},
+		{"  kubectl gte pods", []int{2}},
+		{"kubectl gte pods && kubectl gte pods", []int{0, 20}},
+		{"kubectl get pods -A; kubectl get pods", []int{0, 21}},
+		{"kubectl get podsx; kubectl get pods", []int{0, 19}},
+		{"sudo kubectl get pods", []int{5}},
+		{"kubectl get 'my pod'", []int{0}},
+		{`kubectl get "my pod"`, []int{-1}}, // Re-quoted differently
+	}
+
+	for _, tt := range tests {
+		var got []int
+		for _, seg := range segments(tt.line) {
+			got = append(got, seg.offset)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("segments(%q) offsets = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLine(t *testing.T) {
+	list := []Validator{typoValidator{"kubectl"}, typoValidator{"helm"}}
+
+	tests := []struct {
+		line     string
+		commands []string // Command of each finding
+	}{
+		{"kubectl get pods", nil},
+		{"kubectl gte pods", []string{"kubectl gte pods"}},
+		{"helm gte x | kubectl gte pods", []string{"helm gte x", "kubectl gte pods"}},
+		{"docker gte x", nil}, // No validator
+		{"sudo helm gte x && true", []string{"helm gte x"}},
+	}
+
+	for _, tt := range tests {
+		result := ValidateLine(tt.line, list)
+		var commands []string
+		for _, finding := range result.Findings {
+			commands = append(commands, finding.Command)
+		}
+		if !reflect.DeepEqual(commands, tt.commands) {
+			t.Errorf("ValidateLine(%q) findings on %q, want %q", tt.line, commands, tt.commands)
+		}
+		if result.Blocked() != (len(tt.commands) > 0) {
+			t.Errorf("ValidateLine(%q).Blocked() = %v", tt.line, result.Blocked())
+		}
+	}
+}
+
+func TestValidateLineFirstValidatorWins(t *testing.T) {
+	first := typoValidator{"kubectl"}
+	second := recordingValidator{}
+	result := ValidateLine("kubectl gte pods", []Validator{first, &second})
+	if len(result.Findings) != 1 || second.calls != 0 {
+		t.Errorf("findings %v, second validator called %d times; want only the first", result.Findings, second.calls)
+	}
+}
+
+type recordingValidator struct {
+	calls int
+}
+
+func (v *recordingValidator) CanValidate(string) bool { return true }
+
+func (v *recordingValidator) Validate(string) []Finding {
+	v.calls++
+	return nil
+}
+
+func TestCorrected(t *testing.T) {
+	list := []Validator{typoValidator{"kubectl"}, typoValidator{"helm"}}
+
+	tests := []struct {
+		line string
+		want string // "" when the line cannot be corrected
+	}{
+		{"kubectl gte pods", "kubectl get pods"},
+		{"kubectl get pods && kubectl gte pods", "kubectl get pods && kubectl get pods"},
+		{"kubectl gte pods && kubectl gte pods", "kubectl get pods && kubectl get pods"},
+		{"kubectl gte pods; kubectl gte pods -A", "kubectl get pods; kubectl get pods -A"},
+		{"sudo kubectl gte pods | helm gte x", "sudo kubectl get pods | helm get x"},
+		{"kubectl gte gte", "kubectl get get"},
+		{`kubectl gte "my pod"`, ""}, // Command not verbatim in the line
+		{"kubectl get pods", ""},     // Nothing to correct
+	}
+
+	for _, tt := range tests {
+		got, ok := ValidateLine(tt.line, list).Corrected()
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("Corrected(%q) = %q, %v; want %q", tt.line, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCorrectedNeedsEveryFix(t *testing.T) {
+	result := &Result{Line: "kubectl gte pods", Findings: []Finding{
+		NewFinding("kubectl/subcommand", SeverityBlock, "kubectl gte pods", 8, 11, "unknown", "").WithFix("get"),
+		NewFinding("kubectl/flag", SeverityBlock, "kubectl gte pods", 12, 16, "no fix", ""),
+	}}
+	if got, ok := result.Corrected(); ok {
+		t.Errorf("Corrected() = %q, want no correction while a blocking finding has no fix", got)
+	}
+
+	result.Findings[1].Severity = SeverityWarn
+	if got, ok := result.Corrected(); !ok || got != "kubectl get pods" {
+		t.Errorf("Corrected() = %q, %v; want warnings ignored", got, ok)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	result := ValidateLine("kubectl gte pods | helm gte x", []Validator{typoValidator{"kubectl"}, typoValidator{"helm"}})
+	want := "`kubectl gte pods`: unknown subcommand 'gte' (hint: did you mean 'get'?)\n" +
+		"`helm gte x`: unknown subcommand 'gte' (hint: did you mean 'get'?)"
+	if got := result.Summary(SeverityWarn); got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+
+	single := ValidateLine("kubectl gte pods", []Validator{typoValidator{"kubectl"}})
+	if got := single.Summary(SeverityWarn); strings.Contains(got, "`") {
+		t.Errorf("Summary of a single command names it: %q", got)
+	}
+}
@@ -4,64 +4,25 @@ import (
 	"strings"
 
 	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
-// Validator validates Docker commands
-type Validator struct {
-	validPsFlags     map[string]bool
-	validStatsFlags  map[string]bool
-	validRunFlags    map[string]bool
+// Validator validates Docker commands. Subcommands and flags are checked
+// against the docker schema; the checks here add advice for common
+// mistakes.
+type Validator struct{}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:  "docker",
+		Tools: []string{"docker"},
+		New:   func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new Docker validator
 func NewValidator() *Validator {
-	return &Validator{
-		validPsFlags: map[string]bool{
-			"-a":         true,
-			"--all":      true,
-			"-f":         true,
-			"--filter":   true,
-			"--format":   true,
-			"-n":         true,
-			"--last":     true,
-			"-l":         true,
-			"--latest":   true,
-			"--no-trunc": true,
-			"-q":         true,
-			"--quiet":    true,
-			"-s":         true,
-			"--size":     true,
-		},
-		validStatsFlags: map[string]bool{
-			"-a":           true,
-			"--all":        true,
-			"--format":     true,
-			"--no-stream":  true,
-			"--no-trunc":   true,
-		},
-		validRunFlags: map[string]bool{
-			"-d":            true,
-			"--detach":      true,
-			"-e":            true,
-			"--env":         true,
-			"-p":            true,
-			"--publish":     true,
-			"-v":            true,
-			"--volume":      true,
-			"--name":        true,
-			"--rm":          true,
-			"-i":            true,
-			"--interactive": true,
-			"-t":            true,
-			"--tty":         true,
-			"--network":     true,
-			"--restart":     true,
-			"-w":            true,
-			"--workdir":     true,
-			"-u":            true,
-			"--user":        true,
-		},
-	}
+	return &Validator{}
 }
 
 // CanValidate returns true if this is a Docker command
@@ -70,113 +31,96 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate validates a Docker command
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	command = strings.TrimSpace(command)
 	parts := strings.Fields(command)
 
 	if len(parts) < 2 {
-		return validators.NewValidationError(
-			command,
+		return []validators.Finding{validators.NewFinding(
+			"docker/incomplete",
+			validators.SeverityBlock,
+			command, 0, len(command),
 			"incomplete docker command",
 			"docker commands need a subcommand (e.g., 'docker ps')",
-		)
+		)}
 	}
 
-	subCommand := parts[1]
-
-	switch subCommand {
+	var findings []validators.Finding
+	switch parts[1] {
 	case "ps":
-		return v.validatePs(parts[2:])
-	case "stats":
-		return v.validateStats(parts[2:])
+		findings = v.validatePs(command, parts[2:])
 	case "run":
-		return v.validateRun(parts[2:])
+		findings = v.validateRun(command, parts[2:])
 	}
 
-	// For other subcommands, just do basic validation
-	return nil
+	// Check every subcommand and flag against the docker schema
+	if checked, ok := schema.Check("docker", command); ok {
+		findings = validators.Merge(findings, checked)
+	}
+	return findings
+}
+
+// argFinding returns a finding on the i-th argument after the subcommand
+func argFinding(code, command string, i int, message, hint string) validators.Finding {
+	start, end := validators.WordSpan(command, i+2)
+	return validators.NewFinding(code, validators.SeverityBlock, command, start, end, message, hint)
 }
 
 // validatePs validates 'docker ps' command
-func (v *Validator) validatePs(args []string) error {
+func (v *Validator) validatePs(command string, args []string) []validators.Finding {
+	var findings []validators.Finding
+
 	// Check for common hallucinations
-	for _, arg := range args {
+	for i, arg := range args {
 		if strings.HasPrefix(arg, "--sort") {
-			return validators.NewValidationError(
-				"docker ps",
+			findings = append(findings, argFinding(
+				"docker/unknown-flag", command, i,
 				"docker ps does not have a --sort flag",
 				"use 'docker stats --no-stream | sort' or format with --format and pipe to sort",
-			)
-		}
-		
-		// Check if it's a flag (starts with -)
-		if strings.HasPrefix(arg, "-") {
-			flag := strings.Split(arg, "=")[0] // Handle --flag=value
-			if !v.validPsFlags[flag] {
-				return validators.NewValidationError(
-					"docker ps "+arg,
-					"invalid flag for docker ps",
-					"run 'docker ps --help' for valid flags",
-				)
-			}
+			))
 		}
 	}
 
-	return nil
-}
-
-// validateStats validates 'docker stats' command
-func (v *Validator) validateStats(args []string) error {
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "-") {
-			flag := strings.Split(arg, "=")[0]
-			if !v.validStatsFlags[flag] {
-				return validators.NewValidationError(
-					"docker stats "+arg,
-					"invalid flag for docker stats",
-					"run 'docker stats --help' for valid flags",
-				)
-			}
-		}
-	}
-
-	return nil
+	return findings
 }
 
 // validateRun validates 'docker run' command
-func (v *Validator) validateRun(args []string) error {
+func (v *Validator) validateRun(command string, args []string) []validators.Finding {
+	var findings []validators.Finding
+
 	// Check for port conflicts and other common issues
 	for i, arg := range args {
 		if strings.HasPrefix(arg, "-") {
 			flag := strings.Split(arg, "=")[0]
 			
-			// Skip known flags
-			if v.validRunFlags[flag] {
-				continue
-			}
-			
 			// Check for common typos
-			if strings.HasPrefix(flag, "--port") {
-				return validators.NewValidationError(
-					"docker run "+flag,
+			if flag == "--port" {
+				finding := argFinding(
+					"docker/unknown-flag", command, i,
 					"invalid flag --port, did you mean -p or --publish?",
 					"use -p host:container or --publish host:container",
 				)
+				if value := strings.TrimPrefix(arg, "--port"); value != "" {
+					finding = finding.WithFix("-p " + strings.TrimPrefix(value, "="))
+				} else {
+					finding = finding.WithFix("-p")
+				}
+				findings = append(findings, finding)
+				continue
 			}
 		}
 
 		// Check for port format
-		if (args[i-1] == "-p" || args[i-1] == "--publish") && i > 0 {
+		if i > 0 && (args[i-1] == "-p" || args[i-1] == "--publish" || args[i-1] == "--port") {
 			if !strings.Contains(arg, ":") {
-				return validators.NewValidationError(
-					"docker run -p "+arg,
+				findings = append(findings, argFinding(
+					"docker/port-format", command, i,
 					"port mapping must be in format host:container",
 					"example: -p 8080:80",
-				)
+				))
 			}
 		}
 	}
 
-	return nil
+	return findings
 }
-
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"docker run --port 8080:80 nginx", []spanOf{{"docker/unknown-flag", 11, "--port", "docker run -p 8080:80 nginx"}}},
+		{"docker run --port=8080:80 nginx", []spanOf{{"docker/unknown-flag", 11, "--port=8080:80", "docker run -p 8080:80 nginx"}}},
+		{"docker run -p 8080 nginx", []spanOf{{"docker/port-format", 14, "8080", ""}}},
+		{"docker ps --sort name", []spanOf{{"docker/unknown-flag", 10, "--sort", ""}}},
+		{"docker pss", []spanOf{{"docker/unknown-subcommand", 7, "pss", "docker ps"}}},
+		{"docker", []spanOf{{"docker/incomplete", 0, "docker", ""}}},
+		{"docker run nginx --port 80", []spanOf{
+			{"docker/unknown-flag", 17, "--port", "docker run nginx -p 80"},
+			{"docker/port-format", 24, "80", ""},
+		}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -3,13 +3,18 @@ package git
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
 // Validator implements the Validator interface for git commands.
 type Validator struct {
 	validSubcommands []string
 	dangerousOps     []DangerousOp
+	mistakes         []validators.Mistake
 }
 
 // DangerousOp represents a dangerous git operation.
@@ -20,6 +25,21 @@ type DangerousOp struct {
 	IsBlocking  bool // If true, block the command; if false, just warn
 }
 
+func init() {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	validators.Register(validators.Registration{
+		Name:    "git",
+		Tools:   []string{"git"},
+		Aliases: names,
+		New:     func() validators.Validator { return NewValidator() },
+	})
+}
+
 // NewValidator creates a new git validator.
 func NewValidator() *Validator {
 	return &Validator{
@@ -67,6 +87,18 @@ func NewValidator() *Validator {
 				IsBlocking: false,
 			},
 		},
+		// Commonly hallucinated git flags
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `push --force-all`, Message: "git push does not have a --force-all flag", Hint: "use --force or --force-with-lease"},
+			{Pattern: `commit --push`, Message: "git commit does not have a --push flag", Hint: "run 'git push' separately"},
+			{Pattern: `pull --commit`, Message: "git pull does not have a --commit flag", Hint: "commits are created automatically during merge"},
+			{Pattern: `log --sort`, Message: "git log does not have a --sort flag", Hint: "use --author-date-order or --date-order instead"},
+			{Pattern: `branch --rename-all`, Message: "git branch does not have a --rename-all flag", Hint: "use -m to rename a single branch"},
+			{Pattern: `merge --force-merge`, Message: "git merge does not have a --force-merge flag"},
+			{Pattern: `checkout --create`, Message: "git checkout does not have a --create flag", Hint: "use -b to create a new branch", Fix: "checkout -b"},
+			{Pattern: `stash --list`, Message: "git stash does not have a --list flag", Hint: "use 'git stash list'", Fix: "stash list"},
+			{Pattern: `rebase --interactive`, Severity: validators.SeverityInfo, Message: "'git rebase -i' is the usual spelling of --interactive", Fix: "rebase -i"},
+		}),
 	}
 }
 
@@ -97,67 +129,47 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if a git command is valid.
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	// Resolve Oh My Zsh aliases to git commands
-	command = v.resolveGitAlias(command)
+	expansion := validators.Expand(command, aliases)
+	command = expansion.Expanded
 	
 	if !strings.HasPrefix(command, "git") {
 		return nil // Not a git command
 	}
 
 	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
-	}
+	findings := validators.FindMistakes("git/unknown-flag", command, v.mistakes)
 
-	// Check for invalid subcommands
-	if err := v.checkSubcommand(command); err != nil {
-		return err
+	// Check subcommands and flags against the git schema if there is
+	// one, otherwise against the known subcommands
+	if checked, ok := schema.Check("git", command); ok {
+		findings = validators.Merge(findings, checked)
+	} else if finding, ok := v.checkSubcommand(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for dangerous operations
-	if err := v.checkDangerousOps(command); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// checkHallucinatedFlags checks for commonly hallucinated git flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`push --force-all`:     "git push does not have a --force-all flag. Use --force or --force-with-lease.",
-		`commit --push`:        "git commit does not have a --push flag. Run 'git push' separately.",
-		`pull --commit`:        "git pull does not have a --commit flag. Commits are created automatically during merge.",
-		`log --sort`:           "git log does not have a --sort flag. Use --author-date-order or --date-order instead.",
-		`branch --rename-all`:  "git branch does not have a --rename-all flag. Use -m to rename a single branch.",
-		`merge --force-merge`:  "git merge does not have a --force-merge flag.",
-		`checkout --create`:    "git checkout does not have a --create flag. Use -b to create a new branch.",
-		`stash --list`:         "Use 'git stash list' (without --list flag).",
-		`rebase --interactive`: "Use 'git rebase -i' (not --interactive).",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
+	if finding, ok := v.checkDangerousOps(command); ok {
+		findings = append(findings, finding)
 	}
 
-	return nil
+	return expansion.Findings(findings)
 }
 
 // checkSubcommand validates the git subcommand.
-func (v *Validator) checkSubcommand(command string) error {
+func (v *Validator) checkSubcommand(command string) (validators.Finding, bool) {
 	parts := strings.Fields(command)
 	if len(parts) < 2 {
-		return fmt.Errorf("incomplete git command")
+		return validators.NewFinding("git/incomplete", validators.SeverityBlock, command, 0, len(command),
+			"incomplete git command", "add a subcommand, e.g. 'git status'"), true
 	}
 
 	subcommand := parts[1]
 	
 	// Check if it's a valid subcommand (or a flag like --version)
 	if strings.HasPrefix(subcommand, "-") {
-		return nil // It's a flag, not a subcommand
+		return validators.Finding{}, false // It's a flag, not a subcommand
 	}
 	
 	// Check if it's a valid subcommand
@@ -170,93 +182,77 @@ func (v *Validator) checkSubcommand(command string) error {
 	}
 
 	if !isValid {
-		return fmt.Errorf("'%s' is not a valid git subcommand. Use 'git --help' to see valid commands.", subcommand)
+		start, end := validators.WordSpan(command, 1)
+		return validators.NewFinding("git/unknown-subcommand", validators.SeverityBlock, command, start, end,
+			fmt.Sprintf("'%s' is not a valid git subcommand", subcommand), "use 'git --help' to see valid commands").DidYouMean(subcommand, v.validSubcommands), true
 	}
 
-	return nil
+	return validators.Finding{}, false
 }
 
 // checkDangerousOps checks for dangerous git operations.
-func (v *Validator) checkDangerousOps(command string) error {
+func (v *Validator) checkDangerousOps(command string) (validators.Finding, bool) {
 	for _, danger := range v.dangerousOps {
-		if danger.Pattern.MatchString(command) {
+		if loc := danger.Pattern.FindStringIndex(command); loc != nil {
+			code, severity := "git/dangerous", validators.SeverityWarn
 			if danger.IsBlocking {
-				return fmt.Errorf("🚨 BLOCKED: %s\n💡 Suggestion: %s", danger.Warning, danger.Suggestion)
+				code, severity = "git/force-push-main", validators.SeverityBlock
 			}
-			return fmt.Errorf("⚠️  Warning: %s\n💡 Suggestion: %s", danger.Warning, danger.Suggestion)
+			return validators.NewFinding(code, severity, command, loc[0], loc[1], danger.Warning, danger.Suggestion), true
 		}
 	}
-	return nil
+	return validators.Finding{}, false
 }
 
-// resolveGitAlias converts Oh My Zsh git aliases to full git commands.
-func (v *Validator) resolveGitAlias(command string) string {
-	aliases := map[string]string{
-		"gco":  "git checkout",
-		"gcb":  "git checkout -b",
-		"gcm":  "git checkout master",
-		"gcd":  "git checkout develop",
-		"gcmg": "git checkout main",
-		"ga":    "git add",
-		"gaa":   "git add --all",
-		"gc":    "git commit -v",
-		"gc!":   "git commit -v --amend",
-		"gcmsg": "git commit -m",
-		"gca":   "git commit -v -a",
-		"gca!":  "git commit -v -a --amend",
-		"gcam":  "git commit -a -m",
-		"gb":  "git branch",
-		"gba": "git branch -a",
-		"gbd": "git branch -d",
-		"gbD": "git branch -D",
-		"gst":  "git status",
-		"gss":  "git status -s",
-		"gd":   "git diff",
-		"gdca": "git diff --cached",
-		"gp":   "git push",
-		"gpf":  "git push --force",
-		"gpf!": "git push --force",
-		"gl":   "git pull",
-		"ggl":  "git pull origin",
-		"ggp":  "git push origin",
-		"gf":   "git fetch",
-		"gfa":  "git fetch --all",
-		"glog":  "git log --oneline --decorate",
-		"glol":  "git log --graph --pretty='%Cred%h%Creset -%C(auto)%d%Creset %s %Cgreen(%cr) %C(bold blue)<%an>%Creset'",
-		"glola": "git log --graph --pretty='%Cred%h%Creset -%C(auto)%d%Creset %s %Cgreen(%cr) %C(bold blue)<%an>%Creset' --all",
-		"gm":   "git merge",
-		"grb":  "git rebase",
-		"grbi": "git rebase -i",
-		"grbc": "git rebase --continue",
-		"grba": "git rebase --abort",
-		"gsta": "git stash",
-		"gstp": "git stash pop",
-		"gstl": "git stash list",
-		"gr":   "git remote",
-		"gra":  "git remote add",
-		"grv":  "git remote -v",
-		"grmv": "git remote rename",
-		"grrm": "git remote remove",
-		"gcl": "git clone",
-		"grh":   "git reset",
-		"grhh":  "git reset --hard",
-		"gclean": "git clean -fd",
-	}
-	
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return command
-	}
-	
-	firstWord := parts[0]
-	if fullCmd, exists := aliases[firstWord]; exists {
-		remaining := ""
-		if len(parts) > 1 {
-			remaining = " " + strings.Join(parts[1:], " ")
-		}
-		return fullCmd + remaining
-	}
-	
-	return command
+// aliases maps Oh My Zsh git aliases to full git commands.
+var aliases = map[string]string{
+	"gco":  "git checkout",
+	"gcb":  "git checkout -b",
+	"gcm":  "git checkout master",
+	"gcd":  "git checkout develop",
+	"gcmg": "git checkout main",
+	"ga":    "git add",
+	"gaa":   "git add --all",
+	"gc":    "git commit -v",
+	"gc!":   "git commit -v --amend",
+	"gcmsg": "git commit -m",
+	"gca":   "git commit -v -a",
+	"gca!":  "git commit -v -a --amend",
+	"gcam":  "git commit -a -m",
+	"gb":  "git branch",
+	"gba": "git branch -a",
+	"gbd": "git branch -d",
+	"gbD": "git branch -D",
+	"gst":  "git status",
+	"gss":  "git status -s",
+	"gd":   "git diff",
+	"gdca": "git diff --cached",
+	"gp":   "git push",
+	"gpf":  "git push --force",
+	"gpf!": "git push --force",
+	"gl":   "git pull",
+	"ggl":  "git pull origin",
+	"ggp":  "git push origin",
+	"gf":   "git fetch",
+	"gfa":  "git fetch --all",
+	"glog":  "git log --oneline --decorate",
+	"glol":  "git log --graph --pretty='%Cred%h%Creset -%C(auto)%d%Creset %s %Cgreen(%cr) %C(bold blue)<%an>%Creset'",
+	"glola": "git log --graph --pretty='%Cred%h%Creset -%C(auto)%d%Creset %s %Cgreen(%cr) %C(bold blue)<%an>%Creset' --all",
+	"gm":   "git merge",
+	"grb":  "git rebase",
+	"grbi": "git rebase -i",
+	"grbc": "git rebase --continue",
+	"grba": "git rebase --abort",
+	"gsta": "git stash",
+	"gstp": "git stash pop",
+	"gstl": "git stash list",
+	"gr":   "git remote",
+	"gra":  "git remote add",
+	"grv":  "git remote -v",
+	"grmv": "git remote rename",
+	"grrm": "git remote remove",
+	"gcl": "git clone",
+	"grh":   "git reset",
+	"grhh":  "git reset --hard",
+	"gclean": "git clean -fd",
 }
-
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"git checkout --create feature", []spanOf{{"git/unknown-flag", 4, "checkout --create", "git checkout -b feature"}}},
+		{"git rebase --interactive HEAD~3", []spanOf{{"git/unknown-flag", 4, "rebase --interactive", "git rebase -i HEAD~3"}}},
+		{"git stauts", []spanOf{{"git/unknown-subcommand", 4, "stauts", "git status"}}},
+		{"git", []spanOf{{"git/incomplete", 0, "git", ""}}},
+		{"git push --force origin main", []spanOf{{"git/force-push-main", 4, "push --force origin main", ""}}},
+		{"git reset --hard HEAD~1", []spanOf{{"git/dangerous", 4, "reset --hard", ""}}},
+		// Spans inside an alias widen to the whole alias
+		{"gco --create feature", []spanOf{{"git/unknown-flag", 0, "gco --create", "git checkout -b feature"}}},
+		{"gpf origin main", []spanOf{{"git/force-push-main", 0, "gpf origin main", ""}}},
+		{"grhh", []spanOf{{"git/dangerous", 0, "grhh", ""}}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
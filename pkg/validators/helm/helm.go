@@ -4,12 +4,36 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
 // Validator implements the Validator interface for helm commands.
 type Validator struct {
+	validators.Environment
+
 	validSubcommands []string
 	dangerousOps     []string
+	mistakes         []validators.Mistake
+}
+
+// helm3 is Helm 3, whose releases are namespaced and removed with uninstall
+var helm3 = toolversion.MustParseRange(">=3")
+
+// isHelm2 reports whether the installed helm is known to be Helm 2; an
+// unknown version is taken to be Helm 3, which replaced it in 2019
+func isHelm2(version toolversion.Version) bool {
+	return !version.IsZero() && !helm3.Contains(version)
+}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:  "helm",
+		Tools: []string{"helm"},
+		New:   func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new helm validator.
@@ -24,6 +48,21 @@ func NewValidator() *Validator {
 		dangerousOps: []string{
 			"uninstall", "delete", "rollback",
 		},
+		// Commonly hallucinated helm flags
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `install --update`, Message: "helm install does not have --update", Hint: "use 'helm upgrade --install' instead", Fix: "upgrade --install"},
+			{Pattern: `upgrade --force-install`, Message: "helm upgrade does not have --force-install", Hint: "use 'helm upgrade --install' instead", Fix: "upgrade --install"},
+			{Pattern: `--auto-approve`, Message: "helm does not have --auto-approve", Hint: "helm operations proceed without confirmation by default"},
+			{Pattern: `list --sort`, Message: "helm list does not have --sort", Hint: "use --date or --reverse instead"},
+			{Pattern: `--force-yes`, Message: "helm does not have --force-yes"},
+			{Pattern: `install --dry-run(\s|$)`, Severity: validators.SeverityWarn, Message: "use --dry-run=client or --dry-run=server, not just --dry-run", Fix: "install --dry-run=client$1", Versions: ">=3.13"},
+			{Pattern: `--dry-run=(client|server)`, Message: "--dry-run only takes a value since helm 3.13", Hint: "use --dry-run", Fix: "--dry-run", Versions: "<3.13"},
+			{Pattern: `\buninstall\b`, Message: "helm 2 has no uninstall", Hint: "use 'helm delete --purge'", Fix: "delete --purge", Versions: "<3"},
+			{Pattern: `--no-hooks`, Severity: validators.SeverityInfo, Message: "--no-hooks skips pre/post hooks"},
+			{Pattern: `repo add --update`, Message: "helm repo add does not have --update", Hint: "run 'helm repo update' separately"},
+			{Pattern: `--version latest`, Message: "helm doesn't support 'latest' as a version", Hint: "omit --version to get the latest"},
+			{Pattern: `install --replace`, Message: "helm install does not have --replace", Hint: "use 'helm upgrade --install' instead", Fix: "upgrade --install"},
+		}),
 	}
 }
 
@@ -33,66 +72,44 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if a helm command is valid.
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	if !strings.HasPrefix(command, "helm") {
 		return nil // Not a helm command
 	}
 
-	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
-	}
+	// Check for common hallucinated flags, and those of another version
+	version := toolversion.Lookup("helm")
+	findings := validators.FindMistakes("helm/unknown-flag", command, validators.ForVersion(v.mistakes, version))
 
-	// Check for invalid subcommands
-	if err := v.checkSubcommand(command); err != nil {
-		return err
+	// Check subcommands and flags against the helm schema if there is
+	// one, otherwise against the known subcommands
+	if checked, ok := schema.Check("helm", command); ok {
+		findings = validators.Merge(findings, checked)
+	} else if finding, ok := v.checkSubcommand(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for dangerous operations
-	if warning := v.checkDangerousOps(command); warning != "" {
-		return fmt.Errorf("⚠️  Warning: %s", warning)
-	}
+	findings = append(findings, v.checkDangerousOps(command, version)...)
 
 	// Check for common mistakes
-	if err := v.checkCommonMistakes(command); err != nil {
-		return err
-	}
+	findings = append(findings, v.checkCommonMistakes(command, version)...)
 
-	return nil
-}
-
-// checkHallucinatedFlags checks for commonly hallucinated helm flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`install --update`:        "helm install does not have --update. Use 'helm upgrade --install' instead.",
-		`upgrade --force-install`: "helm upgrade does not have --force-install. Use 'helm upgrade --install' instead.",
-		`--auto-approve`:          "helm does not have --auto-approve. Helm operations proceed without confirmation by default.",
-		`list --sort`:             "helm list does not have --sort. Use --date or --reverse instead.",
-		`--force-yes`:             "helm does not have --force-yes.",
-		`install --dry-run`:       "use --dry-run=client or --dry-run=server, not just --dry-run.",
-		`--no-hooks`:              "use --no-hooks (correct), but be aware this skips pre/post hooks.",
-		`repo add --update`:       "helm repo add does not have --update. Run 'helm repo update' separately.",
-		`--version latest`:        "helm doesn't support 'latest' as a version. Omit --version to get the latest.",
-		`install --replace`:       "helm install does not have --replace. Use 'helm upgrade --install' instead.",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
-	}
-
-	return nil
+	return findings
 }
 
 // checkSubcommand validates the helm subcommand.
-func (v *Validator) checkSubcommand(command string) error {
+func (v *Validator) checkSubcommand(command string) (validators.Finding, bool) {
 	parts := strings.Fields(command)
 	if len(parts) < 2 {
-		return fmt.Errorf("incomplete helm command")
+		return validators.NewFinding("helm/incomplete", validators.SeverityBlock, command, 0, len(command),
+			"incomplete helm command", "add a subcommand, e.g. 'helm list'"), true
 	}
 
 	subcommand := parts[1]
+	if subcommand == "delete" {
+		return validators.Finding{}, false // Helm 2 name, see checkCommonMistakes
+	}
 	
 	// Check if it's a valid subcommand
 	isValid := false
@@ -104,59 +121,78 @@ func (v *Validator) checkSubcommand(command string) error {
 	}
 
 	if !isValid {
-		return fmt.Errorf("'%s' is not a valid helm subcommand. Use 'helm --help' to see valid commands.", subcommand)
+		start, end := validators.WordSpan(command, 1)
+		return validators.NewFinding("helm/unknown-subcommand", validators.SeverityBlock, command, start, end,
+			fmt.Sprintf("'%s' is not a valid helm subcommand", subcommand), "use 'helm --help' to see valid commands").DidYouMean(subcommand, v.validSubcommands), true
 	}
 
-	return nil
+	return validators.Finding{}, false
 }
 
 // checkDangerousOps checks for dangerous helm operations.
-func (v *Validator) checkDangerousOps(command string) string {
+func (v *Validator) checkDangerousOps(command string, version toolversion.Version) []validators.Finding {
+	var findings []validators.Finding
 	for _, danger := range v.dangerousOps {
-		if strings.Contains(command, danger) {
-			return fmt.Sprintf("Dangerous operation detected: 'helm %s'. This will modify or remove deployed applications.", danger)
+		if i := validators.IndexWord(command, danger); i >= 0 {
+			findings = append(findings, validators.NewFinding("helm/dangerous", validators.SeverityWarn, command, i, i+len(danger),
+				fmt.Sprintf("Dangerous operation detected: 'helm %s'. This will modify or remove deployed applications.", danger)+v.ProductionNote(command),
+				"check 'helm history' first"))
+			break
 		}
 	}
 
 	// Check for uninstall --purge (deprecated in Helm 3)
-	if strings.Contains(command, "uninstall") && strings.Contains(command, "--purge") {
-		return "The --purge flag is deprecated in Helm 3. Uninstall now purges by default."
+	if i := strings.Index(command, "--purge"); i >= 0 && strings.Contains(command, "uninstall") && !isHelm2(version) {
+		findings = append(findings, validators.NewFinding("helm/deprecated", validators.SeverityWarn, command, i, i+len("--purge"),
+			"The --purge flag is deprecated in Helm 3", "uninstall now purges by default; drop the flag"))
 	}
 
-	return ""
+	return findings
 }
 
 // checkCommonMistakes checks for common helm command mistakes.
-func (v *Validator) checkCommonMistakes(command string) error {
+func (v *Validator) checkCommonMistakes(command string, version toolversion.Version) []validators.Finding {
+	var findings []validators.Finding
+	if isHelm2(version) {
+		return findings // Helm 2 takes the chart first and names releases with --name
+	}
+
 	// Check for helm 2 vs helm 3 differences
-	if strings.Contains(command, " delete ") {
-		return fmt.Errorf("'helm delete' is deprecated in Helm 3. Use 'helm uninstall' instead.")
+	if i := strings.Index(command, " delete "); i >= 0 {
+		findings = append(findings, validators.NewFinding("helm/deprecated", validators.SeverityBlock, command, i+1, i+len(" delete"),
+			"'helm delete' is deprecated in Helm 3", "use 'helm uninstall' instead").WithFix("uninstall"))
 	}
 
-	// Check for missing namespace in helm 3
-	if (strings.Contains(command, "install") || strings.Contains(command, "upgrade")) &&
-		!strings.Contains(command, "-n ") && !strings.Contains(command, "--namespace") {
-		return fmt.Errorf("⚠️  No namespace specified. In Helm 3, releases are namespaced. Add -n <namespace> or use --namespace.")
+	// Check for missing namespace in helm 3 ("install" is part of "uninstall")
+	installs := validators.IndexWord(command, "install") >= 0 || validators.IndexWord(command, "upgrade") >= 0
+	if installs && !strings.Contains(command, "-n ") && !strings.Contains(command, "--namespace") {
+		findings = append(findings, validators.NewFinding("helm/no-namespace", validators.SeverityWarn, command, len(command), len(command),
+			"No namespace specified. In Helm 3, releases are namespaced.", "add -n <namespace> or use --namespace"))
 	}
 
 	// Check for install without release name
 	if strings.Contains(command, "helm install") {
 		parts := strings.Fields(command)
 		if len(parts) < 4 {
-			return fmt.Errorf("helm install requires: helm install [NAME] [CHART] [flags]")
+			findings = append(findings, validators.NewFinding("helm/incomplete", validators.SeverityBlock, command, 0, len(command),
+				"helm install requires: helm install [NAME] [CHART] [flags]", "name the release or pass --generate-name"))
+			return findings
 		}
 	}
 
 	// Check for missing repo name format
-	if strings.Contains(command, "install") || strings.Contains(command, "upgrade") {
-		if matched, _ := regexp.MatchString(`(install|upgrade)\s+\S+\s+\S+/\S+`, command); !matched {
+	if installs {
+		if !chartFormat.MatchString(command) {
 			// Check if it's a local chart (.)
 			if !strings.Contains(command, " ./") && !strings.Contains(command, " .") {
-				return fmt.Errorf("⚠️  Chart should be in format: repo/chart or ./local-path. Did you forget 'helm repo add'?")
+				findings = append(findings, validators.NewFinding("helm/chart-format", validators.SeverityWarn, command, len(command), len(command),
+					"Chart should be in format: repo/chart or ./local-path", "did you forget 'helm repo add'?"))
 			}
 		}
 	}
 
-	return nil
+	return findings
 }
 
+// chartFormat matches a release name followed by a repo/chart reference
+var chartFormat = regexp.MustCompile(`(install|upgrade)\s+\S+\s+\S+/\S+`)
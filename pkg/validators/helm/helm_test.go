@@ -0,0 +1,59 @@
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	// Without the tool on PATH only the rules for every version apply
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"helm instal web bitnami/nginx -n web", []spanOf{{"helm/unknown-subcommand", 5, "instal", "helm install web bitnami/nginx -n web"}}},
+		{"helm list --sort", []spanOf{{"helm/unknown-flag", 5, "list --sort", ""}}},
+		{"helm install web bitnami/nginx", []spanOf{{"helm/no-namespace", 30, "", ""}}},
+		{"helm upgrade web nginx -n web", []spanOf{{"helm/chart-format", 29, "", ""}}},
+		{"helm delete web -n web", []spanOf{
+			{"helm/dangerous", 5, "delete", ""},
+			{"helm/deprecated", 5, "delete", "helm uninstall web -n web"},
+		}},
+		// uninstall is not install: no chart or namespace advice
+		{"helm uninstall web -n web", []spanOf{{"helm/dangerous", 5, "uninstall", ""}}},
+		{"helm uninstall web --purge -n web", []spanOf{
+			{"helm/dangerous", 5, "uninstall", ""},
+			{"helm/deprecated", 19, "--purge", ""},
+		}},
+		{"helm rollback web 3 -n web", []spanOf{{"helm/dangerous", 5, "rollback", ""}}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
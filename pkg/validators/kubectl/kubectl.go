@@ -2,16 +2,33 @@ package kubectl
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 	"gopkg.in/yaml.v3"
 )
 
 // Validator implements the Validator interface for kubectl commands.
 type Validator struct {
+	validators.Environment
+
 	validSubcommands []string
 	dangerousOps     []string
+	mistakes         []validators.Mistake
+}
+
+// aliases are expanded before validation
+var aliases = map[string]string{"k": "kubectl"}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:    "kubectl",
+		Tools:   []string{"kubectl"},
+		Aliases: []string{"k"},
+		New:     func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new kubectl validator.
@@ -23,10 +40,30 @@ func NewValidator() *Validator {
 			"port-forward", "proxy", "cp", "attach", "run", "explain",
 			"drain", "cordon", "uncordon", "taint", "label", "annotate",
 			"config", "cluster-info", "top", "api-resources", "api-versions",
+			"events",
 		},
+		// The most specific operation is reported
 		dangerousOps: []string{
-			"delete", "drain", "delete --all", "delete namespace",
+			"delete --all", "delete namespace", "delete", "drain",
 		},
+		// Commonly hallucinated kubectl flags
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `--sort([\s=])`, Message: "kubectl get does not have a --sort flag", Hint: "use --sort-by instead", Fix: "--sort-by$1"},
+			{Pattern: `--filter[\s=]`, Message: "kubectl does not have a --filter flag", Hint: "use -l (label selector) or field selectors"},
+			{Pattern: `--format([\s=])`, Message: "kubectl does not have a --format flag", Hint: "use -o or --output instead", Fix: "--output$1"},
+			{Pattern: `--limit[\s=]`, Message: "kubectl does not have a --limit flag", Hint: "use --field-selector or pipe to head"},
+			{Pattern: `--where[\s=]`, Message: "kubectl does not have a --where flag", Hint: "use --field-selector instead"},
+			{Pattern: `--order-by([\s=])`, Message: "kubectl does not have an --order-by flag", Hint: "use --sort-by instead", Fix: "--sort-by$1"},
+			{Pattern: `get pods --memory`, Message: "kubectl get pods does not show memory directly", Hint: "use 'kubectl top pods' instead", Fix: "top pods"},
+			{Pattern: `get pods --cpu`, Message: "kubectl get pods does not show CPU directly", Hint: "use 'kubectl top pods' instead", Fix: "top pods"},
+			{Pattern: `logs --grep`, Message: "kubectl logs does not have a --grep flag", Hint: "pipe to grep instead"},
+			{Pattern: `apply --force-delete`, Message: "kubectl apply does not have --force-delete", Hint: "use 'kubectl delete --force' separately"},
+
+			// --dry-run took a value and events became a command in later releases
+			{Pattern: `--dry-run(=true)?(\s|$)`, Severity: validators.SeverityWarn, Message: "--dry-run without a value is deprecated since kubectl 1.18", Hint: "use --dry-run=client or --dry-run=server", Fix: "--dry-run=client$2", Versions: ">=1.18"},
+			{Pattern: `--dry-run=(client|server|none)`, Message: "kubectl before 1.18 takes --dry-run without a value", Hint: "use --dry-run", Fix: "--dry-run", Versions: "<1.18"},
+			{Pattern: `^kubectl events\b`, Message: "kubectl events needs kubectl 1.26 or later", Hint: "use 'kubectl get events'", Fix: "kubectl get events", Versions: "<1.26"},
+		}),
 	}
 }
 
@@ -36,30 +73,30 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if a kubectl command is valid.
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	// Handle alias 'k' for 'kubectl'
-	if strings.HasPrefix(command, "k ") {
-		command = "kubectl" + command[1:]
-	}
-	
+	expansion := validators.Expand(command, aliases)
+	command = expansion.Expanded
+
 	if !strings.HasPrefix(command, "kubectl") {
 		return nil // Not a kubectl command
 	}
 
-	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
-	}
+	// Check for common hallucinated flags, and those of another version
+	version := toolversion.Lookup("kubectl")
+	findings := validators.FindMistakes("kubectl/unknown-flag", command, validators.ForVersion(v.mistakes, version))
 
-	// Check for invalid subcommands
-	if err := v.checkSubcommand(command); err != nil {
-		return err
+	// Check subcommands and flags against the kubectl schema if there is
+	// one, otherwise against the known subcommands
+	if checked, ok := schema.Check("kubectl", command); ok {
+		findings = validators.Merge(findings, checked)
+	} else if finding, ok := v.checkSubcommand(command); ok {
+		findings = append(findings, finding)
 	}
 
-	// Check for dangerous operations
-	if warning := v.checkDangerousOps(command); warning != "" {
-		// Don't block, just warn
-		return fmt.Errorf("⚠️  Warning: %s", warning)
+	// Check for dangerous operations (don't block, just warn)
+	if finding, ok := v.checkDangerousOps(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for YAML syntax if apply/create with -f
@@ -70,38 +107,15 @@ func (v *Validator) Validate(command string) error {
 		}
 	}
 
-	return nil
-}
-
-// checkHallucinatedFlags checks for commonly hallucinated kubectl flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`--sort[\s=]`:          "kubectl get does not have a --sort flag. Use --sort-by instead.",
-		`--filter[\s=]`:        "kubectl does not have a --filter flag. Use -l (label selector) or field selectors.",
-		`--format[\s=]`:        "kubectl does not have a --format flag. Use -o or --output instead.",
-		`--limit[\s=]`:         "kubectl does not have a --limit flag. Use --field-selector or pipe to head.",
-		`--where[\s=]`:         "kubectl does not have a --where flag. Use --field-selector instead.",
-		`--order-by[\s=]`:      "kubectl does not have an --order-by flag. Use --sort-by instead.",
-		`get pods --memory`:    "kubectl get pods does not show memory directly. Use 'kubectl top pods' instead.",
-		`get pods --cpu`:       "kubectl get pods does not show CPU directly. Use 'kubectl top pods' instead.",
-		`logs --grep`:          "kubectl logs does not have a --grep flag. Pipe to grep instead.",
-		`apply --force-delete`: "kubectl apply does not have --force-delete. Use 'kubectl delete --force' separately.",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
-	}
-
-	return nil
+	return expansion.Findings(findings)
 }
 
 // checkSubcommand validates the kubectl subcommand.
-func (v *Validator) checkSubcommand(command string) error {
+func (v *Validator) checkSubcommand(command string) (validators.Finding, bool) {
 	parts := strings.Fields(command)
 	if len(parts) < 2 {
-		return fmt.Errorf("incomplete kubectl command")
+		return validators.NewFinding("kubectl/incomplete", validators.SeverityBlock, command, 0, len(command),
+			"incomplete kubectl command", "add a subcommand, e.g. 'kubectl get pods'"), true
 	}
 
 	subcommand := parts[1]
@@ -116,20 +130,23 @@ func (v *Validator) checkSubcommand(command string) error {
 	}
 
 	if !isValid {
-		return fmt.Errorf("'%s' is not a valid kubectl subcommand. Use 'kubectl --help' to see valid commands.", subcommand)
+		start, end := validators.WordSpan(command, 1)
+		return validators.NewFinding("kubectl/unknown-subcommand", validators.SeverityBlock, command, start, end,
+			fmt.Sprintf("'%s' is not a valid kubectl subcommand", subcommand), "use 'kubectl --help' to see valid commands").DidYouMean(subcommand, v.validSubcommands), true
 	}
 
-	return nil
+	return validators.Finding{}, false
 }
 
 // checkDangerousOps checks for dangerous kubectl operations.
-func (v *Validator) checkDangerousOps(command string) string {
+func (v *Validator) checkDangerousOps(command string) (validators.Finding, bool) {
 	for _, danger := range v.dangerousOps {
-		if strings.Contains(command, danger) {
-			return fmt.Sprintf("Dangerous operation detected: '%s'. Ensure this is intentional.", danger)
+		if i := validators.IndexWord(command, danger); i >= 0 {
+			return validators.NewFinding("kubectl/dangerous", validators.SeverityWarn, command, i, i+len(danger),
+				fmt.Sprintf("Dangerous operation detected: '%s'.", danger)+v.ProductionNote(command), "ensure this is intentional"), true
 		}
 	}
-	return ""
+	return validators.Finding{}, false
 }
 
 // ValidateYAML validates Kubernetes YAML content.
@@ -0,0 +1,56 @@
+package kubectl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	// Without the tool on PATH only the rules for every version apply
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"kubectl get pods --sort .metadata.name", []spanOf{{"kubectl/unknown-flag", 17, "--sort ", "kubectl get pods --sort-by .metadata.name"}}},
+		{"kubectl get pods --format json", []spanOf{{"kubectl/unknown-flag", 17, "--format ", "kubectl get pods --output json"}}},
+		{"kubectl gte pods", []spanOf{{"kubectl/unknown-subcommand", 8, "gte", "kubectl get pods"}}},
+		{"kubectl", []spanOf{{"kubectl/incomplete", 0, "kubectl", ""}}},
+		{"kubectl delete pod web", []spanOf{{"kubectl/dangerous", 8, "delete", ""}}},
+		{"kubectl delete namespace prod", []spanOf{{"kubectl/dangerous", 8, "delete namespace", ""}}},
+		{"kubectl delete --all pods", []spanOf{{"kubectl/dangerous", 8, "delete --all", ""}}},
+		{"kubectl drain node-1", []spanOf{{"kubectl/dangerous", 8, "drain", ""}}},
+		{"kubectl get pods -l app=delete-me", nil},
+		// Spans after an alias shift back to the command as written
+		{"k gte pods", []spanOf{{"kubectl/unknown-subcommand", 2, "gte", "k get pods"}}},
+		{"k get pods --sort=.metadata.name", []spanOf{{"kubectl/unknown-flag", 11, "--sort=", "k get pods --sort-by=.metadata.name"}}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
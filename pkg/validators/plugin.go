@@ -0,0 +1,208 @@
+package validators
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PluginProtocolVersion is the version of the plugin protocol
+const PluginProtocolVersion = 1
+
+// PluginTimeout bounds each run of a plugin
+var PluginTimeout = 2 * time.Second
+
+// PluginRequest is written to a plugin's stdin. Action is "describe" or
+// "validate"; Command is set for validate.
+type PluginRequest struct {
+	Version int    `json:"version"`
+	Action  string `json:"action"`
+	Command string `json:"command,omitempty"`
+}
+
+// PluginDescription is a plugin's answer to describe
+type PluginDescription struct {
+	Name     string   `json:"name"`
+	Tools    []string `json:"tools"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+}
+
+// PluginFinding is a finding as reported by a plugin. Start and End are
+// byte offsets in the command; Fix replaces that span.
+type PluginFinding struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Hint     string   `json:"hint,omitempty"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	Fix      *string  `json:"fix,omitempty"`
+}
+
+// PluginResponse is a plugin's answer to validate
+type PluginResponse struct {
+	Findings []PluginFinding `json:"findings"`
+}
+
+// Plugin is an external validator: an executable that reads a
+// PluginRequest as JSON on stdin and writes its answer as JSON on stdout
+type Plugin struct {
+	Path string
+	PluginDescription
+}
+
+// LoadPlugins registers every executable in dir as a validator plugin. A
+// missing dir is not an error; plugins that cannot be registered are
+// skipped and reported together.
+func LoadPlugins(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path) // Follows symlinks
+		if strings.HasPrefix(entry.Name(), ".") || err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		plugin, err := DescribePlugin(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := register(Registration{
+			Name:     plugin.Name,
+			Tools:    plugin.Tools,
+			Aliases:  plugin.Aliases,
+			Priority: plugin.Priority,
+			Source:   path,
+			New:      func() Validator { return plugin },
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DescribePlugin asks the executable at path what it validates
+func DescribePlugin(path string) (*Plugin, error) {
+	plugin := &Plugin{Path: path}
+	if err := plugin.run(PluginRequest{Action: "describe"}, &plugin.PluginDescription); err != nil {
+		return nil, err
+	}
+	if plugin.Name == "" {
+		plugin.Name = filepath.Base(path)
+	}
+	if len(plugin.Tools) == 0 {
+		return nil, fmt.Errorf("%s: plugin names no tools", path)
+	}
+	return plugin, nil
+}
+
+// CanValidate returns true if the first word of command is one of the
+// plugin's tools or aliases
+func (p *Plugin) CanValidate(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, names := range [][]string{p.Tools, p.Aliases} {
+		for _, name := range names {
+			if fields[0] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Validate runs the plugin on command. A plugin that fails yields a single
+// info finding, so a broken plugin never blocks a suggestion.
+func (p *Plugin) Validate(command string) []Finding {
+	var resp PluginResponse
+	if err := p.run(PluginRequest{Action: "validate", Command: command}, &resp); err != nil {
+		return []Finding{NewFinding("plugin/error", SeverityInfo, command, 0, len(command),
+			fmt.Sprintf("validator plugin %s failed: %v", p.Name, err), "")}
+	}
+
+	findings := make([]Finding, 0, len(resp.Findings))
+	for _, pf := range resp.Findings {
+		findings = append(findings, pf.finding(p.Name, command))
+	}
+	return findings
+}
+
+// finding converts a plugin finding, defaulting what the plugin left out:
+// the code to the plugin name, the severity to warn and an invalid span to
+// the whole command (which drops the fix)
+func (pf PluginFinding) finding(name, command string) Finding {
+	code := pf.Code
+	if code == "" {
+		code = name
+	}
+	severity := pf.Severity
+	if severity.Rank() == 0 {
+		severity = SeverityWarn
+	}
+
+	valid := 0 <= pf.Start && pf.Start <= pf.End && pf.End <= len(command)
+	start, end := pf.Start, pf.End
+	if !valid {
+		start, end = 0, len(command)
+	}
+
+	finding := NewFinding(code, severity, command, start, end, pf.Message, pf.Hint)
+	if pf.Fix != nil && valid {
+		finding = finding.WithFix(*pf.Fix)
+	}
+	return finding
+}
+
+// run sends one request to the plugin and decodes its answer into out
+func (p *Plugin) run(req PluginRequest, out interface{}) error {
+	req.Version = PluginProtocolVersion
+	input, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Processes the plugin started may hold its output open after it is
+	// killed; stop waiting for them shortly after
+	cmd.WaitDelay = 100 * time.Millisecond
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: timed out after %s", p.Path, PluginTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v: %s", p.Path, err, msg)
+		}
+		return fmt.Errorf("%s: %v", p.Path, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return fmt.Errorf("%s: invalid %s response: %v", p.Path, req.Action, err)
+	}
+	return nil
+}
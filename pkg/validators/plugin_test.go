@@ -0,0 +1,249 @@
+package validators
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePlugin writes an executable shell script answering describe with
+// description and validate with response
+func writePlugin(t *testing.T, dir, name, description, response string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	script := "#!/bin/sh\ninput=$(cat)\ncase \"$input\" in\n" +
+		"*'\"describe\"'*) echo '" + description + "' ;;\n" +
+		"*) " + response + " ;;\nesac\n"
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// cleanRegistry restores the registry after a test
+func cleanRegistry(t *testing.T) {
+	t.Helper()
+	registryMu.Lock()
+	saved := registry
+	registry = map[string]Registration{}
+	registryMu.Unlock()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = saved
+		registryMu.Unlock()
+	})
+}
+
+func builtin(name string, priority int) Registration {
+	return Registration{Name: name, Tools: []string{name}, Priority: priority, New: func() Validator { return typoValidator{name} }}
+}
+
+func TestRegister(t *testing.T) {
+	cleanRegistry(t)
+
+	Register(builtin("kubectl", 0))
+	if got := Registrations()[0].Source; got != SourceBuiltin {
+		t.Errorf("Source = %q, want %s", got, SourceBuiltin)
+	}
+
+	tests := []struct {
+		name string
+		r    Registration
+		err  string
+	}{
+		{"duplicate", builtin("kubectl", 0), `validator "kubectl" is already registered by builtin`},
+		{"no name", Registration{New: func() Validator { return nil }}, "needs a name"},
+		{"no constructor", Registration{Name: "helm"}, "needs a name and a constructor"},
+	}
+	for _, tt := range tests {
+		if err := register(tt.r); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: register error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register should panic on a duplicate name")
+		}
+	}()
+	Register(builtin("kubectl", 0))
+}
+
+func TestRegistrationsOrder(t *testing.T) {
+	cleanRegistry(t)
+
+	for _, r := range []Registration{
+		builtin("terraform", 0),
+		builtin("docker", 0),
+		builtin("kubectl", 10),
+		{Name: "corp", Priority: 0, Source: "/plugins/corp", New: func() Validator { return nil }},
+		{Name: "urgent", Priority: 20, Source: "/plugins/urgent", New: func() Validator { return nil }},
+	} {
+		if r.Source == "" {
+			Register(r)
+		} else if err := register(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+	for _, r := range Registrations() {
+		names = append(names, r.Name)
+	}
+	want := []string{"urgent", "kubectl", "corp", "docker", "terraform"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Registrations() = %q, want %q", names, want)
+	}
+	if got := len(New()); got != len(want) {
+		t.Errorf("New() created %d validators, want %d", got, len(want))
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	cleanRegistry(t)
+	Register(builtin("kubectl", 0))
+
+	dir := t.TempDir()
+	writePlugin(t, dir, "corp", `{"name": "corpctl", "tools": ["corpctl"], "aliases": ["cc"], "priority": 5}`, `echo '{"findings": []}'`)
+	writePlugin(t, dir, "clash", `{"name": "kubectl", "tools": ["kubectl"]}`, "true")
+	writePlugin(t, dir, "notools", `{"name": "empty"}`, "true")
+	writePlugin(t, dir, ".hidden", `{"name": "hidden", "tools": ["x"]}`, "true")
+	writePlugin(t, dir, "noname", `{"tools": ["thing"]}`, "true")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := LoadPlugins(dir)
+	if err == nil || !strings.Contains(err.Error(), "already registered") || !strings.Contains(err.Error(), "names no tools") {
+		t.Errorf("LoadPlugins error = %v, want the clash and the plugin without tools reported", err)
+	}
+
+	got := make(map[string]Registration)
+	for _, r := range Registrations() {
+		got[r.Name] = r
+	}
+	corp, ok := got["corpctl"]
+	if !ok || corp.Priority != 5 || corp.Source != filepath.Join(dir, "corp") || !reflect.DeepEqual(corp.Aliases, []string{"cc"}) {
+		t.Errorf("corpctl registration = %+v", corp)
+	}
+	if _, ok := got["noname"]; !ok {
+		t.Error("a plugin without a name should be named after its file")
+	}
+	if _, ok := got["hidden"]; ok {
+		t.Error("hidden files should be skipped")
+	}
+	if got["kubectl"].Source != SourceBuiltin {
+		t.Error("a plugin replaced a built-in validator")
+	}
+
+	if err := LoadPlugins(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("LoadPlugins(missing dir) = %v, want nil", err)
+	}
+}
+
+func TestPluginValidate(t *testing.T) {
+	const description = `{"name": "corp", "tools": ["corpctl"], "aliases": ["cc"]}`
+	command := "corpctl gte x"
+
+	tests := []struct {
+		name     string
+		response string // Shell command producing the validate answer
+		want     []Finding
+	}{
+		{
+			name:     "no findings",
+			response: `echo '{"findings": []}'`,
+			want:     []Finding{},
+		},
+		{
+			name:     "finding with a fix",
+			response: `echo '{"findings": [{"code": "corp/typo", "severity": "block", "message": "typo", "hint": "h", "start": 8, "end": 11, "fix": "get"}]}'`,
+			want:     []Finding{{Code: "corp/typo", Severity: SeverityBlock, Message: "typo", Hint: "h", Command: command, Span: Span{8, 11}, Correction: "corpctl get x"}},
+		},
+		{
+			name:     "defaults",
+			response: `echo '{"findings": [{"message": "odd", "start": 0, "end": 7}]}'`,
+			want:     []Finding{{Code: "corp", Severity: SeverityWarn, Message: "odd", Command: command, Span: Span{0, 7}}},
+		},
+		{
+			name:     "span past the end",
+			response: `echo '{"findings": [{"severity": "block", "message": "bad", "start": 8, "end": 99, "fix": "get"}]}'`,
+			want:     []Finding{{Code: "corp", Severity: SeverityBlock, Message: "bad", Command: command, Span: Span{0, len(command)}}},
+		},
+		{
+			name:     "reversed span",
+			response: `echo '{"findings": [{"severity": "warn", "message": "bad", "start": 5, "end": 2}]}'`,
+			want:     []Finding{{Code: "corp", Severity: SeverityWarn, Message: "bad", Command: command, Span: Span{0, len(command)}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := DescribePlugin(writePlugin(t, t.TempDir(), "corp", description, tt.response))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plugin.Validate(command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluginFailures(t *testing.T) {
+	const description = `{"name": "corp", "tools": ["corpctl"]}`
+	defer func(saved time.Duration) { PluginTimeout = saved }(PluginTimeout)
+	PluginTimeout = 200 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		response string
+		message  string // Part of the info finding's message
+	}{
+		{"exit status", "echo 'no config' >&2; exit 3", "no config"},
+		{"invalid json", "echo 'not json'", "invalid validate response"},
+		{"timeout", "sleep 5", "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := DescribePlugin(writePlugin(t, t.TempDir(), "corp", description, tt.response))
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings := plugin.Validate("corpctl x")
+			if len(findings) != 1 || findings[0].Severity != SeverityInfo || !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("Validate = %+v, want one info finding with %q", findings, tt.message)
+			}
+		})
+	}
+}
+
+func TestPluginCanValidate(t *testing.T) {
+	plugin := &Plugin{PluginDescription: PluginDescription{Tools: []string{"corpctl"}, Aliases: []string{"cc"}}}
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"corpctl deploy", true},
+		{"cc deploy", true},
+		{"corpctl", true},
+		{"corpctlx deploy", false},
+		{"kubectl get pods", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := plugin.CanValidate(tt.command); got != tt.want {
+			t.Errorf("CanValidate(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package validators
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SourceBuiltin is the source of validators compiled into ai-helper
+const SourceBuiltin = "builtin"
+
+// Registration describes a validator in the registry
+type Registration struct {
+	Name     string   // Unique name, e.g. "kubectl"
+	Tools    []string // Commands it validates
+	Aliases  []string // Shell aliases of those commands (k, tf, gco)
+	Priority int      // Higher is tried first
+
+	// Source is SourceBuiltin or the path of a plugin
+	Source string
+
+	// New creates the validator
+	New func() Validator
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Registration{}
+)
+
+// Register adds a validator to the registry. Built-in validators call it
+// from init; it panics if the name is empty or already taken.
+func Register(r Registration) {
+	if r.Source == "" {
+		r.Source = SourceBuiltin
+	}
+	if err := register(r); err != nil {
+		panic(err)
+	}
+}
+
+func register(r Registration) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if r.Name == "" || r.New == nil {
+		return fmt.Errorf("validator registration needs a name and a constructor")
+	}
+	if existing, ok := registry[r.Name]; ok {
+		return fmt.Errorf("validator %q is already registered by %s", r.Name, existing.Source)
+	}
+	registry[r.Name] = r
+	return nil
+}
+
+// Registrations returns the registered validators in the order they are
+// tried: highest priority first, plugins before built-in validators of the
+// same priority, then by name
+func Registrations() []Registration {
+	registryMu.Lock()
+	list := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		list = append(list, r)
+	}
+	registryMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if (a.Source == SourceBuiltin) != (b.Source == SourceBuiltin) {
+			return b.Source == SourceBuiltin
+		}
+		return a.Name < b.Name
+	})
+	return list
+}
+
+// New creates every registered validator, in the order they are tried
+func New() []Validator {
+	var list []Validator
+	for _, r := range Registrations() {
+		list = append(list, r.New())
+	}
+	return list
+}
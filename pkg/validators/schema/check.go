@@ -0,0 +1,187 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// word is a shell word of a command with its quotes removed and its byte
+// range in the command
+type word struct {
+	text       string
+	start, end int
+}
+
+// splitWords splits command into shell words, honouring quotes and
+// backslashes
+func splitWords(command string) []word {
+	var words []word
+	var b strings.Builder
+	start, quote, inWord := 0, byte(0), false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		if quote == 0 && (c == ' ' || c == '\t') {
+			if inWord {
+				words = append(words, word{b.String(), start, i})
+				b.Reset()
+				inWord = false
+			}
+			continue
+		}
+		if !inWord {
+			start, inWord = i, true
+		}
+
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote != '\'' && c == '\\' && i+1 < len(command):
+			i++
+			b.WriteByte(command[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if inWord {
+		words = append(words, word{b.String(), start, len(command)})
+	}
+	return words
+}
+
+// Check validates the subcommands and flags of command (tool name first,
+// aliases already expanded) against the tool's schema. ok is false if the
+// tool has no schema. Flags are only checked for commands whose flags the
+// schema lists, and subcommands only where the schema lists some and until
+// the first positional argument. Nothing after the first positional
+// argument of a command that runs another one (docker run IMAGE ...) is
+// checked.
+func Check(tool, command string) (findings []validators.Finding, ok bool) {
+	s := Lookup(tool)
+	if s == nil {
+		return nil, false
+	}
+	return s.Check(command), true
+}
+
+// Check validates command against the schema
+func (s *Schema) Check(command string) []validators.Finding {
+	var findings []validators.Finding
+
+	words := splitWords(command)
+	node := &s.Command
+	path := []*Command{node}
+	names := []string{s.Tool}
+	positional := false
+
+	for i := 1; i < len(words); i++ {
+		w := words[i]
+		if w.text == "--" {
+			break
+		}
+
+		if strings.HasPrefix(w.text, "-") && len(w.text) > 1 {
+			name, _, inline := strings.Cut(w.text, "=")
+			flag := lookupFlag(path, name)
+			if flag == nil {
+				flag = lookupCluster(path, name)
+			}
+
+			switch {
+			case flag == nil && len(node.Flags) > 0:
+				finding := validators.NewFinding(
+					s.Tool+"/unknown-flag", validators.SeverityBlock, command, w.start, w.start+len(name),
+					fmt.Sprintf("unknown flag %s for %s", name, strings.Join(names, " ")),
+					fmt.Sprintf("run '%s --help' for valid flags", strings.Join(names, " ")))
+				if len(name) > 2 {
+					// Short flags are too close to each other to guess
+					finding = finding.DidYouMean(name, flagNames(path))
+				}
+				findings = append(findings, finding)
+			case flag == nil:
+				// Unknown to a command without listed flags: it may take a
+				// value, so later words cannot be told apart
+				positional = true
+			case flag.Arg != "" && !inline && i+1 < len(words):
+				// Skip the value, unless an unknown-valued flag is followed
+				// by a subcommand
+				if flag.Arg != "?" || positional || node.Subcommands[words[i+1].text] == nil {
+					i++
+				}
+			}
+			continue
+		}
+
+		if positional {
+			continue
+		}
+		if sub := node.Subcommands[w.text]; sub != nil {
+			node = sub
+			path = append(path, sub)
+			names = append(names, w.text)
+			continue
+		}
+		if node.NoInterspersed {
+			break
+		}
+		if len(node.Subcommands) > 0 {
+			findings = append(findings, validators.NewFinding(
+				s.Tool+"/unknown-subcommand", validators.SeverityBlock, command, w.start, w.end,
+				fmt.Sprintf("'%s' is not a valid %s subcommand", w.text, strings.Join(names, " ")),
+				fmt.Sprintf("run '%s --help' for valid commands", strings.Join(names, " "))).
+				DidYouMean(w.text, subcommandNames(node)))
+		}
+		positional = true
+	}
+	return findings
+}
+
+// lookupFlag finds a flag on the command or one of its parents
+func lookupFlag(path []*Command, name string) *Flag {
+	for i := len(path) - 1; i >= 0; i-- {
+		if flag := path[i].flag(name); flag != nil {
+			return flag
+		}
+	}
+	return nil
+}
+
+// flagNames returns the long flag names of the command and its parents
+func flagNames(path []*Command) []string {
+	var names []string
+	for _, cmd := range path {
+		for _, flag := range cmd.Flags {
+			if len(flag.Name) > 2 {
+				names = append(names, flag.Name)
+			}
+		}
+	}
+	return names
+}
+
+// subcommandNames returns the names of the subcommands of cmd
+func subcommandNames(cmd *Command) []string {
+	names := make([]string, 0, len(cmd.Subcommands))
+	for name := range cmd.Subcommands {
+		names = append(names, name)
+	}
+	return names
+}
+
+// lookupCluster resolves combined short flags such as -it to the last of
+// them, if every letter is a known short flag
+func lookupCluster(path []*Command, name string) *Flag {
+	if len(name) < 3 || strings.HasPrefix(name, "--") {
+		return nil
+	}
+	var flag *Flag
+	for _, c := range name[1:] {
+		if flag = lookupFlag(path, "-"+string(c)); flag == nil {
+			return nil
+		}
+	}
+	return flag
+}
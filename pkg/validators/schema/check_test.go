@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testSchema is a small tool with nested subcommands
+func testSchema() *Schema {
+	return &Schema{
+		Version: FormatVersion,
+		Tool:    "tool",
+		Command: Command{
+			Flags: []Flag{{Name: "--verbose", Short: "-v"}, {Name: "--context", Arg: "string"}},
+			Subcommands: map[string]*Command{
+				"get": {Flags: []Flag{{Name: "--output", Short: "-o", Arg: "string"}, {Name: "--all", Short: "-a"}}},
+				"config": {Subcommands: map[string]*Command{
+					"view": {Flags: []Flag{{Name: "--raw"}}},
+				}},
+				"exec":   {Flags: []Flag{{Name: "--tty", Short: "-t"}}, NoInterspersed: true},
+				"plugin": {},
+			},
+		},
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []word
+	}{
+		{"tool get", []word{{"tool", 0, 4}, {"get", 5, 8}}},
+		{"tool  'a b'", []word{{"tool", 0, 4}, {"a b", 6, 11}}},
+		{`tool "x\"y" z\ w`, []word{{"tool", 0, 4}, {`x"y`, 5, 11}, {"z w", 12, 16}}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitWords(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	tests := []struct {
+		command string
+		codes   []string // Codes of the expected findings
+		fix     string   // Correction of the first finding, if any
+	}{
+		// Valid commands
+		{command: "tool get pods"},
+		{command: "tool get -o yaml pods"},
+		{command: "tool get --output=yaml pods -a"},
+		{command: "tool get -ao yaml"},
+		{command: "tool -v --context prod get"},
+		{command: "tool config view --raw"},
+		{command: "tool plugin --anything goes"},
+		{command: "tool get -- --not-a-flag"},
+		{command: "tool exec web ls --color=auto"},
+		{command: "tool exec -t web sh -c 'echo hi'"},
+
+		// Invalid commands
+		{command: "tool gte pods", codes: []string{"tool/unknown-subcommand"}, fix: "tool get pods"},
+		{command: "tool config veiw", codes: []string{"tool/unknown-subcommand"}, fix: "tool config view"},
+		{command: "tool get --outptu yaml", codes: []string{"tool/unknown-flag"}, fix: "tool get --output yaml"},
+		{command: "tool get -x", codes: []string{"tool/unknown-flag"}},
+		{command: "tool get pods --bogus -z", codes: []string{"tool/unknown-flag", "tool/unknown-flag"}},
+		{command: "tool exec --bogus web ls", codes: []string{"tool/unknown-flag"}},
+	}
+
+	s := testSchema()
+	for _, tt := range tests {
+		findings := s.Check(tt.command)
+		var codes []string
+		for _, f := range findings {
+			codes = append(codes, f.Code)
+		}
+		if !reflect.DeepEqual(codes, tt.codes) {
+			t.Errorf("Check(%q) = %q, want %q", tt.command, codes, tt.codes)
+			continue
+		}
+		if tt.fix != "" && findings[0].Correction != tt.fix {
+			t.Errorf("Check(%q) correction = %q, want %q", tt.command, findings[0].Correction, tt.fix)
+		}
+	}
+}
+
+func TestCheckSpans(t *testing.T) {
+	command := "tool get pods --bogus=1"
+	findings := testSchema().Check(command)
+	if len(findings) != 1 {
+		t.Fatalf("Check(%q) = %v, want one finding", command, findings)
+	}
+	if got := command[findings[0].Span.Start:findings[0].Span.End]; got != "--bogus" {
+		t.Errorf("finding spans %q, want --bogus", got)
+	}
+}
+
+func TestCheckDocker(t *testing.T) {
+	tests := []struct {
+		command string
+		invalid bool
+	}{
+		{"docker ps -a", false},
+		{"docker run --rm -it alpine sh", false},
+		{"docker run --rm alpine ls --color=auto", false},
+		{"docker exec web ls -la /tmp", false},
+		{"docker exec -it web sh -c 'ls -la'", false},
+		{"docker run ubuntu grep --recursive foo /", false},
+		{"docker run --rm curlimages/curl curl --silent https://x", false},
+		{"docker run --name web -e A=1 nginx nginx -g 'daemon off;'", false},
+		{"docker create alpine ls --all", false},
+
+		{"docker ps --bogus", true},
+		{"docker logs web --bogus", true},
+		{"docker run --bogus alpine ls", true},
+		{"docker exec --bogus web ls", true},
+		{"docker rnu alpine", true},
+	}
+
+	for _, tt := range tests {
+		findings, ok := Check("docker", tt.command)
+		if !ok {
+			t.Fatal("no embedded docker schema")
+		}
+		if (len(findings) > 0) != tt.invalid {
+			t.Errorf("Check(%q) = %v, want invalid %v", tt.command, findings, tt.invalid)
+		}
+	}
+}
+
+func TestCheckUnknownTool(t *testing.T) {
+	if _, ok := Check("no-such-tool", "no-such-tool --x"); ok {
+		t.Error("Check should report that the tool has no schema")
+	}
+}
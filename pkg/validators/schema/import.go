@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// completionDirective ends cobra's __complete output (":4")
+var completionDirective = regexp.MustCompile(`^:\d+$`)
+
+// ParseOutput parses captured --help text or cobra completion output
+// (tool __complete <subcommand> ""), whichever it is
+func ParseOutput(text string) *Command {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "Completion ended") {
+			continue
+		}
+		if completionDirective.MatchString(line) {
+			return ParseCompletion(text)
+		}
+		break
+	}
+	return ParseHelp(text)
+}
+
+// ParseCompletion parses cobra completion output: one "name<TAB>description"
+// per line, flags starting with "-". Completion does not tell whether a
+// flag takes a value, so their Arg is "?".
+func ParseCompletion(text string) *Command {
+	cmd := &Command{}
+	for _, line := range strings.Split(text, "\n") {
+		name, _, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		switch {
+		case name == "" || completionDirective.MatchString(name) || strings.HasPrefix(name, "Completion ended"):
+			continue
+		case strings.HasPrefix(name, "-"):
+			flag := Flag{Name: name, Arg: "?"}
+			if strings.HasSuffix(name, "=") {
+				flag = Flag{Name: strings.TrimSuffix(name, "="), Arg: "value"}
+			}
+			cmd.Flags = append(cmd.Flags, flag)
+		case commandName.MatchString(name):
+			if cmd.Subcommands == nil {
+				cmd.Subcommands = map[string]*Command{}
+			}
+			cmd.Subcommands[name] = &Command{}
+		}
+	}
+	return cmd
+}
+
+var (
+	// sectionHeader is an unindented line ending in ":", e.g. "Options:",
+	// "Available Commands:", "Global options (use these before ...):"
+	sectionHeader = regexp.MustCompile(`^\S.*:$`)
+
+	// commandLine lists a subcommand: "  run         Create and run ..."
+	// (a trailing "*" marks docker CLI plugins)
+	commandLine = regexp.MustCompile(`^ {1,4}([a-z][\w-]*)\*?(\s{2,}\S.*)?$`)
+
+	commandName = regexp.MustCompile(`^[a-z][\w-]*$`)
+	flagName    = regexp.MustCompile(`^--?[A-Za-z0-9][\w.-]*$`)
+
+	// runsCommand is a usage line of a command that runs another one:
+	// "Usage:  docker exec [OPTIONS] CONTAINER COMMAND [ARG...]"
+	runsCommand = regexp.MustCompile(`^Usage:.*\bCOMMAND\]? \[ARG\.\.\.\]`)
+)
+
+// ParseHelp parses --help text in the usual layouts (cobra, docker,
+// kubectl, terraform): flags from "Options:"/"Flags:" sections and
+// subcommands from "Commands:" sections. A usage line ending in
+// "COMMAND [ARG...]" marks a command that runs another one.
+func ParseHelp(text string) *Command {
+	cmd := &Command{}
+	section := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if runsCommand.MatchString(strings.TrimSpace(line)) {
+			cmd.NoInterspersed = true
+		}
+		if sectionHeader.MatchString(line) {
+			header := strings.ToLower(line)
+			switch {
+			case strings.Contains(header, "option") || strings.Contains(header, "flag"):
+				section = "flags"
+			case strings.Contains(header, "command"):
+				section = "commands"
+			default:
+				section = ""
+			}
+			continue
+		}
+
+		switch section {
+		case "commands":
+			if m := commandLine.FindStringSubmatch(line); m != nil {
+				if cmd.Subcommands == nil {
+					cmd.Subcommands = map[string]*Command{}
+				}
+				cmd.Subcommands[m[1]] = &Command{}
+			}
+		case "flags":
+			if flag, ok := parseFlagLine(line); ok {
+				cmd.Flags = append(cmd.Flags, flag)
+			}
+		}
+	}
+	return cmd
+}
+
+// parseFlagLine parses the flag part of a help line:
+//
+//	-a, --all               Show all containers
+//	    --format string     Format output
+//	-A, --all-namespaces=false:
+//	-target=resource        Limit the operation
+//	-var 'foo=bar'          Set a variable
+func parseFlagLine(line string) (Flag, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "-") {
+		return Flag{}, false
+	}
+	spec := trimmed
+	if i := strings.Index(spec, "  "); i >= 0 {
+		spec = spec[:i]
+	}
+
+	var flag Flag
+	for _, part := range strings.Split(spec, ", ") {
+		end := strings.IndexAny(part, " =")
+		name, rest := part, ""
+		if end >= 0 {
+			name, rest = part[:end], part[end:]
+		}
+		if !flagName.MatchString(name) {
+			return Flag{}, false
+		}
+
+		if len(name) == 2 && flag.Short == "" && !strings.HasPrefix(name, "--") {
+			flag.Short = name
+		} else {
+			flag.Name = name
+		}
+		if arg := flagArg(rest); arg != "" {
+			flag.Arg = arg
+		}
+	}
+	if flag.Name == "" {
+		flag.Name, flag.Short = flag.Short, ""
+	}
+	return flag, flag.Name != ""
+}
+
+// flagArg returns the value type from what follows a flag name: " string"
+// gives "string", "=resource" and kubectl's empty defaults give "value", and
+// booleans ("=false:") are switches
+func flagArg(rest string) string {
+	value := strings.TrimSuffix(strings.TrimSpace(rest), ":")
+	if strings.HasPrefix(value, "=") {
+		if value == "=true" || value == "=false" {
+			return ""
+		}
+		return "value"
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+	if arg := strings.Trim(fields[0], `'"[]`); arg != "" {
+		return arg
+	}
+	return "value"
+}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlagLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Flag
+		ok   bool
+	}{
+		{"  -a, --all               Show all containers", Flag{Name: "--all", Short: "-a"}, true},
+		{"      --format string     Format output", Flag{Name: "--format", Arg: "string"}, true},
+		{"  -A, --all-namespaces=false:", Flag{Name: "--all-namespaces", Short: "-A"}, true},
+		{"  -o, --output='':", Flag{Name: "--output", Short: "-o", Arg: "value"}, true},
+		{"  -target=resource        Limit the operation", Flag{Name: "-target", Arg: "value"}, true},
+		{"  -var 'foo=bar'          Set a variable", Flag{Name: "-var", Arg: "foo=bar"}, true},
+		{"  -q                      Quiet", Flag{Name: "-q"}, true},
+		{"  run         Create and run a container", Flag{}, false},
+		{"  --         End of flags", Flag{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseFlagLine(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseFlagLine(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseHelp(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		flags       []string
+		subcommands []string
+		noInter     bool
+	}{
+		{
+			name: "docker run",
+			text: `
+Usage:  docker run [OPTIONS] IMAGE [COMMAND] [ARG...]
+
+Create and run a new container from an image
+
+Options:
+  -d, --detach       Run container in background
+      --name string  Assign a name to the container
+      --rm           Automatically remove the container
+`,
+			flags:   []string{"--detach", "--name", "--rm"},
+			noInter: true,
+		},
+		{
+			name: "docker exec",
+			text: `Usage:  docker exec [OPTIONS] CONTAINER COMMAND [ARG...]
+
+Options:
+  -t, --tty    Allocate a pseudo-TTY
+`,
+			flags:   []string{"--tty"},
+			noInter: true,
+		},
+		{
+			name: "cobra",
+			text: `Usage:
+  kubectl [flags] [options]
+
+Basic Commands (Beginner):
+  create          Create a resource from a file or from stdin
+  get             Display one or many resources
+
+Flags:
+      --context='': The name of the kubeconfig context to use
+  -v, --v=0: number for the log level verbosity
+`,
+			flags:       []string{"--context", "--v"},
+			subcommands: []string{"create", "get"},
+		},
+		{
+			name: "terraform",
+			text: `Usage: terraform [global options] <subcommand> [args]
+
+Main commands:
+  init          Prepare your working directory
+  plan          Show changes required by the current configuration
+
+Global options (use these before the subcommand, if any):
+  -chdir=DIR    Switch to a different working directory
+  -version      An alias for the "version" subcommand.
+`,
+			flags:       []string{"-chdir", "-version"},
+			subcommands: []string{"init", "plan"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := ParseOutput(tt.text)
+			var flags []string
+			for _, flag := range cmd.Flags {
+				flags = append(flags, flag.Name)
+			}
+			if !reflect.DeepEqual(flags, tt.flags) {
+				t.Errorf("flags = %q, want %q", flags, tt.flags)
+			}
+			if got := sortedNames(cmd); !reflect.DeepEqual(got, tt.subcommands) {
+				t.Errorf("subcommands = %q, want %q", got, tt.subcommands)
+			}
+			if cmd.NoInterspersed != tt.noInter {
+				t.Errorf("NoInterspersed = %v, want %v", cmd.NoInterspersed, tt.noInter)
+			}
+		})
+	}
+}
+
+func TestParseCompletion(t *testing.T) {
+	text := "apply\tApply a configuration\n--output=\tOutput format\n--watch\tWatch\nget\n:4\nCompletion ended with directive: ShellCompDirectiveNoFileComp\n"
+
+	cmd := ParseOutput(text)
+	want := []Flag{{Name: "--output", Arg: "value"}, {Name: "--watch", Arg: "?"}}
+	if !reflect.DeepEqual(cmd.Flags, want) {
+		t.Errorf("flags = %+v, want %+v", cmd.Flags, want)
+	}
+	if got := sortedNames(cmd); !reflect.DeepEqual(got, []string{"apply", "get"}) {
+		t.Errorf("subcommands = %q, want apply and get", got)
+	}
+}
@@ -0,0 +1,253 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FormatVersion is the version of the schema file format
+const FormatVersion = 1
+
+// Schema describes the subcommands and flags of a tool
+type Schema struct {
+	Version     int    `json:"version"` // File format version
+	Tool        string `json:"tool"`
+	ToolVersion string `json:"tool_version,omitempty"` // Version the help was captured from
+
+	// The root command holds the global flags and the subcommands
+	Command
+}
+
+// Command is a command or subcommand of a tool
+type Command struct {
+	Flags       []Flag              `json:"flags,omitempty"`
+	Subcommands map[string]*Command `json:"subcommands,omitempty"`
+
+	// NoInterspersed is set for commands whose first argument is followed
+	// by a command of its own (docker run IMAGE COMMAND [ARG...]): flags
+	// after it belong to that command
+	NoInterspersed bool `json:"no_interspersed,omitempty"`
+}
+
+// Flag is a command-line flag
+type Flag struct {
+	Name  string `json:"name"`            // --all, or -auto-approve for single-dash tools
+	Short string `json:"short,omitempty"` // -a
+	Arg   string `json:"arg,omitempty"`   // Value type ("string", "int"), "?" if unknown, "" for a switch
+}
+
+//go:embed schemas/*.json
+var embedded embed.FS
+
+var (
+	mu      sync.Mutex
+	schemas map[string]*Schema
+)
+
+// Load reads the embedded schemas and merges the user's schemas in dir
+// (usually ~/.ai/schemas) over them. A missing dir is not an error; files
+// that cannot be read are skipped and reported together.
+func Load(dir string) error {
+	loaded, err := loadEmbedded()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s, err := Parse(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if base, ok := loaded[s.Tool]; ok {
+			base.Merge(s)
+		} else {
+			loaded[s.Tool] = s
+		}
+	}
+
+	mu.Lock()
+	schemas = loaded
+	mu.Unlock()
+	return errors.Join(errs...)
+}
+
+func loadEmbedded() (map[string]*Schema, error) {
+	loaded := map[string]*Schema{}
+	files, _ := embedded.ReadDir("schemas")
+	for _, file := range files {
+		data, err := embedded.ReadFile("schemas/" + file.Name())
+		if err != nil {
+			return nil, err
+		}
+		s, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("embedded schema %s: %w", file.Name(), err)
+		}
+		loaded[s.Tool] = s
+	}
+	return loaded, nil
+}
+
+// Lookup returns the schema for a tool, or nil if there is none. The
+// embedded schemas are used if Load was not called.
+func Lookup(tool string) *Schema {
+	mu.Lock()
+	defer mu.Unlock()
+	if schemas == nil {
+		loaded, err := loadEmbedded()
+		if err != nil {
+			return nil
+		}
+		schemas = loaded
+	}
+	return schemas[tool]
+}
+
+// Tools returns the tools that have a schema, sorted
+func Tools() []string {
+	Lookup("")
+	mu.Lock()
+	defer mu.Unlock()
+	tools := make([]string, 0, len(schemas))
+	for tool := range schemas {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// Parse decodes a schema file
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported schema version %d (want %d)", s.Version, FormatVersion)
+	}
+	if s.Tool == "" {
+		return nil, fmt.Errorf("schema names no tool")
+	}
+	return &s, nil
+}
+
+// Read reads a schema file, returning an empty schema for tool if the file
+// does not exist
+func Read(file, tool string) (*Schema, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &Schema{Version: FormatVersion, Tool: tool}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return s, nil
+}
+
+// Write saves the schema as indented JSON
+func (s *Schema) Write(file string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// Merge adds the flags and subcommands of other to the schema; flags of
+// the same name are replaced
+func (s *Schema) Merge(other *Schema) {
+	if other.ToolVersion != "" {
+		s.ToolVersion = other.ToolVersion
+	}
+	s.Command.merge(&other.Command)
+}
+
+// Import merges a parsed command into the subcommand at path, creating the
+// subcommands on the way
+func (s *Schema) Import(path []string, cmd *Command) {
+	node := &s.Command
+	for _, name := range path {
+		if node.Subcommands == nil {
+			node.Subcommands = map[string]*Command{}
+		}
+		if node.Subcommands[name] == nil {
+			node.Subcommands[name] = &Command{}
+		}
+		node = node.Subcommands[name]
+	}
+	node.merge(cmd)
+}
+
+func (c *Command) merge(other *Command) {
+	if other.NoInterspersed {
+		c.NoInterspersed = true
+	}
+	for _, flag := range other.Flags {
+		replaced := false
+		for i := range c.Flags {
+			if c.Flags[i].Name == flag.Name {
+				c.Flags[i], replaced = flag, true
+			}
+		}
+		if !replaced {
+			c.Flags = append(c.Flags, flag)
+		}
+	}
+
+	for name, sub := range other.Subcommands {
+		if c.Subcommands == nil {
+			c.Subcommands = map[string]*Command{}
+		}
+		if c.Subcommands[name] == nil {
+			c.Subcommands[name] = &Command{}
+		}
+		c.Subcommands[name].merge(sub)
+	}
+}
+
+// flag returns the flag called name (long or short), or nil
+func (c *Command) flag(name string) *Flag {
+	for i := range c.Flags {
+		if c.Flags[i].Name == name || c.Flags[i].Short == name {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+// Count returns the number of commands and flags under c
+func (c *Command) Count() (commands, flags int) {
+	flags = len(c.Flags)
+	for _, sub := range c.Subcommands {
+		n, f := sub.Count()
+		commands, flags = commands+n+1, flags+f
+	}
+	return commands, flags
+}
+
+// FileName returns the user schema file for a tool in dir
+func FileName(dir, tool string) string {
+	return filepath.Join(dir, strings.ReplaceAll(tool, string(filepath.Separator), "_")+".json")
+}
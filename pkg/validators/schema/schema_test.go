@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sortedNames returns the subcommand names of cmd, sorted
+func sortedNames(cmd *Command) []string {
+	names := subcommandNames(cmd)
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string // Part of the expected error, "" for a valid schema
+	}{
+		{data: `{"version": 1, "tool": "tool", "subcommands": {"run": {"no_interspersed": true}}}`},
+		{data: `{"version": 2, "tool": "tool"}`, err: "unsupported schema version 2"},
+		{data: `{"version": 1}`, err: "names no tool"},
+		{data: `{"version": 1, "tool": `, err: "unexpected end"},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse([]byte(tt.data))
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("Parse(%s): %v", tt.data, err)
+			} else if !s.Subcommands["run"].NoInterspersed {
+				t.Errorf("Parse(%s) lost no_interspersed", tt.data)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%s) error = %v, want %q", tt.data, err, tt.err)
+		}
+	}
+}
+
+func TestImportMerges(t *testing.T) {
+	s := testSchema()
+	s.Import([]string{"get"}, &Command{Flags: []Flag{{Name: "--output", Short: "-o", Arg: "format"}, {Name: "--watch", Short: "-w"}}})
+	s.Import([]string{"config", "set", "cluster"}, &Command{NoInterspersed: true})
+
+	get := s.Subcommands["get"]
+	want := []Flag{{Name: "--output", Short: "-o", Arg: "format"}, {Name: "--all", Short: "-a"}, {Name: "--watch", Short: "-w"}}
+	if !reflect.DeepEqual(get.Flags, want) {
+		t.Errorf("get flags = %+v, want %+v", get.Flags, want)
+	}
+	if got := sortedNames(s.Subcommands["config"]); !reflect.DeepEqual(got, []string{"set", "view"}) {
+		t.Errorf("config subcommands = %q, want set and view", got)
+	}
+	if !s.Subcommands["config"].Subcommands["set"].Subcommands["cluster"].NoInterspersed {
+		t.Error("Import lost NoInterspersed")
+	}
+	if !s.Subcommands["exec"].NoInterspersed {
+		t.Error("merge cleared NoInterspersed")
+	}
+
+	commands, flags := s.Count()
+	if commands != 7 || flags != 7 {
+		t.Errorf("Count() = %d commands, %d flags; want 7, 7", commands, flags)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schemas", "tool.json")
+
+	s, err := Read(file, "tool")
+	if err != nil || s.Tool != "tool" || s.Version != FormatVersion {
+		t.Fatalf("Read(missing) = %+v, %v; want an empty schema", s, err)
+	}
+
+	if err := testSchema().Write(file); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Read(file, "tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s, testSchema()) {
+		t.Errorf("Read after Write = %+v, want %+v", s, testSchema())
+	}
+}
+
+func TestLoadMergesUserSchemas(t *testing.T) {
+	dir := t.TempDir()
+	user := `{"version": 1, "tool": "docker", "subcommands": {"run": {"flags": [{"name": "--my-flag"}]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "docker.json"), []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Load("") })
+
+	if err := Load(dir); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("Load error = %v, want broken.json reported", err)
+	}
+	run := Lookup("docker").Subcommands["run"]
+	if run.flag("--my-flag") == nil || run.flag("--rm") == nil || !run.NoInterspersed {
+		t.Error("user schema not merged over the embedded one")
+	}
+	if !reflect.DeepEqual(Tools(), []string{"docker"}) {
+		t.Errorf("Tools() = %q, want docker", Tools())
+	}
+}
+
+func TestFileName(t *testing.T) {
+	if got := FileName("/s", "tool"); got != filepath.Join("/s", "tool.json") {
+		t.Errorf("FileName = %q", got)
+	}
+}
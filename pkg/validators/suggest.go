@@ -0,0 +1,120 @@
+package validators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// keyboardRows is the QWERTY layout; each row is shifted right of the one
+// above it
+var keyboardRows = []struct {
+	keys   string
+	offset float64
+}{
+	{"1234567890-", 0},
+	{"qwertyuiop", 0.5},
+	{"asdfghjkl", 0.75},
+	{"zxcvbnm", 1.25},
+}
+
+// keyPositions maps a key to its (column, row) on the keyboard
+var keyPositions = func() map[byte][2]float64 {
+	positions := map[byte][2]float64{}
+	for row, r := range keyboardRows {
+		for col := 0; col < len(r.keys); col++ {
+			positions[r.keys[col]] = [2]float64{float64(col) + r.offset, float64(row)}
+		}
+	}
+	return positions
+}()
+
+// adjacent reports whether two keys are next to each other on the keyboard
+func adjacent(a, b byte) bool {
+	pa, okA := keyPositions[a]
+	pb, okB := keyPositions[b]
+	if !okA || !okB || a == b {
+		return false
+	}
+	return math.Abs(pa[1]-pb[1]) <= 1 && math.Abs(pa[0]-pb[0]) <= 1
+}
+
+// TypoDistance is the edit distance between two words (insertions,
+// deletions, substitutions and swaps of neighbouring letters), with
+// substitutions of adjacent keys counting half
+func TypoDistance(a, b string) float64 {
+	return distance(a, b, 0.5)
+}
+
+// distance is the edit distance with adjacent-key substitutions costing
+// adjacentCost
+func distance(a, b string, adjacentCost float64) float64 {
+	d := make([][]float64, len(a)+1)
+	for i := range d {
+		d[i] = make([]float64, len(b)+1)
+		d[i][0] = float64(i)
+	}
+	for j := range d[0] {
+		d[0][j] = float64(j)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1.0
+			switch {
+			case a[i-1] == b[j-1]:
+				cost = 0
+			case adjacent(a[i-1], b[j-1]):
+				cost = adjacentCost
+			}
+			d[i][j] = math.Min(math.Min(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = math.Min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// Suggest returns the candidate word most likely meant by a mistyped word:
+// the closest by TypoDistance that is at most one edit away (two for
+// longer words). It reports false if there is none or two are equally
+// close.
+func Suggest(word string, candidates []string) (string, bool) {
+	limit := 1.0
+	if len(word) > 5 {
+		limit = 2
+	}
+
+	best, bestDistance, tie := "", math.Inf(1), false
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+	for _, candidate := range sorted {
+		if candidate == word || candidate == best || distance(word, candidate, 1) > limit {
+			continue
+		}
+		d := TypoDistance(word, candidate)
+		switch {
+		case d < bestDistance:
+			best, bestDistance, tie = candidate, d, false
+		case d == bestDistance:
+			tie = true
+		}
+	}
+
+	if best == "" || tie || bestDistance >= float64(len(word)) {
+		return "", false
+	}
+	return best, true
+}
+
+// DidYouMean adds the candidate closest to word as the finding's hint and
+// correction, if there is one
+func (f Finding) DidYouMean(word string, candidates []string) Finding {
+	suggestion, ok := Suggest(word, candidates)
+	if !ok {
+		return f
+	}
+	f.Hint = fmt.Sprintf("did you mean '%s'?", suggestion)
+	return f.WithFix(suggestion)
+}
@@ -0,0 +1,84 @@
+package validators
+
+import (
+	"testing"
+)
+
+func TestTypoDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"apply", "apply", 0},
+		{"aplly", "apply", 0.5}, // l and p are neighbours
+		{"applt", "apply", 0.5},
+		{"applq", "apply", 1},
+		{"aply", "apply", 1},
+		{"appply", "apply", 1},
+		{"aplpy", "apply", 1}, // Swapped letters
+		{"", "get", 3},
+	}
+
+	for _, tt := range tests {
+		if got := TypoDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("TypoDistance(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	subcommands := []string{"apply", "get", "describe", "delete", "logs", "exec", "edit", "create"}
+
+	tests := []struct {
+		word string
+		want string // "" when there is no suggestion
+	}{
+		{"aplly", "apply"},
+		{"gte", "get"},
+		{"desribe", "describe"},
+		{"descirbe", "describe"},
+		{"lgos", "logs"},
+		{"delte", "delete"},
+		{"craete", "create"},
+
+		// Known words and words too far from any candidate
+		{"apply", ""},
+		{"rollout", ""},
+		{"x", ""},
+		{"", ""},
+		{"ecit", "edit"}, // c is next to d, not to x
+	}
+
+	for _, tt := range tests {
+		got, ok := Suggest(tt.word, subcommands)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("Suggest(%q) = %q, %v; want %q", tt.word, got, ok, tt.want)
+		}
+	}
+}
+
+func TestSuggestTie(t *testing.T) {
+	if got, ok := Suggest("mat", []string{"rat", "cat"}); ok {
+		t.Errorf("Suggest(mat) = %q, want no suggestion between equally close words", got)
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	command := "kubectl gte pods"
+	start, end := WordSpan(command, 1)
+	f := NewFinding("kubectl/subcommand", SeverityWarn, command, start, end, "unknown subcommand 'gte'", "").
+		DidYouMean("gte", []string{"get", "apply"})
+
+	if f.Hint != "did you mean 'get'?" {
+		t.Errorf("Hint = %q", f.Hint)
+	}
+	if f.Correction != "kubectl get pods" {
+		t.Errorf("Correction = %q, want kubectl get pods", f.Correction)
+	}
+
+	unchanged := NewFinding("kubectl/subcommand", SeverityWarn, command, start, end, "unknown", "hint").
+		DidYouMean("gte", []string{"rollout"})
+	if unchanged.Hint != "hint" || unchanged.Correction != "" {
+		t.Errorf("finding changed without a suggestion: %+v", unchanged)
+	}
+}
@@ -4,12 +4,30 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
 // Validator implements the Validator interface for terraform commands.
 type Validator struct {
+	validators.Environment
+
 	validSubcommands []string
 	dangerousOps     []string
+	mistakes         []validators.Mistake
+}
+
+// aliases are expanded before validation
+var aliases = map[string]string{"tf": "terraform"}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:    "terraform",
+		Tools:   []string{"terraform"},
+		Aliases: []string{"tf"},
+		New:     func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new terraform validator.
@@ -24,6 +42,18 @@ func NewValidator() *Validator {
 		dangerousOps: []string{
 			"destroy", "force-unlock", "taint",
 		},
+		// Commonly hallucinated terraform flags
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `plan --apply`, Message: "terraform plan does not have an --apply flag", Hint: "run 'terraform apply' separately"},
+			{Pattern: `apply --plan`, Message: "terraform apply does not take a --plan flag", Hint: "use 'terraform apply plan.tfplan' to apply a saved plan"},
+			{Pattern: `--force-yes`, Message: "terraform does not have a --force-yes flag", Hint: "use -auto-approve instead", Fix: "-auto-approve"},
+			{Pattern: `--skip-validation`, Message: "terraform does not have a --skip-validation flag", Hint: "remove it"},
+			{Pattern: `(apply) --target-all`, Message: "terraform apply does not have a --target-all flag", Hint: "omit --target to apply all resources", Fix: "$1"},
+			{Pattern: `destroy --force`, Message: "terraform destroy does not have a --force flag", Hint: "use -auto-approve instead", Fix: "destroy -auto-approve"},
+			{Pattern: `init --upgrade-modules`, Message: "terraform init does not have --upgrade-modules", Hint: "use -upgrade instead", Fix: "init -upgrade"},
+			{Pattern: `plan --save`, Message: "terraform plan does not have a --save flag", Hint: "use -out=FILE to save the plan"},
+			{Pattern: `apply --dry-run`, Message: "terraform apply does not have a --dry-run flag", Hint: "use 'terraform plan' instead", Fix: "plan"},
+		}),
 	}
 }
 
@@ -33,67 +63,43 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if a terraform command is valid.
-func (v *Validator) Validate(command string) error {
+func (v *Validator) Validate(command string) []validators.Finding {
 	// Handle alias 'tf' for 'terraform'
-	if strings.HasPrefix(command, "tf ") {
-		command = "terraform" + command[2:]
-	}
-	
+	expansion := validators.Expand(command, aliases)
+	command = expansion.Expanded
+
 	if !strings.HasPrefix(command, "terraform") {
 		return nil // Not a terraform command
 	}
 
 	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
-	}
+	findings := validators.FindMistakes("terraform/unknown-flag", command, v.mistakes)
 
-	// Check for invalid subcommands
-	if err := v.checkSubcommand(command); err != nil {
-		return err
+	// Check subcommands and flags against the terraform schema if there is
+	// one, otherwise against the known subcommands
+	if checked, ok := schema.Check("terraform", command); ok {
+		findings = validators.Merge(findings, checked)
+	} else if finding, ok := v.checkSubcommand(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for dangerous operations
-	if warning := v.checkDangerousOps(command); warning != "" {
-		return fmt.Errorf("⚠️  Warning: %s", warning)
+	if finding, ok := v.checkDangerousOps(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for common mistakes
-	if err := v.checkCommonMistakes(command); err != nil {
-		return err
-	}
+	findings = append(findings, v.checkCommonMistakes(command)...)
 
-	return nil
-}
-
-// checkHallucinatedFlags checks for commonly hallucinated terraform flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`plan --apply`:           "terraform plan does not have an --apply flag. Run 'terraform apply' separately.",
-		`apply --plan`:           "terraform apply does not take a --plan flag. Use 'terraform apply plan.tfplan' to apply a saved plan.",
-		`--force-yes`:            "terraform does not have a --force-yes flag. Use -auto-approve instead.",
-		`--skip-validation`:      "terraform does not have a --skip-validation flag.",
-		`apply --target-all`:     "terraform apply does not have a --target-all flag. Omit --target to apply all resources.",
-		`destroy --force`:        "terraform destroy does not have a --force flag. Use -auto-approve instead.",
-		`init --upgrade-modules`: "terraform init does not have --upgrade-modules. Use -upgrade instead.",
-		`plan --save`:            "terraform plan does not have a --save flag. Use -out=FILE to save the plan.",
-		`apply --dry-run`:        "terraform apply does not have a --dry-run flag. Use 'terraform plan' instead.",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
-	}
-
-	return nil
+	return expansion.Findings(findings)
 }
 
 // checkSubcommand validates the terraform subcommand.
-func (v *Validator) checkSubcommand(command string) error {
+func (v *Validator) checkSubcommand(command string) (validators.Finding, bool) {
 	parts := strings.Fields(command)
 	if len(parts) < 2 {
-		return fmt.Errorf("incomplete terraform command")
+		return validators.NewFinding("terraform/incomplete", validators.SeverityBlock, command, 0, len(command),
+			"incomplete terraform command", "add a subcommand, e.g. 'terraform plan'"), true
 	}
 
 	subcommand := parts[1]
@@ -108,42 +114,51 @@ func (v *Validator) checkSubcommand(command string) error {
 	}
 
 	if !isValid {
-		return fmt.Errorf("'%s' is not a valid terraform subcommand. Use 'terraform --help' to see valid commands.", subcommand)
+		start, end := validators.WordSpan(command, 1)
+		return validators.NewFinding("terraform/unknown-subcommand", validators.SeverityBlock, command, start, end,
+			fmt.Sprintf("'%s' is not a valid terraform subcommand", subcommand), "use 'terraform --help' to see valid commands").DidYouMean(subcommand, v.validSubcommands), true
 	}
 
-	return nil
+	return validators.Finding{}, false
 }
 
 // checkDangerousOps checks for dangerous terraform operations.
-func (v *Validator) checkDangerousOps(command string) string {
+func (v *Validator) checkDangerousOps(command string) (validators.Finding, bool) {
 	for _, danger := range v.dangerousOps {
-		if strings.Contains(command, danger) {
-			return fmt.Sprintf("Dangerous operation detected: 'terraform %s'. This will modify or destroy infrastructure.", danger)
+		if i := validators.IndexWord(command, danger); i >= 0 {
+			return validators.NewFinding("terraform/dangerous", validators.SeverityWarn, command, i, i+len(danger),
+				fmt.Sprintf("Dangerous operation detected: 'terraform %s'. This will modify or destroy infrastructure.", danger)+v.ProductionNote(command),
+				"review 'terraform plan' output first"), true
 		}
 	}
-	return ""
+	return validators.Finding{}, false
 }
 
 // checkCommonMistakes checks for common terraform command mistakes.
-func (v *Validator) checkCommonMistakes(command string) error {
-	// Check for missing -auto-approve on apply/destroy
+func (v *Validator) checkCommonMistakes(command string) []validators.Finding {
+	var findings []validators.Finding
+
+	// Check for auto-approve without its dash on apply/destroy
 	if strings.Contains(command, "apply") && strings.Contains(command, "auto-approve") {
 		if !strings.Contains(command, "-auto-approve") && !strings.Contains(command, "--auto-approve") {
 			// They probably meant to use the flag correctly
-			return fmt.Errorf("did you mean -auto-approve? (note the single dash)")
+			i := strings.Index(command, "auto-approve")
+			findings = append(findings, validators.NewFinding("terraform/flag-syntax", validators.SeverityBlock, command, i, i+len("auto-approve"),
+				"auto-approve is a flag", "did you mean -auto-approve? (note the single dash)").WithFix("-auto-approve"))
 		}
 	}
 
 	// Check for -target without value
-	if matched, _ := regexp.MatchString(`-target\s*$`, command); matched {
-		return fmt.Errorf("-target flag requires a value. Example: -target=aws_instance.example")
+	if loc := regexp.MustCompile(`-target\s*$`).FindStringIndex(command); loc != nil {
+		findings = append(findings, validators.NewFinding("terraform/missing-value", validators.SeverityBlock, command, loc[0], loc[1],
+			"-target flag requires a value", "example: -target=aws_instance.example"))
 	}
 
 	// Check for -var without value
-	if matched, _ := regexp.MatchString(`-var\s*$`, command); matched {
-		return fmt.Errorf("-var flag requires a value. Example: -var=\"key=value\"")
+	if loc := regexp.MustCompile(`-var\s*$`).FindStringIndex(command); loc != nil {
+		findings = append(findings, validators.NewFinding("terraform/missing-value", validators.SeverityBlock, command, loc[0], loc[1],
+			"-var flag requires a value", `example: -var="key=value"`))
 	}
 
-	return nil
+	return findings
 }
-
@@ -0,0 +1,58 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"terraform aply", []spanOf{{"terraform/unknown-subcommand", 10, "aply", "terraform apply"}}},
+		{"terraform apply auto-approve", []spanOf{{"terraform/flag-syntax", 16, "auto-approve", "terraform apply -auto-approve"}}},
+		{"terraform init --upgrade-modules", []spanOf{{"terraform/unknown-flag", 10, "init --upgrade-modules", "terraform init -upgrade"}}},
+		{"terraform plan -target", []spanOf{{"terraform/missing-value", 15, "-target", ""}}},
+		{"terraform apply -var", []spanOf{{"terraform/missing-value", 16, "-var", ""}}},
+		{"terraform destroy --force", []spanOf{
+			{"terraform/unknown-flag", 10, "destroy --force", "terraform destroy -auto-approve"},
+			{"terraform/dangerous", 10, "destroy", ""},
+		}},
+		{"terraform taint aws_instance.web", []spanOf{{"terraform/dangerous", 10, "taint", ""}}},
+		{"terraform untaint aws_instance.web", nil},
+		// Spans after an alias shift back to the command as written
+		{"tf aply", []spanOf{{"terraform/unknown-subcommand", 3, "aply", "tf apply"}}},
+		{"tf destroy --force", []spanOf{
+			{"terraform/unknown-flag", 3, "destroy --force", "tf destroy -auto-approve"},
+			{"terraform/dangerous", 3, "destroy", ""},
+		}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
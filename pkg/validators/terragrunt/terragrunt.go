@@ -2,14 +2,41 @@ package terragrunt
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+	"github.com/amaslovskyi/ai-helper/pkg/validators/schema"
 )
 
 // Validator implements the Validator interface for terragrunt commands.
 type Validator struct {
+	validators.Environment
+
 	validSubcommands []string
 	dangerousOps     []string
+	mistakes         []validators.Mistake
+}
+
+// The redesigned CLI of terragrunt 0.77 (run --all, flags without the
+// --terragrunt- prefix) and the one before it
+const (
+	newCLI    = ">=0.77"
+	legacyCLI = "<0.77"
+)
+
+var redesigned = toolversion.MustParseRange(newCLI)
+
+// aliases are expanded before validation
+var aliases = map[string]string{"tg": "terragrunt"}
+
+func init() {
+	validators.Register(validators.Registration{
+		Name:    "terragrunt",
+		Tools:   []string{"terragrunt"},
+		Aliases: []string{"tg"},
+		New:     func() validators.Validator { return NewValidator() },
+	})
 }
 
 // NewValidator creates a new terragrunt validator.
@@ -20,6 +47,8 @@ func NewValidator() *Validator {
 			"run-all", "apply-all", "destroy-all", "plan-all", "output-all",
 			"validate-all", "graph-dependencies", "hclfmt", "aws-provider-patch",
 			"render-json", "validate-inputs", "graph",
+			"run", "exec", "stack", "find", "list", "dag", "backend", "info",
+			"catalog", "scaffold", "render", "hcl",
 			
 			// Terraform passthrough commands
 			"init", "plan", "apply", "destroy", "validate", "fmt", "force-unlock",
@@ -28,8 +57,36 @@ func NewValidator() *Validator {
 			"console", "test", "metadata",
 		},
 		dangerousOps: []string{
-			"destroy", "destroy-all", "force-unlock", "apply-all",
+			"destroy-all", "apply-all", "destroy", "force-unlock",
 		},
+		// Commonly hallucinated terragrunt flags, and those of the other CLI
+		mistakes: validators.CompileMistakes([]validators.Mistake{
+			{Pattern: `--all-modules`, Message: "terragrunt does not have --all-modules", Hint: "use the run-all subcommand instead", Versions: legacyCLI},
+			{Pattern: `--all-modules`, Message: "terragrunt does not have --all-modules", Hint: "use 'run --all' instead", Versions: newCLI},
+			{Pattern: `--recurse`, Message: "terragrunt does not have --recurse", Hint: "use the run-all subcommand instead", Versions: legacyCLI},
+			{Pattern: `--recurse`, Message: "terragrunt does not have --recurse", Hint: "use 'run --all' instead", Versions: newCLI},
+			{Pattern: `--force-yes`, Message: "terragrunt does not have --force-yes", Hint: "use -auto-approve for terraform commands", Fix: "-auto-approve"},
+			{Pattern: `--skip-validation`, Message: "terragrunt does not have --skip-validation", Hint: "remove it"},
+			{Pattern: `apply --target-all`, Message: "terragrunt apply does not have --target-all", Hint: "use apply-all or run-all apply", Fix: "run-all apply", Versions: legacyCLI},
+			{Pattern: `apply --target-all`, Message: "terragrunt apply does not have --target-all", Hint: "use 'run --all apply'", Fix: "run --all apply", Versions: newCLI},
+			{Pattern: `destroy --force`, Message: "terragrunt destroy does not have --force", Hint: "use -auto-approve instead", Fix: "destroy -auto-approve"},
+			{Pattern: `--skip-dependencies`, Message: "terragrunt does not have --skip-dependencies", Hint: "use --terragrunt-ignore-dependency-errors instead", Fix: "--terragrunt-ignore-dependency-errors", Versions: legacyCLI},
+			{Pattern: `--skip-dependencies`, Message: "terragrunt does not have --skip-dependencies", Hint: "use --queue-ignore-errors instead", Fix: "--queue-ignore-errors", Versions: newCLI},
+			{Pattern: `--parallel\b`, Message: "terragrunt does not have --parallel", Hint: "use --terragrunt-parallelism instead", Versions: legacyCLI},
+			{Pattern: `--parallel\b`, Message: "terragrunt does not have --parallel", Hint: "use --parallelism instead", Versions: newCLI},
+			{Pattern: `run-all --auto-approve`, Message: "run-all does not take --auto-approve", Hint: "use --terragrunt-non-interactive instead for run-all commands", Fix: "run-all --terragrunt-non-interactive", Versions: legacyCLI},
+			{Pattern: `run --all --auto-approve`, Message: "run --all does not take --auto-approve", Hint: "use --non-interactive instead", Fix: "run --all --non-interactive", Versions: newCLI},
+
+			// The redesigned CLI replaced run-all and the *-all commands with
+			// run --all, and dropped the --terragrunt- prefix of its flags
+			{Pattern: `run --all(\s|$)`, Message: "terragrunt before 0.77 has no 'run --all'", Hint: "use run-all", Fix: "run-all$1", Versions: legacyCLI},
+			{Pattern: `run-all(\s|$)`, Severity: validators.SeverityWarn, Message: "run-all is deprecated since terragrunt 0.77", Hint: "use 'run --all'", Fix: "run --all$1", Versions: newCLI},
+			{Pattern: `\b(apply|destroy|plan|output|validate)-all\b`, Message: "the *-all commands were removed in terragrunt 0.77", Hint: "use 'run --all' followed by the command", Fix: "run --all $1", Versions: newCLI},
+			{Pattern: `--terragrunt-(non-interactive|working-dir|config|parallelism|source|log-level|no-auto-init)\b`, Severity: validators.SeverityWarn, Message: "terragrunt 0.77 dropped the --terragrunt- prefix from its flags", Hint: "drop the prefix", Fix: "--$1", Versions: newCLI},
+			{Pattern: `--terragrunt-ignore-dependency-errors\b`, Severity: validators.SeverityWarn, Message: "--terragrunt-ignore-dependency-errors was renamed in terragrunt 0.77", Hint: "use --queue-ignore-errors", Fix: "--queue-ignore-errors", Versions: newCLI},
+			{Pattern: `--terragrunt-(include|exclude)-dir\b`, Severity: validators.SeverityWarn, Message: "terragrunt 0.77 renamed its include and exclude flags", Hint: "use --queue-include-dir or --queue-exclude-dir", Fix: "--queue-$1-dir", Versions: newCLI},
+			{Pattern: `--terragrunt-iam-role\b`, Severity: validators.SeverityWarn, Message: "--terragrunt-iam-role was renamed in terragrunt 0.77", Hint: "use --iam-assume-role", Fix: "--iam-assume-role", Versions: newCLI},
+		}),
 	}
 }
 
@@ -39,62 +96,44 @@ func (v *Validator) CanValidate(command string) bool {
 }
 
 // Validate checks if a terragrunt command is valid.
-func (v *Validator) Validate(command string) error {
-	if !strings.HasPrefix(command, "terragrunt") && !strings.HasPrefix(command, "tg ") {
+func (v *Validator) Validate(command string) []validators.Finding {
+	// Handle alias 'tg' for 'terragrunt'
+	expansion := validators.Expand(command, aliases)
+	command = expansion.Expanded
+
+	if !strings.HasPrefix(command, "terragrunt") {
 		return nil // Not a terragrunt command
 	}
 
-	// Check for common hallucinated flags
-	if err := v.checkHallucinatedFlags(command); err != nil {
-		return err
-	}
+	// Check for common hallucinated flags, and those of another version
+	version := toolversion.Lookup("terragrunt")
+	findings := validators.FindMistakes("terragrunt/unknown-flag", command, validators.ForVersion(v.mistakes, version))
 
-	// Check for invalid subcommands
-	if err := v.checkSubcommand(command); err != nil {
-		return err
+	// Check subcommands and flags against the terragrunt schema if there is
+	// one, otherwise against the known subcommands
+	if checked, ok := schema.Check("terragrunt", command); ok {
+		findings = validators.Merge(findings, checked)
+	} else if finding, ok := v.checkSubcommand(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for dangerous operations
-	if warning := v.checkDangerousOps(command); warning != "" {
-		return fmt.Errorf("⚠️  Warning: %s", warning)
+	if finding, ok := v.checkDangerousOps(command); ok {
+		findings = append(findings, finding)
 	}
 
 	// Check for common mistakes
-	if err := v.checkCommonMistakes(command); err != nil {
-		return err
-	}
+	findings = append(findings, v.checkCommonMistakes(command, version)...)
 
-	return nil
-}
-
-// checkHallucinatedFlags checks for commonly hallucinated terragrunt flags.
-func (v *Validator) checkHallucinatedFlags(command string) error {
-	hallucinatedPatterns := map[string]string{
-		`--all-modules`:          "terragrunt does not have --all-modules. Use run-all subcommand instead.",
-		`--recurse`:              "terragrunt does not have --recurse. Use run-all subcommand instead.",
-		`--force-yes`:            "terragrunt does not have --force-yes. Use -auto-approve for terraform commands.",
-		`--skip-validation`:      "terragrunt does not have --skip-validation.",
-		`apply --target-all`:     "terragrunt apply does not have --target-all. Use apply-all or run-all apply.",
-		`destroy --force`:        "terragrunt destroy does not have --force. Use -auto-approve instead.",
-		`--skip-dependencies`:    "Use --terragrunt-ignore-dependency-errors instead.",
-		`--parallel`:             "Use --terragrunt-parallelism instead.",
-		`run-all --auto-approve`: "Use --terragrunt-non-interactive instead for run-all commands.",
-	}
-
-	for pattern, message := range hallucinatedPatterns {
-		if matched, _ := regexp.MatchString(pattern, command); matched {
-			return fmt.Errorf("%s", message)
-		}
-	}
-
-	return nil
+	return expansion.Findings(findings)
 }
 
 // checkSubcommand validates the terragrunt subcommand.
-func (v *Validator) checkSubcommand(command string) error {
+func (v *Validator) checkSubcommand(command string) (validators.Finding, bool) {
 	parts := strings.Fields(command)
 	if len(parts) < 2 {
-		return fmt.Errorf("incomplete terragrunt command")
+		return validators.NewFinding("terragrunt/incomplete", validators.SeverityBlock, command, 0, len(command),
+			"incomplete terragrunt command", "add a subcommand, e.g. 'terragrunt plan'"), true
 	}
 
 	subcommand := parts[1]
@@ -109,46 +148,64 @@ func (v *Validator) checkSubcommand(command string) error {
 	}
 
 	if !isValid {
-		return fmt.Errorf("'%s' is not a valid terragrunt subcommand. Use 'terragrunt --help' to see valid commands.", subcommand)
+		start, end := validators.WordSpan(command, 1)
+		return validators.NewFinding("terragrunt/unknown-subcommand", validators.SeverityBlock, command, start, end,
+			fmt.Sprintf("'%s' is not a valid terragrunt subcommand", subcommand), "use 'terragrunt --help' to see valid commands").DidYouMean(subcommand, v.validSubcommands), true
 	}
 
-	return nil
+	return validators.Finding{}, false
 }
 
 // checkDangerousOps checks for dangerous terragrunt operations.
-func (v *Validator) checkDangerousOps(command string) string {
+func (v *Validator) checkDangerousOps(command string) (validators.Finding, bool) {
 	for _, danger := range v.dangerousOps {
-		if strings.Contains(command, danger) {
+		if i := validators.IndexWord(command, danger); i >= 0 {
 			if strings.Contains(danger, "-all") {
-				return fmt.Sprintf("EXTREMELY DANGEROUS: 'terragrunt %s' will affect ALL modules in the dependency tree. This can destroy entire environments!", danger)
+				return validators.NewFinding("terragrunt/dangerous", validators.SeverityWarn, command, i, i+len(danger),
+					fmt.Sprintf("EXTREMELY DANGEROUS: 'terragrunt %s' will affect ALL modules in the dependency tree. This can destroy entire environments!", danger)+v.ProductionNote(command),
+					"run it in a single module first"), true
 			}
-			return fmt.Sprintf("Dangerous operation detected: 'terragrunt %s'. This will modify or destroy infrastructure.", danger)
+			return validators.NewFinding("terragrunt/dangerous", validators.SeverityWarn, command, i, i+len(danger),
+				fmt.Sprintf("Dangerous operation detected: 'terragrunt %s'. This will modify or destroy infrastructure.", danger)+v.ProductionNote(command),
+				"review 'terragrunt plan' output first"), true
 		}
 	}
-	return ""
+	return validators.Finding{}, false
 }
 
 // checkCommonMistakes checks for common terragrunt command mistakes.
-func (v *Validator) checkCommonMistakes(command string) error {
-	// Check for using terraform flags that don't work with terragrunt
-	if strings.Contains(command, "run-all") && strings.Contains(command, "-target") {
-		return fmt.Errorf("-target doesn't work with run-all. Use it with individual apply/plan commands.")
-	}
+func (v *Validator) checkCommonMistakes(command string, version toolversion.Version) []validators.Finding {
+	var findings []validators.Finding
 
-	// Check for missing terragrunt-specific prefix
-	if matched, _ := regexp.MatchString(`--working-dir\s`, command); matched {
-		return fmt.Errorf("did you mean --terragrunt-working-dir?")
+	// An unknown version is taken from the syntax the command uses
+	runAll, nonInteractive := "run-all", "--terragrunt-non-interactive"
+	if redesigned.Contains(version) || (version.IsZero() && strings.Contains(command, "run --all")) {
+		runAll, nonInteractive = "run --all", "--non-interactive"
 	}
 
-	if matched, _ := regexp.MatchString(`--config\s`, command); matched {
-		return fmt.Errorf("did you mean --terragrunt-config?")
+	// Check for using terraform flags that don't work with terragrunt
+	if strings.Contains(command, runAll) {
+		if i := strings.Index(command, "-target"); i >= 0 {
+			findings = append(findings, validators.NewFinding("terragrunt/run-all-target", validators.SeverityBlock, command, i, i+len("-target"),
+				fmt.Sprintf("-target doesn't work with %s", runAll), "use it with individual apply/plan commands"))
+		}
 	}
 
+	// Check for missing terragrunt-specific prefix
+	findings = append(findings, validators.FindMistakes("terragrunt/missing-prefix", command, validators.ForVersion(prefixMistakes, version))...)
+
 	// Warn about run-all without proper flags
-	if strings.Contains(command, "run-all apply") && !strings.Contains(command, "--terragrunt-non-interactive") {
-		return fmt.Errorf("⚠️  run-all apply without --terragrunt-non-interactive will prompt for each module. Consider adding this flag.")
+	if strings.Contains(command, runAll+" apply") && !strings.Contains(command, nonInteractive) {
+		findings = append(findings, validators.NewFinding("terragrunt/interactive-run-all", validators.SeverityWarn, command, len(command), len(command),
+			fmt.Sprintf("%s apply without %s will prompt for each module", runAll, nonInteractive), "consider adding this flag").WithFix(" "+nonInteractive))
 	}
 
-	return nil
+	return findings
 }
 
+// prefixMistakes are terragrunt flags written without the --terragrunt-
+// prefix they needed before 0.77
+var prefixMistakes = validators.CompileMistakes([]validators.Mistake{
+	{Pattern: `--working-dir(\s)`, Message: "terragrunt does not have --working-dir", Hint: "did you mean --terragrunt-working-dir?", Fix: "--terragrunt-working-dir$1", Versions: legacyCLI},
+	{Pattern: `--config(\s)`, Message: "terragrunt does not have --config", Hint: "did you mean --terragrunt-config?", Fix: "--terragrunt-config$1", Versions: legacyCLI},
+})
@@ -0,0 +1,59 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/validators"
+)
+
+// spanOf describes a finding by its code, where its span starts, the text it
+// covers ("" at the end of the command) and its correction
+type spanOf struct {
+	code       string
+	start      int
+	text       string
+	correction string
+}
+
+// describe lists the findings as spanOf values
+func describe(findings []validators.Finding) []spanOf {
+	var spans []spanOf
+	for _, f := range findings {
+		spans = append(spans, spanOf{f.Code, f.Span.Start, f.Command[f.Span.Start:f.Span.End], f.Correction})
+	}
+	return spans
+}
+
+func TestValidateSpans(t *testing.T) {
+	// Without the tool on PATH only the rules for every version apply
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		command string
+		want    []spanOf
+	}{
+		{"terragrunt aply", []spanOf{{"terragrunt/unknown-subcommand", 11, "aply", "terragrunt apply"}}},
+		{"terragrunt plan --force-yes", []spanOf{{"terragrunt/unknown-flag", 16, "--force-yes", "terragrunt plan -auto-approve"}}},
+		{"terragrunt destroy --force", []spanOf{
+			{"terragrunt/unknown-flag", 11, "destroy --force", "terragrunt destroy -auto-approve"},
+			{"terragrunt/dangerous", 11, "destroy", ""},
+		}},
+		{"terragrunt destroy-all", []spanOf{{"terragrunt/dangerous", 11, "destroy-all", ""}}},
+		{"terragrunt force-unlock 123", []spanOf{{"terragrunt/dangerous", 11, "force-unlock", ""}}},
+		{"terragrunt run-all apply", []spanOf{{"terragrunt/interactive-run-all", 24, "", "terragrunt run-all apply --terragrunt-non-interactive"}}},
+		{"terragrunt run --all apply -target=x", []spanOf{
+			{"terragrunt/run-all-target", 27, "-target", ""},
+			{"terragrunt/interactive-run-all", 36, "", "terragrunt run --all apply -target=x --non-interactive"},
+		}},
+		// Spans after an alias shift back to the command as written
+		{"tg run-all apply", []spanOf{{"terragrunt/interactive-run-all", 16, "", "tg run-all apply --terragrunt-non-interactive"}}},
+	}
+
+	v := NewValidator()
+	for _, tt := range tests {
+		if got := describe(v.Validate(tt.command)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -1,33 +1,325 @@
 package validators
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
+)
 
 // Validator interface for command validation
 type Validator interface {
-	// Validate checks if a command is valid
-	Validate(command string) error
-	
+	// Validate checks a command and returns what is wrong with it
+	// (nil if nothing is)
+	Validate(command string) []Finding
+
 	// CanValidate returns true if this validator can handle the command
 	CanValidate(command string) bool
 }
 
-// ValidationError represents a validation failure
-type ValidationError struct {
+// Severity says how much a finding matters
+type Severity string
+
+const (
+	// SeverityInfo is worth knowing; the command is fine as it is
+	SeverityInfo Severity = "info"
+
+	// SeverityWarn is a valid command that is risky or probably not
+	// what was meant
+	SeverityWarn Severity = "warn"
+
+	// SeverityBlock is a command that is invalid and should not be run
+	SeverityBlock Severity = "block"
+)
+
+// severityRank orders severities from least to most severe
+var severityRank = map[Severity]int{"": 0, SeverityInfo: 1, SeverityWarn: 2, SeverityBlock: 3}
+
+// Rank returns a number that orders severities (higher is more severe)
+func (s Severity) Rank() int {
+	return severityRank[s]
+}
+
+// Span is the byte range [Start, End) of a finding in its command
+type Span struct {
+	Start int
+	End   int
+}
+
+// Finding is one problem a validator found in a command
+type Finding struct {
+	Code     string // Stable identifier, e.g. "kubectl/unknown-flag"
+	Severity Severity
+	Message  string
+	Hint     string
+
+	// Command is the command that was validated; Span locates the
+	// problem in it
 	Command string
-	Reason  string
-	Hint    string
+	Span    Span
+
+	// Offset is where Command starts in the line given to ValidateLine
+	// (-1 if the line does not contain it verbatim)
+	Offset int
+
+	// Correction is the command with the problem fixed ("" if there is
+	// no automatic fix)
+	Correction string
+}
+
+// NewFinding returns a finding for command[start:end]
+func NewFinding(code string, severity Severity, command string, start, end int, message, hint string) Finding {
+	return Finding{
+		Code:     code,
+		Severity: severity,
+		Message:  message,
+		Hint:     hint,
+		Command:  command,
+		Span:     Span{Start: start, End: end},
+	}
+}
+
+// WithFix returns the finding with a correction that replaces its span
+// with replacement
+func (f Finding) WithFix(replacement string) Finding {
+	f.Correction = f.Command[:f.Span.Start] + replacement + f.Command[f.Span.End:]
+	return f
+}
+
+// replacement returns the text that the correction puts in place of the span
+func (f Finding) replacement() string {
+	return f.Correction[f.Span.Start : len(f.Correction)-(len(f.Command)-f.Span.End)]
+}
+
+// String formats the finding as "message (hint: ...)"
+func (f Finding) String() string {
+	if f.Hint == "" {
+		return f.Message
+	}
+	return fmt.Sprintf("%s (hint: %s)", f.Message, f.Hint)
+}
+
+// WordSpan returns the byte range of the n-th whitespace-separated word of
+// command (the end of the command if there are fewer words)
+func WordSpan(command string, n int) (int, int) {
+	i := 0
+	for word := 0; ; word++ {
+		for i < len(command) && (command[i] == ' ' || command[i] == '\t') {
+			i++
+		}
+		start := i
+		for i < len(command) && command[i] != ' ' && command[i] != '\t' {
+			i++
+		}
+		if word == n || i == start {
+			return start, i
+		}
+	}
+}
+
+// IndexWord returns the index of the first occurrence of words in command
+// that is not part of a longer word ("taint" in "untaint", "destroy" in
+// "destroy-all"), or -1 if there is none
+func IndexWord(command, words string) int {
+	for from := 0; ; {
+		i := strings.Index(command[from:], words)
+		if i < 0 {
+			return -1
+		}
+		start := from + i
+		if wordBoundary(command, start, start+len(words)) {
+			return start
+		}
+		from = start + 1
+	}
+}
+
+// Mistake is a known hallucination or typo. Fix, if set, replaces the
+// text matched by Pattern ($1 expands submatches).
+type Mistake struct {
+	Pattern  string
+	Severity Severity // Defaults to block
+	Message  string
+	Hint     string
+	Fix      string
+	Versions string // Tool versions it is a mistake in, e.g. ">=0.77" (all if empty)
+
+	re       *regexp.Regexp
+	versions toolversion.Range
+}
+
+// CompileMistakes compiles the patterns and version ranges of a list of
+// mistakes
+func CompileMistakes(mistakes []Mistake) []Mistake {
+	for i := range mistakes {
+		mistakes[i].re = regexp.MustCompile(mistakes[i].Pattern)
+		mistakes[i].versions = toolversion.MustParseRange(mistakes[i].Versions)
+		if mistakes[i].Severity == "" {
+			mistakes[i].Severity = SeverityBlock
+		}
+	}
+	return mistakes
 }
 
-func (e *ValidationError) Error() string {
-	return fmt.Sprintf("invalid command: %s (hint: %s)", e.Reason, e.Hint)
+// Merge appends more to findings, leaving out those whose span overlaps a
+// finding already there (a generic check repeating a specific one)
+func Merge(findings, more []Finding) []Finding {
+	merged := findings
+	for _, m := range more {
+		duplicate := false
+		for _, f := range findings {
+			if f.Command == m.Command && m.Span.Start < f.Span.End && f.Span.Start < m.Span.End {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, m)
+		}
+	}
+	return merged
+}
+
+// FindMistakes returns a finding with the given code for every mistake
+// that matches command
+func FindMistakes(code, command string, mistakes []Mistake) []Finding {
+	var findings []Finding
+	for _, mistake := range mistakes {
+		loc := mistake.re.FindStringSubmatchIndex(command)
+		if loc == nil {
+			continue
+		}
+
+		finding := NewFinding(code, mistake.Severity, command, loc[0], loc[1], mistake.Message, mistake.Hint)
+		if mistake.Fix != "" {
+			finding = finding.WithFix(string(mistake.re.ExpandString(nil, mistake.Fix, command, loc)))
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// ForVersion returns the mistakes that are mistakes in version of the
+// tool. If the version is unknown only the mistakes in every version are
+// returned: advice for the wrong release is worse than none.
+func ForVersion(mistakes []Mistake, version toolversion.Version) []Mistake {
+	var matching []Mistake
+	for _, mistake := range mistakes {
+		if version.IsZero() && mistake.Versions != "" {
+			continue
+		}
+		if mistake.versions.Contains(version) {
+			matching = append(matching, mistake)
+		}
+	}
+	return matching
+}
+
+// Expansion is a command whose first word was expanded from an alias
+// (k → kubectl, gco → git checkout). Validators check the expanded form
+// and map their findings back to the command as written.
+type Expansion struct {
+	Command  string // As written
+	Expanded string
+
+	// The first word spans [start, alias) as written and [start, full)
+	// once expanded
+	start, alias, full int
+}
+
+// Expand expands the first word of command if it is one of aliases
+func Expand(command string, aliases map[string]string) Expansion {
+	e := Expansion{Command: command, Expanded: command}
+	start, end := WordSpan(command, 0)
+	if full, ok := aliases[command[start:end]]; ok {
+		e.Expanded = command[:start] + full + command[end:]
+		e.start, e.alias, e.full = start, end, start+len(full)
+	}
+	return e
+}
+
+// Finding maps a finding on the expanded command back to the command as
+// written. A span that touches the expansion is widened to the whole
+// alias, and so is its correction.
+func (e Expansion) Finding(f Finding) Finding {
+	if e.Expanded == e.Command {
+		return f
+	}
+
+	mapped := f
+	mapped.Command = e.Command
+
+	start, end := f.Span.Start, f.Span.End
+	prefix, suffix := "", ""
+	if start < e.full {
+		prefix = e.Expanded[e.start:start]
+		start = e.start
+	} else {
+		start += e.alias - e.full
+	}
+	if end < e.full {
+		suffix = e.Expanded[end:e.full]
+		end = e.alias
+	} else {
+		end += e.alias - e.full
+	}
+	mapped.Span = Span{Start: start, End: end}
+
+	if f.Correction != "" {
+		mapped = mapped.WithFix(prefix + f.replacement() + suffix)
+	}
+	return mapped
 }
 
-// NewValidationError creates a new validation error
-func NewValidationError(command, reason, hint string) *ValidationError {
-	return &ValidationError{
-		Command: command,
-		Reason:  reason,
-		Hint:    hint,
+// Findings maps every finding back to the command as written
+func (e Expansion) Findings(findings []Finding) []Finding {
+	for i := range findings {
+		findings[i] = e.Finding(findings[i])
 	}
+	return findings
+}
+
+// EnvironmentAware is implemented by validators whose checks depend on
+// where a command runs (kube context, terraform workspace, AWS profile)
+type EnvironmentAware interface {
+	SetEnvironment(env *envctx.Environment, productionPatterns []string)
+}
+
+// Environment is embedded by validators to implement EnvironmentAware
+type Environment struct {
+	env        *envctx.Environment
+	production []string
 }
 
+// SetEnvironment records the detected environment and production patterns
+func (e *Environment) SetEnvironment(env *envctx.Environment, productionPatterns []string) {
+	e.env = env
+	e.production = productionPatterns
+}
+
+// ProductionNote returns a sentence naming the production environments the
+// command targets, or "" if it targets none (or no environment is set)
+func (e *Environment) ProductionNote(command string) string {
+	if e.env == nil {
+		return ""
+	}
+
+	// Leading NAME=value words are assignments for the command
+	fields := strings.Fields(command)
+	assigns := 0
+	for assigns < len(fields) && strings.Contains(fields[assigns], "=") && !strings.HasPrefix(fields[assigns], "-") {
+		assigns++
+	}
+	if assigns == len(fields) {
+		return ""
+	}
+	words := strings.Fields(NewAliasMapper().ResolveAlias(strings.Join(fields[assigns:], " ")))
+
+	targets := e.env.Production(words[0], words[1:], fields[:assigns], e.production)
+	if len(targets) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" Target is PRODUCTION (%s).", envctx.DescribeTargets(targets))
+}
@@ -0,0 +1,112 @@
+package validators
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amaslovskyi/ai-helper/pkg/envctx"
+	"github.com/amaslovskyi/ai-helper/pkg/toolversion"
+)
+
+func TestForVersion(t *testing.T) {
+	mistakes := CompileMistakes([]Mistake{
+		{Pattern: `\brun-all\b`, Message: "run-all was replaced by run --all", Versions: ">=0.77"},
+		{Pattern: `\brun --all\b`, Message: "run --all needs terragrunt 0.77", Versions: "<0.77"},
+		{Pattern: `\bplan-all\b`, Message: "plan-all was removed"},
+	})
+
+	tests := []struct {
+		version string // "" for unknown
+		want    []string
+	}{
+		{"0.80.1", []string{"run-all was replaced by run --all", "plan-all was removed"}},
+		{"0.76.0", []string{"run --all needs terragrunt 0.77", "plan-all was removed"}},
+
+		// Rules for a particular release are skipped rather than guessed
+		{"", []string{"plan-all was removed"}},
+	}
+
+	for _, tt := range tests {
+		var version toolversion.Version
+		if tt.version != "" {
+			version = toolversion.MustParse(tt.version)
+		}
+
+		var got []string
+		for _, mistake := range ForVersion(mistakes, version) {
+			got = append(got, mistake.Message)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ForVersion(%s) = %q, want %q", version, got, tt.want)
+		}
+	}
+}
+
+func TestFindMistakes(t *testing.T) {
+	mistakes := CompileMistakes([]Mistake{
+		{Pattern: `\bkubectl logs --follow-all\b`, Message: "no such flag", Hint: "use -f", Fix: "kubectl logs -f"},
+		{Pattern: `\bkubectl get pod\b`, Severity: SeverityInfo, Message: "prefer pods"},
+	})
+
+	findings := FindMistakes("kubectl/mistake", "kubectl logs --follow-all web", mistakes)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Severity != SeverityBlock || f.Code != "kubectl/mistake" {
+		t.Errorf("finding = %+v, want a kubectl/mistake block", f)
+	}
+	if f.Correction != "kubectl logs -f web" {
+		t.Errorf("Correction = %q, want kubectl logs -f web", f.Correction)
+	}
+
+	if findings := FindMistakes("kubectl/mistake", "kubectl get pods", mistakes); len(findings) != 0 {
+		t.Errorf("kubectl get pods matched %+v", findings)
+	}
+}
+
+func TestProductionNote(t *testing.T) {
+	var e Environment
+	e.SetEnvironment(&envctx.Environment{AWSProfile: "dev", KubeContext: "staging"}, envctx.DefaultProductionPatterns)
+
+	tests := []struct {
+		command string
+		want    bool // Whether a production note is expected
+	}{
+		{"aws s3 ls", false},
+		{"aws s3 ls --profile prod", true},
+		{"AWS_PROFILE=prod aws s3 ls", true},
+		{"AWS_PROFILE=prod", false},
+		{"kubectl get pods", false},
+		{"k delete pod x --context prod-eu", true},
+	}
+
+	for _, tt := range tests {
+		if got := e.ProductionNote(tt.command); (got != "") != tt.want {
+			t.Errorf("ProductionNote(%q) = %q, want a note: %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestIndexWord(t *testing.T) {
+	tests := []struct {
+		command, words string
+		want           int
+	}{
+		{"terraform taint aws_instance.web", "taint", 10},
+		{"terraform untaint aws_instance.web", "taint", -1},
+		{"terragrunt destroy-all", "destroy", -1},
+		{"terragrunt destroy-all", "destroy-all", 11},
+		{"kubectl delete pod delete-me", "delete", 8},
+		{"kubectl get pods --selector=app=delete-me; kubectl delete pod x", "delete", 51},
+		{"kubectl delete namespace prod", "delete namespace", 8},
+		{"kubectl delete namespaces", "delete namespace", -1},
+		{"", "delete", -1},
+	}
+
+	for _, tt := range tests {
+		if got := IndexWord(tt.command, tt.words); got != tt.want {
+			t.Errorf("IndexWord(%q, %q) = %d, want %d", tt.command, tt.words, got, tt.want)
+		}
+	}
+}